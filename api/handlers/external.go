@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMovieByIMDbID handles GET /movies/by-imdb/:imdb_id
+func (h *Handler) GetMovieByIMDbID(c *gin.Context) {
+	movie, err := h.repo.GetMovieByIMDbID(c, c.Param("imdb_id"))
+	if err != nil {
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	movie.Localize(languages(c))
+	c.JSON(http.StatusOK, movie)
+}
+
+// GetMovieByTMDBID handles GET /movies/by-tmdb/:tmdb_id
+func (h *Handler) GetMovieByTMDBID(c *gin.Context) {
+	tmdbID, ok := tmdbIDParam(c)
+	if !ok {
+		return
+	}
+
+	movie, err := h.repo.GetMovieByTMDBID(c, tmdbID)
+	if err != nil {
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	movie.Localize(languages(c))
+	c.JSON(http.StatusOK, movie)
+}
+
+// GetTVByIMDbID handles GET /tv/by-imdb/:imdb_id
+func (h *Handler) GetTVByIMDbID(c *gin.Context) {
+	tv, err := h.repo.GetTVByIMDbID(c, c.Param("imdb_id"))
+	if err != nil {
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	stripSources(tv)
+	tv.Localize(languages(c))
+	c.JSON(http.StatusOK, tv)
+}
+
+// GetTVByTMDBID handles GET /tv/by-tmdb/:tmdb_id
+func (h *Handler) GetTVByTMDBID(c *gin.Context) {
+	tmdbID, ok := tmdbIDParam(c)
+	if !ok {
+		return
+	}
+
+	tv, err := h.repo.GetTVByTMDBID(c, tmdbID)
+	if err != nil {
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	stripSources(tv)
+	tv.Localize(languages(c))
+	c.JSON(http.StatusOK, tv)
+}
+
+// GetTVEpisodeByTMDBID handles GET /tv/by-tmdb/:tmdb_id/:season/:episode
+func (h *Handler) GetTVEpisodeByTMDBID(c *gin.Context) {
+	tmdbID, ok := tmdbIDParam(c)
+	if !ok {
+		return
+	}
+
+	seasonNum, err := strconv.Atoi(c.Param("season"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "season must be a number"})
+		return
+	}
+
+	episodeNum, err := strconv.Atoi(c.Param("episode"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "episode must be a number"})
+		return
+	}
+
+	tv, err := h.repo.GetTVByTMDBID(c, tmdbID)
+	if err != nil {
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	episode, err := h.repo.GetTVEpisodeById(c, tv.TVID, seasonNum, episodeNum)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	episode.Localize(languages(c))
+	c.JSON(http.StatusOK, episode)
+}
+
+func tmdbIDParam(c *gin.Context) (int, bool) {
+	tmdbID, err := strconv.Atoi(c.Param("tmdb_id"))
+	if err != nil || tmdbID <= 0 {
+		c.JSON(400, gin.H{"error": "tmdb_id must be a positive number"})
+		return 0, false
+	}
+	return tmdbID, true
+}
+
+func lookupErrorStatus(err error) int {
+	if errors.Is(err, repository.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// stripSources drops episode sources, like GetTVById does, to keep show
+// details small
+func stripSources(tv *models.TV) {
+	for i := range tv.Seasons {
+		for j := range tv.Seasons[i].Episodes {
+			tv.Seasons[i].Episodes[j].Sources = nil
+		}
+	}
+}
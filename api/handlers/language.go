@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/gin-gonic/gin"
+)
+
+// languages returns the languages the client asked for, most preferred
+// first: the comma separated ?lang= parameter, or else the Accept-Language
+// header. Titles are localized to the first of them with a translation and
+// stay English otherwise.
+func languages(c *gin.Context) []string {
+	c.Header("Vary", "Accept-Language")
+	if lang := c.Query("lang"); lang != "" {
+		var result []string
+		for _, code := range strings.Split(lang, ",") {
+			if code = normalizeLanguage(code); code != "" {
+				result = append(result, code)
+			}
+		}
+		return result
+	}
+	return parseAcceptLanguage(c.GetHeader("Accept-Language"))
+}
+
+// parseAcceptLanguage orders the languages of an Accept-Language header by
+// their q value. Wildcards and languages with q=0 are dropped.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		code string
+		q    float64
+	}
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		code, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if code = normalizeLanguage(code); code == "" || code == "*" || q <= 0 {
+			continue
+		}
+		entries = append(entries, weighted{code: code, q: q})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+
+	result := make([]string, len(entries))
+	for i, entry := range entries {
+		result[i] = entry.code
+	}
+	return result
+}
+
+// normalizeLanguage writes a code the way localizations are keyed, e.g.
+// "pt-br" as "pt-BR"
+func normalizeLanguage(code string) string {
+	code = strings.TrimSpace(code)
+	base, region, hasRegion := strings.Cut(code, "-")
+	if !hasRegion {
+		return strings.ToLower(base)
+	}
+	return strings.ToLower(base) + "-" + strings.ToUpper(region)
+}
+
+func localizeMovies(movies []models.Movie, languages []string) {
+	for i := range movies {
+		movies[i].Localize(languages)
+	}
+}
+
+func localizeTVShows(tvShows []models.TV, languages []string) {
+	for i := range tvShows {
+		tvShows[i].Localize(languages)
+	}
+}
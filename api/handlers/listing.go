@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// ListMovies handles GET /movies. Requests with a query parameter are
+// searches, as they were before listing existed.
+func (h *Handler) ListMovies(c *gin.Context) {
+	if c.Query("query") != "" {
+		h.GetMoviesByQuery(c)
+		return
+	}
+
+	var params queryParams
+	filter := repository.MovieFilter{
+		GenreID:        params.int(c, "genre"),
+		YearFrom:       params.int(c, "year_from"),
+		YearTo:         params.int(c, "year_to"),
+		MinVoteAverage: params.float(c, "min_vote_average"),
+		MinVoteCount:   params.int(c, "min_vote_count"),
+		RuntimeMin:     params.int(c, "runtime_min"),
+		RuntimeMax:     params.int(c, "runtime_max"),
+		Sort:           repository.SortField(c.Query("sort")),
+		Cursor:         c.Query("cursor"),
+		Limit:          int64(params.int(c, "limit")),
+	}
+	if year := params.int(c, "year"); year > 0 {
+		filter.YearFrom, filter.YearTo = year, year
+	}
+	if params.err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": params.err.Error()})
+		return
+	}
+
+	page, err := h.repo.ListMovies(c, filter)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	localizeMovies(page.Results, languages(c))
+	c.JSON(http.StatusOK, page)
+}
+
+// ListTV handles GET /tv
+func (h *Handler) ListTV(c *gin.Context) {
+	var params queryParams
+	filter := repository.TVFilter{
+		GenreID:        params.int(c, "genre"),
+		YearFrom:       params.int(c, "year_from"),
+		YearTo:         params.int(c, "year_to"),
+		MinVoteAverage: params.float(c, "min_vote_average"),
+		MinVoteCount:   params.int(c, "min_vote_count"),
+		Status:         c.Query("status"),
+		NetworkID:      params.int(c, "network"),
+		Sort:           repository.SortField(c.Query("sort")),
+		Cursor:         c.Query("cursor"),
+		Limit:          int64(params.int(c, "limit")),
+	}
+	if year := params.int(c, "year"); year > 0 {
+		filter.YearFrom, filter.YearTo = year, year
+	}
+	if filter.Sort == "first_air_date" {
+		filter.Sort = repository.SortReleaseDate
+	}
+	if params.err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": params.err.Error()})
+		return
+	}
+
+	page, err := h.repo.ListTV(c, filter)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	localizeTVShows(page.Results, languages(c))
+	c.JSON(http.StatusOK, page)
+}
+
+func listErrorStatus(err error) int {
+	if errors.Is(err, repository.ErrInvalidCursor) || errors.Is(err, repository.ErrUnknownSort) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// queryParams parses optional numeric query parameters, keeping the first
+// error so a handler can check once after reading all of them
+type queryParams struct {
+	err error
+}
+
+func (p *queryParams) int(c *gin.Context, name string) int {
+	raw := c.Query(name)
+	if raw == "" || p.err != nil {
+		return 0
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		p.err = fmt.Errorf("%s must be a non-negative integer", name)
+		return 0
+	}
+	return value
+}
+
+func (p *queryParams) float(c *gin.Context, name string) float64 {
+	raw := c.Query(name)
+	if raw == "" || p.err != nil {
+		return 0
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		p.err = fmt.Errorf("%s must be a non-negative number", name)
+		return 0
+	}
+	return value
+}
@@ -5,15 +5,19 @@ import (
 	"strconv"
 
 	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/tmdb"
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	mongo *repository.MongoRepo
+	repo repository.Repository
+	sync *tmdb.SyncService
 }
 
-func NewHandler(db *repository.MongoRepo) *Handler {
-	return &Handler{mongo: db}
+// NewHandler creates the API handlers. sync is used to pin TMDB matches and
+// may be nil when no TMDB API key is configured.
+func NewHandler(repo repository.Repository, sync *tmdb.SyncService) *Handler {
+	return &Handler{repo: repo, sync: sync}
 }
 
 func (h *Handler) GetMovieById(c *gin.Context) {
@@ -23,11 +27,12 @@ func (h *Handler) GetMovieById(c *gin.Context) {
 		return
 	}
 
-	movie, err := h.mongo.GetMovieById(c, id)
+	movie, err := h.repo.GetMovieById(c, id)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	movie.Localize(languages(c))
 	c.JSON(http.StatusOK, movie)
 }
 
@@ -38,11 +43,12 @@ func (h *Handler) GetMoviesByQuery(c *gin.Context) {
 		return
 	}
 
-	movies, err := h.mongo.SearchMovieByQuery(c, query)
+	movies, err := h.repo.SearchMovieByQuery(c, query)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	localizeMovies(movies, languages(c))
 	c.JSON(http.StatusOK, movies)
 }
 
@@ -53,11 +59,12 @@ func (h *Handler) GetTVById(c *gin.Context) {
 		return
 	}
 
-	tv, err := h.mongo.GetTVById(c, id)
+	tv, err := h.repo.GetTVById(c, id)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	tv.Localize(languages(c))
 	c.JSON(http.StatusOK, tv)
 }
 
@@ -77,12 +84,13 @@ func (h *Handler) GetTVSeasonById(c *gin.Context) {
 		return
 	}
 
-	season, err := h.mongo.GetTVSeasonById(c, id, seasonNum)
+	season, err := h.repo.GetTVSeasonById(c, id, seasonNum)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	season.Localize(languages(c))
 	c.JSON(http.StatusOK, season)
 }
 
@@ -109,12 +117,13 @@ func (h *Handler) GetTVEpisodeById(c *gin.Context) {
 		return
 	}
 
-	episode, err := h.mongo.GetTVEpisodeById(c, id, seasonNum, episodeNum)
+	episode, err := h.repo.GetTVEpisodeById(c, id, seasonNum, episodeNum)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	episode.Localize(languages(c))
 	c.JSON(http.StatusOK, episode)
 }
 
@@ -125,37 +134,11 @@ func (h *Handler) GetTVByQuery(c *gin.Context) {
 		return
 	}
 
-	tvShows, err := h.mongo.SearchTVByQuery(c, query)
+	tvShows, err := h.repo.SearchTVByQuery(c, query)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, tvShows)
-}
-
-func (h *Handler) GetAllTVShows(c *gin.Context) {
-	limit := 20
-	skip := 0
-
-	if c.Query("limit") != "" {
-		limitInt, err := strconv.Atoi(c.Query("limit"))
-		if err == nil && limitInt > 0 {
-			limit = limitInt
-		}
-	}
-
-	if c.Query("skip") != "" {
-		skipInt, err := strconv.Atoi(c.Query("skip"))
-		if err == nil && skipInt >= 0 {
-			skip = skipInt
-		}
-	}
-
-	tvShows, err := h.mongo.GetAllTVShows(c, int64(limit), int64(skip))
-	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-
+	localizeTVShows(tvShows, languages(c))
 	c.JSON(http.StatusOK, tvShows)
 }
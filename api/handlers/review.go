@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
+	"github.com/gin-gonic/gin"
+)
+
+// pinRequest is the body of PUT /movies/:id/pin and /tv/:id/pin
+type pinRequest struct {
+	TMDBID int `json:"tmdb_id" binding:"required,gt=0"`
+}
+
+// ListMovieReviews handles GET /reviews/movies: the movies whose TMDB match
+// needs review, with their scored candidates. ?status=unmatched lists the
+// ones without any acceptable match instead.
+func (h *Handler) ListMovieReviews(c *gin.Context) {
+	status, ok := reviewStatus(c)
+	if !ok {
+		return
+	}
+	var params queryParams
+	filter := repository.MovieFilter{
+		MatchStatus: status,
+		Cursor:      c.Query("cursor"),
+		Limit:       int64(params.int(c, "limit")),
+	}
+	if params.err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": params.err.Error()})
+		return
+	}
+
+	page, err := h.repo.ListMovies(c, filter)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ListTVReviews handles GET /reviews/tv, like ListMovieReviews
+func (h *Handler) ListTVReviews(c *gin.Context) {
+	status, ok := reviewStatus(c)
+	if !ok {
+		return
+	}
+	var params queryParams
+	filter := repository.TVFilter{
+		MatchStatus: status,
+		Cursor:      c.Query("cursor"),
+		Limit:       int64(params.int(c, "limit")),
+	}
+	if params.err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": params.err.Error()})
+		return
+	}
+
+	page, err := h.repo.ListTV(c, filter)
+	if err != nil {
+		c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// PinMovie handles PUT /movies/:id/pin with {"tmdb_id": 603}. The movie's
+// details are synced from that TMDB ID right away and future syncs keep it.
+func (h *Handler) PinMovie(c *gin.Context) {
+	var req pinRequest
+	if !h.bindPin(c, &req) {
+		return
+	}
+
+	movie, err := h.repo.GetMovieById(c, c.Param("id"))
+	if err != nil {
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.sync.PinMovie(c, movie, req.TMDBID); err != nil {
+		c.JSON(pinErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, movie)
+}
+
+// PinTV handles PUT /tv/:id/pin, like PinMovie
+func (h *Handler) PinTV(c *gin.Context) {
+	var req pinRequest
+	if !h.bindPin(c, &req) {
+		return
+	}
+
+	tv, err := h.repo.GetFullTVById(c, c.Param("id"))
+	if err != nil {
+		c.JSON(lookupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.sync.PinTV(c, tv, req.TMDBID); err != nil {
+		c.JSON(pinErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	stripSources(tv)
+	c.JSON(http.StatusOK, tv)
+}
+
+func (h *Handler) bindPin(c *gin.Context, req *pinRequest) bool {
+	if h.sync == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "pinning needs a TMDB API key (tmdb.api_key)"})
+		return false
+	}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body must be {\"tmdb_id\": <positive number>}"})
+		return false
+	}
+	return true
+}
+
+// pinErrorStatus answers 404 for TMDB IDs TMDB doesn't know, and 502 for
+// every other failure to reach TMDB
+func pinErrorStatus(err error) int {
+	if errors.Is(err, upstream.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadGateway
+}
+
+func reviewStatus(c *gin.Context) (string, bool) {
+	switch status := c.DefaultQuery("status", models.MatchNeedsReview); status {
+	case models.MatchNeedsReview, models.MatchUnmatched:
+		return status, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be needs_review or unmatched"})
+		return "", false
+	}
+}
@@ -0,0 +1,48 @@
+// Package api wires the HTTP handlers and the Stremio addon into the router
+// served by `showbox serve`.
+package api
+
+import (
+	"github.com/amankumarsingh77/go-showbox-api/api/handlers"
+	"github.com/amankumarsingh77/go-showbox-api/api/stremio"
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/db/utils"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/tmdb"
+	"github.com/gin-gonic/gin"
+)
+
+// NewRouter returns the router serving the movie and TV endpoints from repo.
+// streamer refreshes expired links before the Stremio addon hands them out.
+// sync pins TMDB matches from the review routes; without it they answer 503.
+func NewRouter(repo repository.Repository, streamer *utils.Streamer, sync *tmdb.SyncService) *gin.Engine {
+	handlers := handlers.NewHandler(repo, sync)
+
+	r := gin.Default()
+	r.GET("/movies/search", handlers.GetMoviesByQuery)
+	r.GET("/movies/by-imdb/:imdb_id", handlers.GetMovieByIMDbID)
+	r.GET("/movies/by-tmdb/:tmdb_id", handlers.GetMovieByTMDBID)
+	r.GET("/movies/:id", handlers.GetMovieById)
+	r.GET("/movies", handlers.ListMovies) // Filterable, paginated list; ?query= still searches
+
+	// TV routes with nested structure
+	r.GET("/tv/search", handlers.GetTVByQuery)                   // Search TV shows (no path parameter conflict)
+	r.GET("/tv", handlers.ListTV)                                // Filterable, paginated list of TV shows
+	r.GET("/tv/:id/:season/:episode", handlers.GetTVEpisodeById) // Get episode details with links (most specific route)
+	r.GET("/tv/:id/:season", handlers.GetTVSeasonById)           // Get season details without links (more specific route)
+	r.GET("/tv/:id", handlers.GetTVById)                         // Get TV details without links (least specific)
+
+	// Lookups by external ID, episodes come with links
+	r.GET("/tv/by-imdb/:imdb_id", handlers.GetTVByIMDbID)
+	r.GET("/tv/by-tmdb/:tmdb_id", handlers.GetTVByTMDBID)
+	r.GET("/tv/by-tmdb/:tmdb_id/:season/:episode", handlers.GetTVEpisodeByTMDBID)
+
+	// Review queue of uncertain TMDB matches, and manual pinning
+	r.GET("/reviews/movies", handlers.ListMovieReviews)
+	r.GET("/reviews/tv", handlers.ListTVReviews)
+	r.PUT("/movies/:id/pin", handlers.PinMovie)
+	r.PUT("/tv/:id/pin", handlers.PinTV)
+
+	stremio.NewAddon(repo, streamer).Register(r)
+
+	return r
+}
@@ -0,0 +1,496 @@
+// Package stremio serves the library as a Stremio addon: a manifest, catalogs
+// of the titles matched to IMDb, their metadata, and the stored febbox links
+// as streams. Titles are addressed by IMDb ID, episodes as
+// "<imdb_id>:<season>:<episode>".
+package stremio
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/db/utils"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/releaseparse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	TypeMovie  = "movie"
+	TypeSeries = "series"
+
+	movieCatalogID  = "showbox-movies"
+	seriesCatalogID = "showbox-series"
+
+	// Stremio pages catalogs with skip, in steps of the page size it got
+	catalogPageSize = 100
+
+	posterBase     = "https://image.tmdb.org/t/p/w500"
+	backgroundBase = "https://image.tmdb.org/t/p/original"
+
+	// Links expiring within this window are resolved again before they're
+	// handed out, so playback doesn't start on a link about to die
+	refreshMargin = 10 * time.Minute
+)
+
+// Addon serves the Stremio addon endpoints
+type Addon struct {
+	repo     repository.Repository
+	streamer *utils.Streamer
+}
+
+// NewAddon creates the addon. With a nil streamer, expired links are served
+// as stored.
+func NewAddon(repo repository.Repository, streamer *utils.Streamer) *Addon {
+	return &Addon{repo: repo, streamer: streamer}
+}
+
+// Register adds the addon routes to r. Stremio loads addons from other
+// origins, so they're served with permissive CORS headers.
+func (a *Addon) Register(r gin.IRouter) {
+	g := r.Group("")
+	g.Use(cors)
+	g.GET("/manifest.json", a.GetManifest)
+	g.GET("/catalog/:type/:id", a.GetCatalog)
+	g.GET("/catalog/:type/:id/:extra", a.GetCatalog)
+	g.GET("/meta/:type/:id", a.GetMeta)
+	g.GET("/stream/:type/:id", a.GetStreams)
+}
+
+func cors(c *gin.Context) {
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Access-Control-Allow-Headers", "*")
+	c.Next()
+}
+
+// GetManifest handles GET /manifest.json
+func (a *Addon) GetManifest(c *gin.Context) {
+	extra := []CatalogExtra{{Name: "search"}, {Name: "skip"}}
+	c.JSON(http.StatusOK, Manifest{
+		ID:          "media.showbox.addon",
+		Version:     "1.0.0",
+		Name:        "ShowBox",
+		Description: "Movies and TV series from the ShowBox library",
+		Resources:   []string{"catalog", "meta", "stream"},
+		Types:       []string{TypeMovie, TypeSeries},
+		IDPrefixes:  []string{"tt"},
+		Catalogs: []Catalog{
+			{Type: TypeMovie, ID: movieCatalogID, Name: "ShowBox Movies", Extra: extra},
+			{Type: TypeSeries, ID: seriesCatalogID, Name: "ShowBox Series", Extra: extra},
+		},
+	})
+}
+
+// GetCatalog handles GET /catalog/:type/:id.json and
+// /catalog/:type/:id/:extra.json, where extra is "search=..." or "skip=N"
+func (a *Addon) GetCatalog(c *gin.Context) {
+	contentType := c.Param("type")
+	catalogID := strings.TrimSuffix(c.Param("id"), ".json")
+	extra, err := url.ParseQuery(strings.TrimSuffix(c.Param("extra"), ".json"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid catalog extra"})
+		return
+	}
+	skip, _ := strconv.ParseInt(extra.Get("skip"), 10, 64)
+	if skip < 0 {
+		skip = 0
+	}
+	search := extra.Get("search")
+
+	var metas []MetaPreview
+	switch {
+	case contentType == TypeMovie && catalogID == movieCatalogID:
+		metas, err = a.movieCatalog(c, search, skip)
+	case contentType == TypeSeries && catalogID == seriesCatalogID:
+		metas, err = a.seriesCatalog(c, search, skip)
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown catalog"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading Stremio catalog %s: %v", catalogID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if metas == nil {
+		metas = []MetaPreview{}
+	}
+	c.JSON(http.StatusOK, gin.H{"metas": metas})
+}
+
+func (a *Addon) movieCatalog(c *gin.Context, search string, skip int64) ([]MetaPreview, error) {
+	var movies []models.Movie
+	if search != "" {
+		found, err := a.repo.SearchMovieByQuery(c, search)
+		if err != nil {
+			return nil, err
+		}
+		for _, movie := range found {
+			if movie.IMDbID != "" {
+				movies = append(movies, movie)
+			}
+		}
+		movies = window(movies, skip)
+	} else {
+		page, err := a.repo.ListMovies(c, repository.MovieFilter{
+			HasIMDbID: true,
+			Sort:      repository.SortPopularity,
+			Skip:      skip,
+			Limit:     catalogPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		movies = page.Results
+	}
+
+	metas := make([]MetaPreview, 0, len(movies))
+	for i := range movies {
+		metas = append(metas, moviePreview(&movies[i]))
+	}
+	return metas, nil
+}
+
+func (a *Addon) seriesCatalog(c *gin.Context, search string, skip int64) ([]MetaPreview, error) {
+	var tvShows []models.TV
+	if search != "" {
+		found, err := a.repo.SearchTVByQuery(c, search)
+		if err != nil {
+			return nil, err
+		}
+		for _, tv := range found {
+			if tv.IMDbID != "" {
+				tvShows = append(tvShows, tv)
+			}
+		}
+		tvShows = window(tvShows, skip)
+	} else {
+		page, err := a.repo.ListTV(c, repository.TVFilter{
+			HasIMDbID: true,
+			Sort:      repository.SortPopularity,
+			Skip:      skip,
+			Limit:     catalogPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tvShows = page.Results
+	}
+
+	metas := make([]MetaPreview, 0, len(tvShows))
+	for i := range tvShows {
+		metas = append(metas, tvPreview(&tvShows[i]))
+	}
+	return metas, nil
+}
+
+// window returns the catalog page of search results starting at skip
+func window[T any](items []T, skip int64) []T {
+	if skip >= int64(len(items)) {
+		return nil
+	}
+	items = items[skip:]
+	if len(items) > catalogPageSize {
+		items = items[:catalogPageSize]
+	}
+	return items
+}
+
+// GetMeta handles GET /meta/:type/:imdb_id.json
+func (a *Addon) GetMeta(c *gin.Context) {
+	imdbID := strings.TrimSuffix(c.Param("id"), ".json")
+	if !strings.HasPrefix(imdbID, "tt") {
+		c.JSON(http.StatusNotFound, gin.H{"meta": nil})
+		return
+	}
+
+	switch c.Param("type") {
+	case TypeMovie:
+		movie, err := a.repo.GetMovieByIMDbID(c, imdbID)
+		if err != nil {
+			a.lookupFailed(c, "meta", err)
+			return
+		}
+		meta := Meta{MetaPreview: moviePreview(movie)}
+		if movie.Runtime > 0 {
+			meta.Runtime = fmt.Sprintf("%d min", movie.Runtime)
+		}
+		c.JSON(http.StatusOK, gin.H{"meta": meta})
+
+	case TypeSeries:
+		tv, err := a.repo.GetTVByIMDbID(c, imdbID)
+		if err != nil {
+			a.lookupFailed(c, "meta", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"meta": Meta{MetaPreview: tvPreview(tv), Videos: episodeVideos(tv)}})
+
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"meta": nil})
+	}
+}
+
+// GetStreams handles GET /stream/movie/:imdb_id.json and
+// /stream/series/:imdb_id::season::episode.json
+func (a *Addon) GetStreams(c *gin.Context) {
+	id := strings.TrimSuffix(c.Param("id"), ".json")
+
+	switch c.Param("type") {
+	case TypeMovie:
+		movie, err := a.repo.GetMovieByIMDbID(c, id)
+		if err != nil {
+			a.lookupFailed(c, "streams", err)
+			return
+		}
+		if a.refreshFiles(c, movie.Files) {
+			if err := a.repo.UpdateMovie(c, movie); err != nil {
+				log.Printf("Error saving refreshed links of movie %s: %v", movie.MovieID, err)
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"streams": fileStreams(movie.Files, "")})
+
+	case TypeSeries:
+		imdbID, seasonNum, episodeNum, ok := parseEpisodeID(id)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"streams": []Stream{}})
+			return
+		}
+		tv, err := a.repo.GetTVByIMDbID(c, imdbID)
+		if err != nil {
+			a.lookupFailed(c, "streams", err)
+			return
+		}
+		episode := findEpisode(tv, seasonNum, episodeNum)
+		if episode == nil {
+			c.JSON(http.StatusOK, gin.H{"streams": []Stream{}})
+			return
+		}
+
+		refreshed := false
+		for i := range episode.Sources {
+			if a.refreshFiles(c, episode.Sources[i].Files) {
+				refreshed = true
+			}
+		}
+		if refreshed {
+			if err := a.repo.UpdateTV(c, tv); err != nil {
+				log.Printf("Error saving refreshed links of TV series %s: %v", tv.TVID, err)
+			}
+		}
+
+		streams := []Stream{}
+		for _, source := range episode.Sources {
+			streams = append(streams, fileStreams(source.Files, "showbox-"+tv.TVID)...)
+		}
+		c.JSON(http.StatusOK, gin.H{"streams": streams})
+
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"streams": []Stream{}})
+	}
+}
+
+// lookupFailed answers a request for an unknown title with an empty result,
+// which is what Stremio expects from addons that don't have it
+func (a *Addon) lookupFailed(c *gin.Context, key string, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		if key == "meta" {
+			c.JSON(http.StatusNotFound, gin.H{"meta": nil})
+		} else {
+			c.JSON(http.StatusOK, gin.H{key: []Stream{}})
+		}
+		return
+	}
+	log.Printf("Error looking up Stremio %s: %v", key, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// refreshFiles re-resolves the links of files that are missing or about to
+// expire and reports whether any file changed. Failures are logged and the
+// stored links are served as they are.
+func (a *Addon) refreshFiles(c *gin.Context, files []models.File) bool {
+	if a.streamer == nil {
+		return false
+	}
+	before := time.Now().Add(refreshMargin)
+	changed := false
+	for i := range files {
+		if len(files[i].Links) > 0 && !utils.LinksExpireBefore(files[i].Links, before) {
+			continue
+		}
+		if err := a.streamer.UpdateFileStream(c, &files[i]); err != nil {
+			log.Printf("Error refreshing links of fid %d: %v", files[i].FID, err)
+			continue
+		}
+		changed = true
+	}
+	return changed
+}
+
+// parseEpisodeID splits "tt0944947:1:2" into its parts
+func parseEpisodeID(id string) (string, int, int, bool) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 3 || !strings.HasPrefix(parts[0], "tt") {
+		return "", 0, 0, false
+	}
+	season, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	episode, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", 0, 0, false
+	}
+	return parts[0], season, episode, true
+}
+
+func findEpisode(tv *models.TV, seasonNum, episodeNum int) *models.Episode {
+	for i := range tv.Seasons {
+		if tv.Seasons[i].SeasonNumber != seasonNum {
+			continue
+		}
+		for j := range tv.Seasons[i].Episodes {
+			if tv.Seasons[i].Episodes[j].EpisodeNo == episodeNum {
+				return &tv.Seasons[i].Episodes[j]
+			}
+		}
+	}
+	return nil
+}
+
+// fileStreams turns every link of every file into a stream titled with the
+// quality, size and codec. bingeGroup, when set, lets Stremio pick the same
+// quality for the next episode.
+func fileStreams(files []models.File, bingeGroup string) []Stream {
+	streams := []Stream{}
+	for _, file := range files {
+		release := file.Release
+		if release == nil {
+			release = releaseparse.Parse(file.FileName)
+		}
+		for _, link := range file.Links {
+			if link.URL == "" {
+				continue
+			}
+
+			size := link.Size
+			if size == "" {
+				size = file.Size
+			}
+			var details []string
+			for _, part := range []string{link.Quality, size, release.Codec} {
+				if part = strings.TrimSpace(part); part != "" {
+					details = append(details, part)
+				}
+			}
+
+			stream := Stream{
+				URL:   link.URL,
+				Name:  strings.TrimSpace("ShowBox " + link.Quality),
+				Title: file.FileName + "\n" + strings.Join(details, " | "),
+				BehaviorHints: &BehaviorHints{
+					Filename: file.FileName,
+				},
+			}
+			if bingeGroup != "" {
+				stream.BehaviorHints.BingeGroup = bingeGroup + "-" + link.Quality
+			}
+			streams = append(streams, stream)
+		}
+	}
+	return streams
+}
+
+func moviePreview(movie *models.Movie) MetaPreview {
+	return MetaPreview{
+		ID:          movie.IMDbID,
+		Type:        TypeMovie,
+		Name:        movie.Title,
+		Poster:      imageURL(posterBase, movie.PosterPath),
+		Background:  imageURL(backgroundBase, movie.BackdropPath),
+		Description: movie.Description,
+		ReleaseInfo: year(movie.ReleaseDate),
+		IMDbRating:  rating(movie.VoteAverage),
+		Genres:      genreNames(movie.Genres),
+	}
+}
+
+func tvPreview(tv *models.TV) MetaPreview {
+	releaseInfo := year(tv.FirstAirDate)
+	if releaseInfo != "" {
+		releaseInfo += "-"
+		if tv.Status == "Ended" || tv.Status == "Canceled" {
+			releaseInfo += year(tv.LastAirDate)
+		}
+	}
+	return MetaPreview{
+		ID:          tv.IMDbID,
+		Type:        TypeSeries,
+		Name:        tv.Title,
+		Poster:      imageURL(posterBase, tv.PosterPath),
+		Background:  imageURL(backgroundBase, tv.BackdropPath),
+		Description: tv.Description,
+		ReleaseInfo: releaseInfo,
+		IMDbRating:  rating(tv.VoteAverage),
+		Genres:      genreNames(tv.Genres),
+	}
+}
+
+func episodeVideos(tv *models.TV) []Video {
+	var videos []Video
+	for _, season := range tv.Seasons {
+		for _, episode := range season.Episodes {
+			title := episode.EpisodeName
+			if title == "" {
+				title = fmt.Sprintf("Episode %d", episode.EpisodeNo)
+			}
+			video := Video{
+				ID:        fmt.Sprintf("%s:%d:%d", tv.IMDbID, season.SeasonNumber, episode.EpisodeNo),
+				Title:     title,
+				Season:    season.SeasonNumber,
+				Episode:   episode.EpisodeNo,
+				Overview:  episode.Overview,
+				Thumbnail: imageURL(posterBase, episode.StillPath),
+			}
+			if aired, err := time.Parse("2006-01-02", episode.AirDate); err == nil {
+				video.Released = aired.Format(time.RFC3339)
+			}
+			videos = append(videos, video)
+		}
+	}
+	return videos
+}
+
+func imageURL(base, path string) string {
+	if path == "" {
+		return ""
+	}
+	return base + path
+}
+
+func year(date string) string {
+	if len(date) < 4 {
+		return ""
+	}
+	return date[:4]
+}
+
+func rating(voteAverage float64) string {
+	if voteAverage == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(voteAverage, 'f', 1, 64)
+}
+
+func genreNames(genres []models.Genre) []string {
+	var names []string
+	for _, genre := range genres {
+		names = append(names, genre.Name)
+	}
+	return names
+}
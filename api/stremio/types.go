@@ -0,0 +1,68 @@
+package stremio
+
+// The response shapes of the Stremio addon protocol, see
+// https://github.com/Stremio/stremio-addon-sdk/tree/master/docs/api
+
+type Manifest struct {
+	ID          string    `json:"id"`
+	Version     string    `json:"version"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Resources   []string  `json:"resources"`
+	Types       []string  `json:"types"`
+	IDPrefixes  []string  `json:"idPrefixes"`
+	Catalogs    []Catalog `json:"catalogs"`
+}
+
+type Catalog struct {
+	Type  string         `json:"type"`
+	ID    string         `json:"id"`
+	Name  string         `json:"name"`
+	Extra []CatalogExtra `json:"extra,omitempty"`
+}
+
+type CatalogExtra struct {
+	Name       string `json:"name"`
+	IsRequired bool   `json:"isRequired,omitempty"`
+}
+
+type MetaPreview struct {
+	ID          string   `json:"id"`
+	Type        string   `json:"type"`
+	Name        string   `json:"name"`
+	Poster      string   `json:"poster,omitempty"`
+	Background  string   `json:"background,omitempty"`
+	Description string   `json:"description,omitempty"`
+	ReleaseInfo string   `json:"releaseInfo,omitempty"`
+	IMDbRating  string   `json:"imdbRating,omitempty"`
+	Genres      []string `json:"genres,omitempty"`
+}
+
+type Meta struct {
+	MetaPreview
+	Runtime string  `json:"runtime,omitempty"`
+	Videos  []Video `json:"videos,omitempty"`
+}
+
+// Video is an episode of a series
+type Video struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Season    int    `json:"season"`
+	Episode   int    `json:"episode"`
+	Released  string `json:"released,omitempty"`
+	Overview  string `json:"overview,omitempty"`
+	Thumbnail string `json:"thumbnail,omitempty"`
+}
+
+type Stream struct {
+	URL           string         `json:"url"`
+	Name          string         `json:"name"`
+	Title         string         `json:"title"`
+	BehaviorHints *BehaviorHints `json:"behaviorHints,omitempty"`
+}
+
+type BehaviorHints struct {
+	BingeGroup string `json:"bingeGroup,omitempty"`
+	Filename   string `json:"filename,omitempty"`
+}
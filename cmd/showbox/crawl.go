@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/amankumarsingh77/go-showbox-api/scraper/showbox"
+)
+
+func runCrawlCatalog(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("crawl catalog", "--movies|--tv [flags]")
+	var content contentFlags
+	content.register(fs, "Crawl the catalog of")
+	startPage := fs.Int("start-page", 0, "First catalog page to crawl (default from config)")
+	endPage := fs.Int("end-page", 0, "Last catalog page to crawl (default from config)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := content.exactlyOne(); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+
+	crawlConfig := cfg.ShowboxConfig()
+	crawlConfig.Movies = content.movies
+	if *startPage > 0 {
+		crawlConfig.StartPage = *startPage
+	}
+	if *endPage > 0 {
+		crawlConfig.EndPage = *endPage
+	}
+	if crawlConfig.EndPage < crawlConfig.StartPage {
+		return usagef("page range %d-%d is invalid", crawlConfig.StartPage, crawlConfig.EndPage)
+	}
+
+	storage := showbox.NewStorage()
+	storage.DryRun = common.dryRun
+
+	scraper, err := showbox.NewScraper(crawlConfig, storage)
+	if err != nil {
+		return err
+	}
+	err = scraper.Run(ctx)
+	logProxyStats(crawlConfig.Proxies)
+	if err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return errors.New("crawl interrupted, progress so far was saved")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func runDBCheck(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("db check", "[flags]")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.RequireMongo(); err != nil {
+		return err
+	}
+
+	client, err := db.Dial(cfg.Mongo.URI)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		disconnectCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		client.Disconnect(disconnectCtx)
+	}()
+
+	// Creating missing indexes is the one write db check does
+	if !common.dryRun {
+		if err := db.EnsureIndexes(client, cfg.Mongo.Database); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	database := client.Database(cfg.Mongo.Database)
+	fmt.Printf("Database: %s\n", cfg.Mongo.Database)
+	for _, name := range []string{"movies", "tv", "sync_state"} {
+		collection := database.Collection(name)
+		count, err := collection.EstimatedDocumentCount(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to count %s: %w", name, err)
+		}
+
+		cursor, err := collection.Indexes().List(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list indexes of %s: %w", name, err)
+		}
+		var indexes []bson.M
+		if err := cursor.All(ctx, &indexes); err != nil {
+			return fmt.Errorf("failed to list indexes of %s: %w", name, err)
+		}
+		var names []interface{}
+		for _, index := range indexes {
+			names = append(names, index["name"])
+		}
+		fmt.Printf("  %-11s %d documents, indexes: %v\n", name+":", count, names)
+	}
+	return nil
+}
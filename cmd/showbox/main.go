@@ -1,47 +1,256 @@
+// Command showbox crawls the showbox catalog, scrapes febbox files, syncs
+// metadata from TMDB, refreshes stream links and episodes and serves the API,
+// all from one binary:
+//
+//	showbox crawl catalog  --movies|--tv [--start-page N] [--end-page N]
+//	showbox scrape files   --movies|--tv [--range start:end | --ids a,b]
+//	showbox sync tmdb      --movies|--tv|--all [--incremental] [--id ID] [--limit N] [--skip N]
+//	showbox review list    --movies|--tv [--status needs_review|unmatched]
+//	showbox review pin     --movies|--tv --id ID --tmdb-id N
+//	showbox refresh links
+//	showbox refresh episodes [--aired-within 720h | --ids a,b]
+//	showbox serve          [--addr :8080]
+//	showbox db check
+//
+// Every subcommand accepts --config and --dry-run. A dry run reads from the
+// network and the database as usual but writes nothing to either the
+// database or local files.
+//
+// Exit codes: 0 on success, 1 when the command failed or was interrupted,
+// 2 on invalid usage.
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/amankumarsingh77/go-showbox-api/db"
 	"github.com/amankumarsingh77/go-showbox-api/db/repository"
-	"github.com/amankumarsingh77/go-showbox-api/scraper/febox"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/config"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// command is a runnable subcommand. args holds everything after the
+// subcommand name.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"crawl catalog", "Crawl the showbox.media catalog into movies_final.json or tv_final.json", runCrawlCatalog},
+	{"scrape files", "Scrape febbox files for catalog titles and store them", runScrapeFiles},
+	{"sync tmdb", "Enrich stored titles with TMDB metadata", runSyncTMDB},
+	{"review list", "List titles whose TMDB match needs review", runReviewList},
+	{"review pin", "Match a title to a TMDB ID by hand", runReviewPin},
+	{"refresh links", "Re-resolve stream links that are about to expire", runRefreshLinks},
+	{"refresh episodes", "Scrape only the new febbox files of ongoing TV shows", runRefreshEpisodes},
+	{"serve", "Run the API server and the background link refresher", runServe},
+	{"db check", "Check the database connection, indexes and document counts", runDBCheck},
+}
+
+// usageError marks errors caused by invalid arguments
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
 func main() {
-	// log.SetFlags(log.LstdFlags | log.Lshortfile)
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	cmd, rest, ok := findCommand(args)
+	if !ok {
+		if len(args) > 0 && !isHelp(args[0]) {
+			fmt.Fprintf(os.Stderr, "showbox: unknown command %q\n\n", strings.Join(args, " "))
+			printUsage()
+			return exitUsage
+		}
+		printUsage()
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
 
-	// config := showbox.DefaultConfig()
-	// storage := showbox.NewStorage()
+	// The first SIGINT/SIGTERM cancels the context so commands can stop
+	// starting new work and save what they have. A second signal kills the
+	// process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := cmd.run(ctx, rest)
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(os.Stderr, "showbox %s: %v\n", cmd.name, err)
+		fmt.Fprintf(os.Stderr, "Run 'showbox %s -h' for usage.\n", cmd.name)
+		return exitUsage
+	default:
+		log.Printf("showbox %s: %v", cmd.name, err)
+		return exitFailure
+	}
+}
 
-	// scraper, err := showbox.NewScraper(config, storage)
-	// if err != nil {
-	// 	log.Fatalf("Failed to create scraper: %v", err)
-	// }
+// findCommand matches the leading arguments against the one or two word
+// command names
+func findCommand(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) {
+			continue
+		}
+		if strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
 
-	// if err := scraper.Run(); err != nil {
-	// 	log.Fatalf("Scraper error: %v", err)
-	// }
+func isHelp(arg string) bool {
+	switch arg {
+	case "-h", "-help", "--help", "help":
+		return true
+	}
+	return false
+}
 
-	cfg := &febox.Config{
-		MaxConcurrency:  5,
-		RequestInterval: 2,
-		MaxRetries:      3,
-		RetryDelay:      2,
-		HTTPTimeout:     120,
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: showbox <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-15s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintln(os.Stderr, "\nEvery command accepts --config and --dry-run. Run 'showbox <command> -h' for its flags.")
+}
 
-	dbConn, err := db.NewMongoConn()
+// commonFlags are the flags every subcommand accepts
+type commonFlags struct {
+	configPath string
+	dryRun     bool
+}
+
+// newFlagSet creates the flag set of a subcommand with the common flags
+// already defined
+func newFlagSet(name, usage string) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet("showbox "+name, flag.ContinueOnError)
+	common := &commonFlags{}
+	fs.StringVar(&common.configPath, "config", "", "Path to the config file (default $SHOWBOX_CONFIG or config.yaml)")
+	fs.BoolVar(&common.dryRun, "dry-run", false, "Do everything except writing to the database or local files")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: showbox %s %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs, common
+}
+
+// parseFlags parses args and rejects leftover positional arguments. Errors
+// other than -h are already reported by the flag set, so they're returned as
+// usage errors.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return nil
+}
+
+func loadConfig(common *commonFlags) (*config.Config, error) {
+	cfg, err := config.Load(common.configPath)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	if common.dryRun {
+		log.Println("Dry run: nothing will be written")
 	}
+	return cfg, nil
+}
 
-	dbRepo := repository.NewMongoRepo(dbConn.Database("showbox").Collection("movies"), dbConn.Database("showbox").Collection("tv"))
-	scraper := febox.NewScraper(dbRepo, cfg)
+// openRepo connects to MongoDB and returns the repository along with a
+// function that disconnects. A dry run neither creates indexes nor writes.
+func openRepo(cfg *config.Config, dryRun bool) (repository.Repository, func(), error) {
+	if err := cfg.RequireMongo(); err != nil {
+		return nil, nil, err
+	}
+	var client *mongo.Client
+	var err error
+	if dryRun {
+		client, err = db.Dial(cfg.Mongo.URI)
+	} else {
+		client, err = db.Connect(cfg.Mongo.URI, cfg.Mongo.Database)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	database := client.Database(cfg.Mongo.Database)
+	var repo repository.Repository = repository.NewMongoRepo(database.Collection("movies"), database.Collection("tv"))
+	if dryRun {
+		repo = repository.NewDryRunRepo(repo)
+	}
 
-	// movies := getMoviesList(1701, 2000)
-	// scraper.ScrapeMoviesConcurrently(movies)
+	closeFn := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := client.Disconnect(ctx); err != nil {
+			log.Printf("Error disconnecting from MongoDB: %v", err)
+		}
+	}
+	return repo, closeFn, nil
+}
 
-	series := febox.GetSeriesList(3732, 7789)
-	scraper.ScrapeSeriesConcurrently(series)
+// contentFlags adds the --movies and --tv flags shared by the crawl, scrape
+// and sync commands
+type contentFlags struct {
+	movies bool
+	tv     bool
+}
+
+func (c *contentFlags) register(fs *flag.FlagSet, what string) {
+	fs.BoolVar(&c.movies, "movies", false, what+" movies")
+	fs.BoolVar(&c.tv, "tv", false, what+" TV series")
+}
+
+// exactlyOne checks that one of --movies and --tv was given
+func (c *contentFlags) exactlyOne() error {
+	if c.movies == c.tv {
+		return usagef("exactly one of --movies or --tv is required")
+	}
+	return nil
 }
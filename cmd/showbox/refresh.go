@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/utils"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/config"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/cookiepool"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/hostlimit"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/httpclient"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/proxypool"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/refresher"
+	"github.com/amankumarsingh77/go-showbox-api/scraper/febox"
+)
+
+func runRefreshLinks(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("refresh links", "[flags]")
+	lead := fs.Duration("lead", 0, "Refresh links expiring within this window (default from config)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *lead < 0 {
+		return usagef("--lead must not be negative")
+	}
+	if *lead >= utils.LinkTTL {
+		return usagef("--lead must be shorter than the %s link lifetime", utils.LinkTTL)
+	}
+
+	cfg, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+
+	repo, closeRepo, err := openRepo(cfg, common.dryRun)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	proxies, limiter := cfg.FebboxProxies(), cfg.FebboxLimiter()
+	streamer, err := newStreamer(cfg, proxies, limiter)
+	if err != nil {
+		return err
+	}
+	refresherConfig := cfg.RefresherConfig()
+	if *lead > 0 {
+		refresherConfig.Lead = *lead
+	}
+
+	stats := refresher.NewRefresher(repo, streamer, refresherConfig).RefreshOnce(ctx)
+	log.Printf("Link refresh done: %d movies, %d TV shows, %d files updated, %d failures",
+		stats.Movies, stats.TVShows, stats.FilesUpdated, stats.Failures)
+	logAccountUsage(streamer.Cookies())
+	logProxyStats(proxies)
+	logHostRates(limiter)
+
+	if ctx.Err() != nil {
+		return errors.New("refresh interrupted")
+	}
+	if stats.Failures > 0 {
+		return fmt.Errorf("%d titles failed to refresh", stats.Failures)
+	}
+	return nil
+}
+
+func runRefreshEpisodes(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("refresh episodes", "[--aired-within 720h | --ids a,b] [flags]")
+	airedWithin := fs.Duration("aired-within", 30*24*time.Hour, "Also refresh ended shows whose last episode aired within this window")
+	idsFlag := fs.String("ids", "", "Comma-separated showbox IDs of the shows to refresh, ongoing or not")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *airedWithin < 0 {
+		return usagef("--aired-within must not be negative")
+	}
+	var ids []string
+	for _, id := range strings.Split(*idsFlag, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if *idsFlag != "" && len(ids) == 0 {
+		return usagef("--ids needs at least one ID")
+	}
+
+	cfg, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+
+	repo, closeRepo, err := openRepo(cfg, common.dryRun)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	var series []models.TV
+	if len(ids) > 0 {
+		for _, id := range ids {
+			tv, err := repo.GetFullTVById(ctx, id)
+			if err != nil {
+				return fmt.Errorf("failed to load TV series %s: %w", id, err)
+			}
+			series = append(series, *tv)
+		}
+	} else {
+		series, err = repo.GetOngoingTVShows(ctx, time.Now().Add(-*airedWithin))
+		if err != nil {
+			return err
+		}
+	}
+
+	scraper, err := febox.NewScraper(repo, cfg.FeboxConfig())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Refreshing episodes of %d TV series", len(series))
+	summary := scraper.RefreshSeriesConcurrently(ctx, series)
+	log.Printf("Episode refresh done: %s", summary)
+	logAccountUsage(scraper.Cookies())
+	logProxyStats(scraper.Proxies())
+	logHostRates(scraper.Limiter())
+
+	if ctx.Err() != nil {
+		return errors.New("refresh interrupted")
+	}
+	if summary.Errored > 0 {
+		return fmt.Errorf("%d TV series failed to refresh", summary.Errored)
+	}
+	return nil
+}
+
+// newStreamer creates the febbox link resolver shared by refresh and serve,
+// sending its requests through proxies at the pace of limiter
+func newStreamer(cfg *config.Config, proxies *proxypool.Pool, limiter *hostlimit.Limiter) (*utils.Streamer, error) {
+	httpCfg := cfg.HTTPClientConfig(cfg.Febbox.Timeout.Std()).WithProxies(proxies).WithLimiter(limiter)
+	httpClient, err := httpclient.New(httpCfg)
+	if err != nil {
+		return nil, err
+	}
+	return utils.NewStreamer(httpClient).
+		WithBaseURL(cfg.Febbox.FebboxBase).
+		WithCookiePool(cfg.CookiePool()), nil
+}
+
+// logAccountUsage logs how each febbox account fared, so expired cookies
+// and accounts over their quota stand out
+func logAccountUsage(pool *cookiepool.Pool) {
+	for _, usage := range pool.Usage() {
+		log.Printf("Febbox account %s", usage)
+	}
+}
+
+// logProxyStats logs how each proxy fared, so dead or throttled proxies
+// stand out
+func logProxyStats(pool *proxypool.Pool) {
+	for _, stats := range pool.Stats() {
+		log.Printf("Proxy %s", stats)
+	}
+}
+
+// logHostRates logs the pace each host was left at and how often it
+// throttled
+func logHostRates(limiter *hostlimit.Limiter) {
+	for _, rate := range limiter.Rates() {
+		log.Printf("Host %s", rate)
+	}
+}
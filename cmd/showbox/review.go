@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/tmdb"
+)
+
+func runReviewList(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("review list", "--movies|--tv [flags]")
+	var content contentFlags
+	content.register(fs, "List")
+	status := fs.String("status", models.MatchNeedsReview, "Match status to list: needs_review or unmatched")
+	limit := fs.Int("limit", 0, "List at most N titles (0 for all)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := content.exactlyOne(); err != nil {
+		return err
+	}
+	if *status != models.MatchNeedsReview && *status != models.MatchUnmatched {
+		return usagef("--status must be needs_review or unmatched")
+	}
+	if *limit < 0 {
+		return usagef("--limit must not be negative")
+	}
+
+	cfg, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	repo, closeRepo, err := openRepo(cfg, common.dryRun)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	listed := 0
+	cursor := ""
+	for *limit == 0 || listed < *limit {
+		var next string
+		if content.movies {
+			page, err := repo.ListMovies(ctx, repository.MovieFilter{MatchStatus: *status, Cursor: cursor, Limit: repository.MaxPageSize})
+			if err != nil {
+				return err
+			}
+			for _, movie := range page.Results {
+				if *limit > 0 && listed == *limit {
+					break
+				}
+				printReview(movie.MovieID, movie.Title, movie.TMDBID, movie.Match)
+				listed++
+			}
+			next = page.NextCursor
+		} else {
+			page, err := repo.ListTV(ctx, repository.TVFilter{MatchStatus: *status, Cursor: cursor, Limit: repository.MaxPageSize})
+			if err != nil {
+				return err
+			}
+			for _, tv := range page.Results {
+				if *limit > 0 && listed == *limit {
+					break
+				}
+				printReview(tv.TVID, tv.Title, tv.TMDBID, tv.Match)
+				listed++
+			}
+			next = page.NextCursor
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	fmt.Fprintf(os.Stderr, "%d titles with status %s\n", listed, *status)
+	return nil
+}
+
+func printReview(id, title string, tmdbID int, match *models.Match) {
+	fmt.Printf("%s\t%s", id, title)
+	if tmdbID != 0 {
+		fmt.Printf("\t-> TMDB %d", tmdbID)
+	}
+	fmt.Println()
+	if match == nil {
+		return
+	}
+	fmt.Printf("\t%s\n", match.Reason)
+	for _, candidate := range match.Candidates {
+		fmt.Printf("\t  %-8d %.1f  %s", candidate.TMDBID, candidate.Score, candidate.Title)
+		if candidate.Year != "" {
+			fmt.Printf(" (%s)", candidate.Year)
+		}
+		fmt.Println()
+	}
+}
+
+func runReviewPin(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("review pin", "--movies|--tv --id ID --tmdb-id N [flags]")
+	var content contentFlags
+	content.register(fs, "Pin")
+	id := fs.String("id", "", "Showbox ID of the movie or TV series")
+	tmdbID := fs.Int("tmdb-id", 0, "TMDB ID to match it to")
+	tmdbKey := fs.String("tmdb-key", "", "TMDB API key (overrides the config file and TMDB_API_KEY)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := content.exactlyOne(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*id) == "" || *tmdbID <= 0 {
+		return usagef("--id and a positive --tmdb-id are required")
+	}
+
+	cfg, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	if *tmdbKey != "" {
+		cfg.TMDB.APIKey = *tmdbKey
+	}
+	tmdbClient, err := cfg.TMDBClient(common.dryRun)
+	if err != nil {
+		return fmt.Errorf("%w, set it in the config file, the environment or with --tmdb-key", err)
+	}
+
+	repo, closeRepo, err := openRepo(cfg, common.dryRun)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	syncService := tmdb.NewSyncServiceWithClient(repo, tmdbClient)
+	if content.movies {
+		movie, err := repo.GetMovieById(ctx, *id)
+		if err != nil {
+			return err
+		}
+		if err := syncService.PinMovie(ctx, movie, *tmdbID); err != nil {
+			return err
+		}
+		log.Printf("Pinned movie %s to TMDB %d: %s (%s)", *id, *tmdbID, movie.Title, movie.ReleaseDate)
+		return nil
+	}
+
+	tv, err := repo.GetFullTVById(ctx, *id)
+	if err != nil {
+		return err
+	}
+	if err := syncService.PinTV(ctx, tv, *tmdbID); err != nil {
+		return err
+	}
+	log.Printf("Pinned TV series %s to TMDB %d: %s (%s)", *id, *tmdbID, tv.Title, tv.FirstAirDate)
+	return nil
+}
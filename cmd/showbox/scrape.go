@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/scraper/febox"
+)
+
+func runScrapeFiles(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("scrape files", "--movies|--tv [--range start:end | --ids a,b] [flags]")
+	var content contentFlags
+	content.register(fs, "Scrape the files of")
+	catalogPath := fs.String("catalog", "", "Catalog file to read titles from (default movies_final.json or tv_final.json)")
+	rangeFlag := fs.String("range", "", "Zero-based, inclusive index range of catalog titles to scrape, e.g. 100:200 or 100:")
+	idsFlag := fs.String("ids", "", "Comma-separated showbox IDs of the titles to scrape")
+	jobsPath := fs.String("jobs", "scrape_jobs.json", "Checkpoint file recording the outcome of every title")
+	resume := fs.Bool("resume", false, "Skip titles already marked done in the checkpoint file")
+	retryFailed := fs.Bool("retry-failed", false, "Only scrape titles marked failed in the checkpoint file")
+	status := fs.Bool("status", false, "Print the checkpoint file summary and failed titles, then exit")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	jobs, err := febox.OpenJobStore(*jobsPath)
+	if err != nil {
+		return err
+	}
+	if *status {
+		printJobStatus(jobs)
+		return nil
+	}
+
+	if err := content.exactlyOne(); err != nil {
+		return err
+	}
+	if *rangeFlag != "" && *idsFlag != "" {
+		return usagef("--range and --ids can't be combined")
+	}
+	if *resume && *retryFailed {
+		return usagef("--resume and --retry-failed can't be combined")
+	}
+	selector, err := newTitleSelector(*rangeFlag, *idsFlag)
+	if err != nil {
+		return err
+	}
+
+	mode := febox.RunAll
+	if *resume {
+		mode = febox.RunResume
+	}
+	if *retryFailed {
+		mode = febox.RunFailedOnly
+	}
+
+	cfg, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+
+	repo, closeRepo, err := openRepo(cfg, common.dryRun)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	scraper, err := febox.NewScraper(repo, cfg.FeboxConfig())
+	if err != nil {
+		return err
+	}
+	// The checkpoint file is still read in a dry run, so --resume and
+	// --retry-failed select the same titles, but it's never written
+	if !common.dryRun {
+		scraper.SetJobStore(jobs)
+	}
+
+	contentType := febox.TVType
+	var scraped []string
+	if content.movies {
+		contentType = febox.MovieType
+		path := *catalogPath
+		if path == "" {
+			path = "movies_final.json"
+		}
+		catalog, err := febox.LoadMovies(path)
+		if err != nil {
+			return err
+		}
+		movies, err := selectTitles(selector, catalog, func(m models.Movie) string { return m.MovieID })
+		if err != nil {
+			return err
+		}
+		movies = jobs.SelectMovies(movies, mode)
+		for _, movie := range movies {
+			scraped = append(scraped, movie.MovieID)
+		}
+		if err := saveJobs(jobs, common.dryRun); err != nil {
+			return err
+		}
+		log.Printf("Scraping %d movies", len(movies))
+		scraper.ScrapeMoviesConcurrently(ctx, movies)
+	} else {
+		path := *catalogPath
+		if path == "" {
+			path = "tv_final.json"
+		}
+		catalog, err := febox.LoadSeries(path)
+		if err != nil {
+			return err
+		}
+		series, err := selectTitles(selector, catalog, func(tv models.TV) string { return tv.TVID })
+		if err != nil {
+			return err
+		}
+		series = jobs.SelectSeries(series, mode)
+		for _, tv := range series {
+			scraped = append(scraped, tv.TVID)
+		}
+		if err := saveJobs(jobs, common.dryRun); err != nil {
+			return err
+		}
+		log.Printf("Scraping %d TV series", len(series))
+		scraper.ScrapeSeriesConcurrently(ctx, series)
+	}
+
+	logAccountUsage(scraper.Cookies())
+	logProxyStats(scraper.Proxies())
+	logHostRates(scraper.Limiter())
+	if common.dryRun {
+		return ctx.Err()
+	}
+	printJobStatus(jobs)
+	if ctx.Err() != nil {
+		return errors.New("scrape interrupted, rerun with --resume to continue")
+	}
+	if failed := countFailed(jobs, contentType, scraped); failed > 0 {
+		return fmt.Errorf("%d titles failed, rerun with --retry-failed to try them again", failed)
+	}
+	return nil
+}
+
+// countFailed counts how many of the given titles ended up failed in this run
+func countFailed(jobs *febox.JobStore, contentType febox.ContentType, ids []string) int {
+	scraped := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		scraped[id] = true
+	}
+	failed := 0
+	for _, item := range jobs.Items(febox.JobFailed) {
+		if item.Type == contentType && scraped[item.ID] {
+			failed++
+		}
+	}
+	return failed
+}
+
+func saveJobs(jobs *febox.JobStore, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+	return jobs.Save()
+}
+
+// titleSelector picks catalog titles either by index range or by ID
+type titleSelector struct {
+	start, end int // inclusive, end < 0 means the last title
+	ids        map[string]bool
+}
+
+func newTitleSelector(rangeFlag, idsFlag string) (*titleSelector, error) {
+	selector := &titleSelector{start: 0, end: -1}
+
+	if idsFlag != "" {
+		selector.ids = make(map[string]bool)
+		for _, id := range strings.Split(idsFlag, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				selector.ids[id] = true
+			}
+		}
+		if len(selector.ids) == 0 {
+			return nil, usagef("--ids needs at least one ID")
+		}
+		return selector, nil
+	}
+
+	if rangeFlag == "" {
+		return selector, nil
+	}
+	startText, endText, found := strings.Cut(rangeFlag, ":")
+	if !found {
+		return nil, usagef("invalid --range %q, expected start:end", rangeFlag)
+	}
+	var err error
+	if startText != "" {
+		if selector.start, err = strconv.Atoi(startText); err != nil || selector.start < 0 {
+			return nil, usagef("invalid --range start %q", startText)
+		}
+	}
+	if endText != "" {
+		if selector.end, err = strconv.Atoi(endText); err != nil || selector.end < selector.start {
+			return nil, usagef("invalid --range end %q", endText)
+		}
+	}
+	return selector, nil
+}
+
+// selectTitles applies the selector to the catalog, failing on IDs or
+// indexes the catalog doesn't have
+func selectTitles[T any](selector *titleSelector, catalog []T, idOf func(T) string) ([]T, error) {
+	if selector.ids != nil {
+		var selected []T
+		found := make(map[string]bool)
+		for _, title := range catalog {
+			if id := idOf(title); selector.ids[id] && !found[id] {
+				found[id] = true
+				selected = append(selected, title)
+			}
+		}
+		var missing []string
+		for id := range selector.ids {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			return nil, fmt.Errorf("IDs not in the catalog: %s", strings.Join(missing, ", "))
+		}
+		return selected, nil
+	}
+
+	end := selector.end
+	if end < 0 {
+		end = len(catalog) - 1
+	}
+	if selector.start >= len(catalog) || end >= len(catalog) {
+		return nil, usagef("--range %d:%d is outside the catalog of %d titles", selector.start, end, len(catalog))
+	}
+	return catalog[selector.start : end+1], nil
+}
+
+func printJobStatus(jobs *febox.JobStore) {
+	summary := jobs.Summary()
+	fmt.Printf("Done: %d, failed: %d, pending: %d\n",
+		summary[febox.JobDone], summary[febox.JobFailed], summary[febox.JobPending])
+
+	authFailures := 0
+	for _, item := range jobs.Items(febox.JobFailed) {
+		kind := item.ErrorKind
+		if kind == "" {
+			kind = "unknown"
+		}
+		fmt.Printf("  %s %q: %d attempts, last error (%s): %s\n", item.ID, item.Title, item.Attempts, kind, item.LastError)
+		if item.ErrorKind == "auth" {
+			authFailures++
+		}
+	}
+	if authFailures > 0 {
+		fmt.Printf("%d titles were refused access, the febbox cookies (febbox.cookie, febbox.accounts) have probably expired\n", authFailures)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/api"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/refresher"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/tmdb"
+)
+
+func runServe(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("serve", "[flags]")
+	addr := fs.String("addr", "", "Address to listen on (default from config, e.g. :8080)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	if *addr != "" {
+		cfg.Server.Addr = *addr
+	}
+
+	repo, closeRepo, err := openRepo(cfg, common.dryRun)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	proxies, limiter := cfg.FebboxProxies(), cfg.FebboxLimiter()
+	streamer, err := newStreamer(cfg, proxies, limiter)
+	if err != nil {
+		return err
+	}
+
+	// Keep stream links fresh in the background so requests never wait on febbox.
+	// A refresher interval of 0 disables the refresher.
+	if cfg.Refresher.Interval > 0 {
+		go refresher.NewRefresher(repo, streamer, cfg.RefresherConfig()).Run(ctx)
+	}
+
+	// Pinning TMDB matches from the review routes needs TMDB; the rest of
+	// the API works without it
+	var syncService *tmdb.SyncService
+	if cfg.TMDB.APIKey != "" {
+		tmdbClient, err := cfg.TMDBClient(common.dryRun)
+		if err != nil {
+			return err
+		}
+		syncService = tmdb.NewSyncServiceWithClient(repo, tmdbClient)
+	}
+
+	server := &http.Server{
+		Addr:    cfg.Server.Addr,
+		Handler: api.NewRouter(repo, streamer, syncService),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s", cfg.Server.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server stopped: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down server...")
+	logAccountUsage(streamer.Cookies())
+	logProxyStats(proxies)
+	logHostRates(limiter)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server shutdown failed: %w", err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/tmdb"
+)
+
+func runSyncTMDB(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("sync tmdb", "--movies|--tv|--all [flags]")
+	var content contentFlags
+	content.register(fs, "Sync")
+	all := fs.Bool("all", false, "Sync both movies and TV series")
+	incremental := fs.Bool("incremental", false, "Only sync titles that changed on TMDB since the last sync and titles never synced")
+	id := fs.String("id", "", "Sync only the movie or TV series with this showbox ID (needs --movies or --tv)")
+	limit := fs.Int("limit", 0, "Limit the number of movies and of TV series to sync (0 for all)")
+	skip := fs.Int("skip", 0, "Skip the first N movies and TV series when syncing with --limit")
+	workers := fs.Int("workers", tmdb.DefaultWorkers, "Number of titles to sync concurrently")
+	noCache := fs.Bool("no-cache", false, "Neither read nor write the TMDB response cache")
+	refresh := fs.Bool("refresh", false, "Fetch everything from TMDB again and refresh the cache with it")
+	tmdbKey := fs.String("tmdb-key", "", "TMDB API key (overrides the config file and TMDB_API_KEY)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	syncMovies := content.movies || *all
+	syncTV := content.tv || *all
+	if !syncMovies && !syncTV {
+		return usagef("one of --movies, --tv or --all is required")
+	}
+	if *id != "" && (*all || content.movies == content.tv) {
+		return usagef("--id needs exactly one of --movies or --tv")
+	}
+	if *limit < 0 || *skip < 0 {
+		return usagef("--limit and --skip must not be negative")
+	}
+	if *skip > 0 && *limit == 0 {
+		return usagef("--skip needs --limit")
+	}
+	if *incremental && (*id != "" || *limit > 0 || *skip > 0) {
+		return usagef("--incremental can't be combined with --id, --limit or --skip")
+	}
+	if *workers < 1 {
+		return usagef("--workers must be at least 1")
+	}
+	if *noCache && *refresh {
+		return usagef("--no-cache and --refresh are mutually exclusive")
+	}
+
+	cfg, err := loadConfig(common)
+	if err != nil {
+		return err
+	}
+	if *tmdbKey != "" {
+		cfg.TMDB.APIKey = *tmdbKey
+	}
+	if *noCache {
+		cfg.TMDB.Cache.Dir = ""
+	}
+	if *refresh {
+		cfg.TMDB.Cache.Refresh = true
+	}
+	tmdbClient, err := cfg.TMDBClient(common.dryRun)
+	if err != nil {
+		return fmt.Errorf("%w, set it in the config file, the environment or with --tmdb-key", err)
+	}
+
+	repo, closeRepo, err := openRepo(cfg, common.dryRun)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	syncService := tmdb.NewSyncServiceWithClient(repo, tmdbClient).WithWorkers(*workers)
+	startTime := time.Now()
+	defer func() {
+		tmdbClient.LogStats()
+		log.Printf("Sync process completed in %s", time.Since(startTime))
+	}()
+
+	if *id != "" {
+		if content.movies {
+			log.Printf("Syncing specific movie with ID: %s", *id)
+			movie, err := repo.GetMovieById(ctx, *id)
+			if err != nil {
+				return fmt.Errorf("error retrieving movie: %w", err)
+			}
+			return syncService.SyncMovie(ctx, movie)
+		}
+		log.Printf("Syncing specific TV show with ID: %s", *id)
+		tv, err := repo.GetFullTVById(ctx, *id)
+		if err != nil {
+			return fmt.Errorf("error retrieving TV show: %w", err)
+		}
+		return syncService.SyncTV(ctx, tv)
+	}
+
+	var errs []error
+	if syncMovies {
+		log.Printf("Starting movie database sync with TMDB using %d workers...", *workers)
+		var summary tmdb.SyncSummary
+		if *limit > 0 {
+			log.Printf("Limiting to %d movies, skipping first %d", *limit, *skip)
+			movies, err := repo.GetMoviesWithLimitAndSkip(ctx, int64(*limit), int64(*skip))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error getting movies: %w", err))
+			} else {
+				summary, err = syncService.SyncMovies(ctx, movies)
+				errs = append(errs, err)
+			}
+		} else if *incremental {
+			summary, err = syncService.SyncChangedMovies(ctx)
+			errs = append(errs, err)
+		} else {
+			summary, err = syncService.SyncAllMovies(ctx)
+			errs = append(errs, err)
+		}
+		log.Printf("Movie sync summary: %s", summary)
+		if summary.Errored > 0 {
+			errs = append(errs, fmt.Errorf("%d movies failed to sync", summary.Errored))
+		}
+	}
+
+	if syncTV && ctx.Err() == nil {
+		log.Printf("Starting TV database sync with TMDB using %d workers...", *workers)
+		var summary tmdb.SyncSummary
+		if *limit > 0 {
+			log.Printf("Limiting to %d TV shows, skipping first %d", *limit, *skip)
+			tvShows, err := loadTVShows(ctx, repo, int64(*limit), int64(*skip))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error getting TV shows: %w", err))
+			} else {
+				summary, err = syncService.SyncTVShows(ctx, tvShows)
+				errs = append(errs, err)
+			}
+		} else if *incremental {
+			summary, err = syncService.SyncChangedTV(ctx)
+			errs = append(errs, err)
+		} else {
+			summary, err = syncService.SyncAllTV(ctx)
+			errs = append(errs, err)
+		}
+		log.Printf("TV sync summary: %s", summary)
+		if summary.Errored > 0 {
+			errs = append(errs, fmt.Errorf("%d TV shows failed to sync", summary.Errored))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// loadTVShows loads a page of TV shows as full documents. GetAllTVShows
+// leaves out the episodes, which a sync would then save over.
+func loadTVShows(ctx context.Context, repo repository.Repository, limit, skip int64) ([]models.TV, error) {
+	page, err := repo.GetAllTVShows(ctx, limit, skip)
+	if err != nil {
+		return nil, err
+	}
+	tvShows := make([]models.TV, 0, len(page))
+	for _, tv := range page {
+		full, err := repo.GetFullTVById(ctx, tv.TVID)
+		if err != nil {
+			return nil, err
+		}
+		tvShows = append(tvShows, *full)
+	}
+	return tvShows, nil
+}
@@ -13,6 +13,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// NewMongoConn connects using MONGO_URI and DB_NAME from the environment
 func NewMongoConn() (*mongo.Client, error) {
 	// Load .env but don't fail if it doesn't exist
 	if err := godotenv.Load(); err != nil {
@@ -29,7 +30,23 @@ func NewMongoConn() (*mongo.Client, error) {
 		return nil, fmt.Errorf("DB_NAME environment variable is not set")
 	}
 
-	// Connect to MongoDB
+	return Connect(mongoURI, dbName)
+}
+
+// Connect connects to MongoDB and makes sure the indexes of dbName exist
+func Connect(mongoURI, dbName string) (*mongo.Client, error) {
+	client, err := Dial(mongoURI)
+	if err != nil {
+		return nil, err
+	}
+	if err := EnsureIndexes(client, dbName); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Dial connects to MongoDB and pings it without touching any collection
+func Dial(mongoURI string) (*mongo.Client, error) {
 	clientOptions := options.Client().ApplyURI(mongoURI)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -44,18 +61,28 @@ func NewMongoConn() (*mongo.Client, error) {
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
+	log.Println("Connected to MongoDB successfully")
+	return client, nil
+}
+
+// EnsureIndexes creates the indexes the repositories rely on in dbName
+func EnsureIndexes(client *mongo.Client, dbName string) error {
 	// Ensure indexes on the "movies" collection
 	if err := createMovieTextIndex(client, dbName); err != nil {
-		return nil, err
+		return err
 	}
 
 	// Ensure indexes on the "tv" collection
 	if err := createTVTextIndex(client, dbName); err != nil {
-		return nil, err
+		return err
 	}
 
-	log.Println("Connected to MongoDB successfully")
-	return client, nil
+	// Back the list endpoints and the external ID lookups
+	database := client.Database(dbName)
+	if err := createListIndexes(database.Collection("movies"), "movie_id", "release_date"); err != nil {
+		return err
+	}
+	return createListIndexes(database.Collection("tv"), "tv_id", "first_air_date")
 }
 
 func createMovieTextIndex(client *mongo.Client, dbName string) error {
@@ -121,3 +148,35 @@ func createTVTextIndex(client *mongo.Client, dbName string) error {
 	log.Println("Text index created on tv collection")
 	return nil
 }
+
+// createListIndexes creates one index per sort order of the list endpoints,
+// each ending in the ID that breaks ties between pages, plus the IMDb and
+// TMDB ID indexes behind the external ID lookups
+func createListIndexes(collection *mongo.Collection, idField, releaseField string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var models []mongo.IndexModel
+	for _, field := range []string{"popularity", "vote_average", releaseField, "last_updated"} {
+		models = append(models, mongo.IndexModel{
+			Keys: bson.D{{Key: field, Value: -1}, {Key: idField, Value: 1}},
+		})
+	}
+	models = append(models, mongo.IndexModel{
+		Keys: bson.D{{Key: "title", Value: 1}, {Key: idField, Value: 1}},
+	})
+	// Lookups by external ID, from the API and the Stremio addon
+	models = append(models,
+		mongo.IndexModel{Keys: bson.D{{Key: "imdb_id", Value: 1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "tmdb_id", Value: 1}}},
+	)
+	// The review queue lists titles by match status
+	models = append(models, mongo.IndexModel{
+		Keys: bson.D{{Key: "match.status", Value: 1}, {Key: "title", Value: 1}, {Key: idField, Value: 1}},
+	})
+
+	if _, err := collection.Indexes().CreateMany(ctx, models); err != nil {
+		return fmt.Errorf("failed to create list indexes on %s collection: %w", collection.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package models
+
+import "strings"
+
+// Localization is the metadata of a title or an episode in one language.
+// Empty fields have no translation and fall back to English.
+type Localization struct {
+	Title      string `bson:"title,omitempty" json:"title,omitempty"`
+	Overview   string `bson:"overview,omitempty" json:"overview,omitempty"`
+	PosterPath string `bson:"poster_path,omitempty" json:"poster_path,omitempty"`
+}
+
+// Localize replaces the title, description and poster with the best match
+// for languages, given in order of preference
+func (m *Movie) Localize(languages []string) {
+	if loc, ok := BestLocalization(m.Localizations, languages); ok {
+		localize(&m.Title, loc.Title)
+		localize(&m.Description, loc.Overview)
+		localize(&m.PosterPath, loc.PosterPath)
+	}
+}
+
+// Localize replaces the title, description and poster of the show and the
+// names and overviews of its episodes with the best match for languages
+func (t *TV) Localize(languages []string) {
+	if loc, ok := BestLocalization(t.Localizations, languages); ok {
+		localize(&t.Title, loc.Title)
+		localize(&t.Description, loc.Overview)
+		localize(&t.PosterPath, loc.PosterPath)
+	}
+	for i := range t.Seasons {
+		t.Seasons[i].Localize(languages)
+	}
+}
+
+// Localize localizes the episodes of the season
+func (s *Season) Localize(languages []string) {
+	for i := range s.Episodes {
+		s.Episodes[i].Localize(languages)
+	}
+}
+
+// Localize replaces the episode name and overview with the best match for
+// languages
+func (e *Episode) Localize(languages []string) {
+	if loc, ok := BestLocalization(e.Localizations, languages); ok {
+		localize(&e.EpisodeName, loc.Title)
+		localize(&e.Overview, loc.Overview)
+	}
+}
+
+func localize(field *string, translated string) {
+	if translated != "" {
+		*field = translated
+	}
+}
+
+// BestLocalization picks the localization for the first of languages that has
+// one. A language matches its exact code, then the same language in another
+// region ("de-AT" matches "de", "pt" matches "pt-BR"). English ends the search,
+// since the untranslated fields are English already.
+func BestLocalization(localizations map[string]Localization, languages []string) (Localization, bool) {
+	for _, language := range languages {
+		base := BaseLanguage(language)
+		if base == "en" {
+			return Localization{}, false
+		}
+		if loc, ok := localizations[language]; ok {
+			return loc, true
+		}
+		if loc, ok := localizations[base]; ok {
+			return loc, true
+		}
+		// Several regions of the language: take the first code, so the
+		// choice is stable
+		best := ""
+		for code := range localizations {
+			if BaseLanguage(code) == base && (best == "" || code < best) {
+				best = code
+			}
+		}
+		if best != "" {
+			return localizations[best], true
+		}
+	}
+	return Localization{}, false
+}
+
+// BaseLanguage strips the region from a language code: "pt-BR" becomes "pt"
+func BaseLanguage(code string) string {
+	base, _, _ := strings.Cut(code, "-")
+	return strings.ToLower(base)
+}
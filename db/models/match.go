@@ -0,0 +1,33 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Match states of a title
+const (
+	MatchMatched     = "matched"      // confident automatic match
+	MatchNeedsReview = "needs_review" // low confidence or ambiguous, best guess applied
+	MatchUnmatched   = "unmatched"    // no acceptable TMDB result
+	MatchPinned      = "pinned"       // TMDB ID set by hand, never replaced by a sync
+)
+
+// Match records how the TMDB sync matched a title: the score of the chosen
+// result, why it was accepted or flagged, and the best scored candidates
+type Match struct {
+	Status     string             `bson:"status" json:"status"`
+	Score      float64            `bson:"score,omitempty" json:"score,omitempty"`
+	Reason     string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Candidates []MatchCandidate   `bson:"candidates,omitempty" json:"candidates,omitempty"`
+	MatchedAt  primitive.DateTime `bson:"matched_at,omitempty" json:"matched_at,omitempty"`
+}
+
+type MatchCandidate struct {
+	TMDBID int     `bson:"tmdb_id" json:"tmdb_id"`
+	Title  string  `bson:"title" json:"title"`
+	Year   string  `bson:"year,omitempty" json:"year,omitempty"`
+	Score  float64 `bson:"score" json:"score"`
+}
+
+// IsPinned reports whether the TMDB ID of a title was set by hand
+func (m *Match) IsPinned() bool {
+	return m != nil && m.Status == MatchPinned
+}
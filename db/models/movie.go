@@ -1,6 +1,9 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"github.com/amankumarsingh77/go-showbox-api/pkg/releaseparse"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Movie struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -24,6 +27,10 @@ type Movie struct {
 	Crew         []Crew             `bson:"crew,omitempty" json:"crew,omitempty"`
 	Videos       []Video            `bson:"videos,omitempty" json:"videos,omitempty"`
 	LastUpdated  primitive.DateTime `bson:"last_updated,omitempty" json:"last_updated,omitempty"`
+	Match        *Match             `bson:"match,omitempty" json:"match,omitempty"`
+	// Localizations holds the metadata in other languages than English,
+	// keyed by language code, e.g. "de" or "pt-BR"
+	Localizations map[string]Localization `bson:"localizations,omitempty" json:"localizations,omitempty"`
 }
 
 type File struct {
@@ -32,12 +39,19 @@ type File struct {
 	Size     string `bson:"size,omitempty" json:"size,omitempty"`
 	ThumbURL string `bson:"thumb_url,omitempty" json:"thumb_url,omitempty"`
 	Links    []Link `bson:"links,omitempty" json:"links,omitempty"`
+
+	// Metadata parsed from FileName
+	Release *releaseparse.Release `bson:"release,omitempty" json:"release,omitempty"`
 }
 
 type Link struct {
 	Quality string `bson:"quality" json:"quality"`
 	URL     string `bson:"url" json:"url"`
 	Size    string `bson:"size,omitempty" json:"size,omitempty"`
+
+	// When the link was resolved and when febbox is expected to stop serving it
+	FetchedAt primitive.DateTime `bson:"fetched_at,omitempty" json:"fetched_at,omitempty"`
+	ExpiresAt primitive.DateTime `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
 }
 
 // TMDB related types
@@ -0,0 +1,12 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// SyncState records the progress of a recurring sync, keyed by its name
+type SyncState struct {
+	Name string `bson:"_id" json:"name"`
+	// Watermark is when the last successful run started; upstream changes
+	// after it haven't been synced yet
+	Watermark primitive.DateTime `bson:"watermark" json:"watermark"`
+	UpdatedAt primitive.DateTime `bson:"updated_at" json:"updated_at"`
+}
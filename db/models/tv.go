@@ -1,6 +1,10 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type TV struct {
 	ID          string   `bson:"_id,omitempty" json:"_id,omitempty"`
@@ -11,6 +15,7 @@ type TV struct {
 
 	// TMDB related fields
 	TMDBID           int                `bson:"tmdb_id,omitempty" json:"tmdb_id,omitempty"`
+	IMDbID           string             `bson:"imdb_id,omitempty" json:"imdb_id,omitempty"`
 	PosterPath       string             `bson:"poster_path,omitempty" json:"poster_path,omitempty"`
 	BackdropPath     string             `bson:"backdrop_path,omitempty" json:"backdrop_path,omitempty"`
 	FirstAirDate     string             `bson:"first_air_date,omitempty" json:"first_air_date,omitempty"`
@@ -27,6 +32,25 @@ type TV struct {
 	Crew             []Crew             `bson:"crew,omitempty" json:"crew,omitempty"`
 	Videos           []Video            `bson:"videos,omitempty" json:"videos,omitempty"`
 	LastUpdated      primitive.DateTime `bson:"last_updated,omitempty" json:"last_updated,omitempty"`
+	Match            *Match             `bson:"match,omitempty" json:"match,omitempty"`
+	// Localizations holds the metadata in other languages than English,
+	// keyed by language code
+	Localizations map[string]Localization `bson:"localizations,omitempty" json:"localizations,omitempty"`
+}
+
+// TMDB statuses of shows that still get new episodes
+const (
+	StatusReturningSeries = "Returning Series"
+	StatusInProduction    = "In Production"
+)
+
+// IsOngoing reports whether the show still gets new episodes, by its TMDB
+// status or because its last episode aired on or after airedSince
+func (t *TV) IsOngoing(airedSince time.Time) bool {
+	if t.Status == StatusReturningSeries || t.Status == StatusInProduction {
+		return true
+	}
+	return t.LastAirDate != "" && t.LastAirDate >= airedSince.Format(time.DateOnly)
 }
 
 type Season struct {
@@ -56,6 +80,28 @@ type Episode struct {
 	Overview    string  `bson:"overview,omitempty" json:"overview,omitempty"`
 	VoteAverage float64 `bson:"vote_average,omitempty" json:"vote_average,omitempty"`
 	VoteCount   int     `bson:"vote_count,omitempty" json:"vote_count,omitempty"`
+	// TMDBMatch says which TMDB episode the metadata comes from
+	TMDBMatch *EpisodeMatch `bson:"tmdb_match,omitempty" json:"tmdb_match,omitempty"`
+	// Localizations holds the episode name and overview in other languages
+	// than English, keyed by language code
+	Localizations map[string]Localization `bson:"localizations,omitempty" json:"localizations,omitempty"`
+}
+
+// Strategies the TMDB sync pairs episodes with
+const (
+	EpisodeMatchNumber       = "number"        // same season and episode number
+	EpisodeMatchAirDate      = "air_date"      // air date in the file name, for daily shows
+	EpisodeMatchEpisodeGroup = "episode_group" // numbering of a TMDB episode group, e.g. DVD order
+	EpisodeMatchAbsolute     = "absolute"      // absolute number across the regular seasons
+	EpisodeMatchSpecial      = "special"       // TMDB special named in the file name
+)
+
+// EpisodeMatch is the position of an episode on TMDB, which differs from
+// our season and episode number when the files number episodes differently
+type EpisodeMatch struct {
+	Season   int    `bson:"season" json:"season"`
+	Episode  int    `bson:"episode" json:"episode"`
+	Strategy string `bson:"strategy" json:"strategy"`
 }
 
 type Source struct {
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+)
+
+// DryRunRepo wraps a Repository for --dry-run: reads go to the wrapped
+// repository, writes are logged and dropped. Every write method of the
+// interfaces has to be overridden here.
+type DryRunRepo struct {
+	Repository
+}
+
+func NewDryRunRepo(repo Repository) *DryRunRepo {
+	return &DryRunRepo{Repository: repo}
+}
+
+func (d *DryRunRepo) CreateMovie(ctx context.Context, movie *models.Movie) error {
+	log.Printf("Dry run: would create movie %s (%s) with %d files", movie.MovieID, movie.Title, len(movie.Files))
+	return nil
+}
+
+func (d *DryRunRepo) UpdateMovie(ctx context.Context, movie *models.Movie) error {
+	log.Printf("Dry run: would update movie %s (%s)", movie.MovieID, movie.Title)
+	return nil
+}
+
+func (d *DryRunRepo) CreateTV(ctx context.Context, tv *models.TV) error {
+	log.Printf("Dry run: would create TV series %s (%s) with %d seasons", tv.TVID, tv.Title, len(tv.Seasons))
+	return nil
+}
+
+func (d *DryRunRepo) UpdateTV(ctx context.Context, tv *models.TV) error {
+	log.Printf("Dry run: would update TV series %s (%s)", tv.TVID, tv.Title)
+	return nil
+}
+
+func (d *DryRunRepo) SaveSyncState(ctx context.Context, state *models.SyncState) error {
+	log.Printf("Dry run: would save sync state %s with watermark %s", state.Name, state.Watermark.Time().Format(time.RFC3339))
+	return nil
+}
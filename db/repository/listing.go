@@ -0,0 +1,470 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor is returned for cursors that weren't produced by a
+// listing with the same sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrUnknownSort is returned for sort fields a listing doesn't support
+var ErrUnknownSort = errors.New("unknown sort")
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// SortField selects the order of a listing. Title sorts ascending, the
+// others descending.
+type SortField string
+
+const (
+	SortTitle       SortField = "title"
+	SortPopularity  SortField = "popularity"
+	SortRating      SortField = "rating"
+	SortReleaseDate SortField = "release_date" // first_air_date for TV
+	SortLastUpdated SortField = "last_updated"
+)
+
+// MovieFilter narrows down and orders ListMovies. Zero values mean no
+// restriction.
+type MovieFilter struct {
+	GenreID        int
+	YearFrom       int // release year, inclusive
+	YearTo         int
+	MinVoteAverage float64
+	MinVoteCount   int
+	RuntimeMin     int // minutes, inclusive
+	RuntimeMax     int
+	HasIMDbID      bool   // only movies matched to an IMDb title
+	MatchStatus    string // e.g. models.MatchNeedsReview
+	Sort           SortField
+	Cursor         string // next_cursor of the previous page
+	Skip           int64  // documents to skip after the cursor
+	Limit          int64
+}
+
+// TVFilter narrows down and orders ListTV. Zero values mean no restriction.
+type TVFilter struct {
+	GenreID        int
+	YearFrom       int // first air year, inclusive
+	YearTo         int
+	MinVoteAverage float64
+	MinVoteCount   int
+	Status         string // TMDB status, e.g. "Returning Series"
+	NetworkID      int
+	HasIMDbID      bool
+	MatchStatus    string
+	Sort           SortField
+	Cursor         string
+	Skip           int64
+	Limit          int64
+}
+
+// Page is one page of a listing. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Results    []T    `json:"results"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// sortSpec describes how a SortField maps onto a collection
+type sortSpec struct {
+	sort    SortField
+	field   string // bson field sorted on
+	idField string // unique tie-breaker
+	desc    bool
+	date    bool // field holds a primitive.DateTime
+}
+
+func movieSortSpec(sort SortField) (sortSpec, error) {
+	return newSortSpec(sort, "movie_id", "release_date")
+}
+
+func tvSortSpec(sort SortField) (sortSpec, error) {
+	return newSortSpec(sort, "tv_id", "first_air_date")
+}
+
+func newSortSpec(sort SortField, idField, releaseField string) (sortSpec, error) {
+	spec := sortSpec{sort: sort, idField: idField, desc: true}
+	switch sort {
+	case "", SortTitle:
+		spec.sort, spec.field, spec.desc = SortTitle, "title", false
+	case SortPopularity:
+		spec.field = "popularity"
+	case SortRating:
+		spec.field = "vote_average"
+	case SortReleaseDate:
+		spec.field = releaseField
+	case SortLastUpdated:
+		spec.field, spec.date = "last_updated", true
+	default:
+		return sortSpec{}, fmt.Errorf("%w %q", ErrUnknownSort, sort)
+	}
+	return spec, nil
+}
+
+// sortKey is the value a document is sorted on. Missing fields are null,
+// which MongoDB places after every value in a descending sort; the zero
+// values of omitempty fields are never stored, so they count as null too.
+type sortKey struct {
+	Num  *float64 `json:"n,omitempty"`
+	Str  *string  `json:"t,omitempty"`
+	ID   string   `json:"id"`
+	Sort string   `json:"s"`
+}
+
+func (k sortKey) isNull() bool {
+	return k.Num == nil && k.Str == nil
+}
+
+func numKey(v float64) *float64 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+func strKey(v string, keepEmpty bool) *string {
+	if v == "" && !keepEmpty {
+		return nil
+	}
+	return &v
+}
+
+func movieSortKey(movie *models.Movie, spec sortSpec) sortKey {
+	key := sortKey{ID: movie.MovieID, Sort: string(spec.sort)}
+	switch spec.sort {
+	case SortTitle:
+		key.Str = strKey(movie.Title, true)
+	case SortPopularity:
+		key.Num = numKey(movie.Popularity)
+	case SortRating:
+		key.Num = numKey(movie.VoteAverage)
+	case SortReleaseDate:
+		key.Str = strKey(movie.ReleaseDate, false)
+	case SortLastUpdated:
+		key.Num = numKey(float64(movie.LastUpdated))
+	}
+	return key
+}
+
+func tvSortKey(tv *models.TV, spec sortSpec) sortKey {
+	key := sortKey{ID: tv.TVID, Sort: string(spec.sort)}
+	switch spec.sort {
+	case SortTitle:
+		key.Str = strKey(tv.Title, true)
+	case SortPopularity:
+		key.Num = numKey(tv.Popularity)
+	case SortRating:
+		key.Num = numKey(tv.VoteAverage)
+	case SortReleaseDate:
+		key.Str = strKey(tv.FirstAirDate, false)
+	case SortLastUpdated:
+		key.Num = numKey(float64(tv.LastUpdated))
+	}
+	return key
+}
+
+// encodeCursor turns the sort key of the last document on a page into an
+// opaque cursor
+func encodeCursor(key sortKey) string {
+	data, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor, which must come from a listing with the
+// same sort. An empty cursor decodes to nil.
+func decodeCursor(cursor string, spec sortSpec) (*sortKey, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var key sortKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if key.Sort != string(spec.sort) || key.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &key, nil
+}
+
+// compareKeys orders two sort keys the way MongoDB does for spec, with the
+// ID breaking ties
+func compareKeys(a, b sortKey, spec sortSpec) int {
+	if c := compareValues(a, b, spec); c != 0 {
+		return c
+	}
+	switch {
+	case a.ID < b.ID:
+		return -1
+	case a.ID > b.ID:
+		return 1
+	}
+	return 0
+}
+
+func compareValues(a, b sortKey, spec sortSpec) int {
+	switch {
+	case a.isNull() && b.isNull():
+		return 0
+	case a.isNull():
+		return 1
+	case b.isNull():
+		return -1
+	}
+
+	c := 0
+	if a.Num != nil && b.Num != nil {
+		switch {
+		case *a.Num < *b.Num:
+			c = -1
+		case *a.Num > *b.Num:
+			c = 1
+		}
+	} else if a.Str != nil && b.Str != nil {
+		switch {
+		case *a.Str < *b.Str:
+			c = -1
+		case *a.Str > *b.Str:
+			c = 1
+		}
+	}
+	if spec.desc {
+		c = -c
+	}
+	return c
+}
+
+// mongoSort is the sort document matching spec
+func (spec sortSpec) mongoSort() bson.D {
+	direction := 1
+	if spec.desc {
+		direction = -1
+	}
+	return bson.D{{Key: spec.field, Value: direction}, {Key: spec.idField, Value: 1}}
+}
+
+// afterFilter matches the documents that come after the cursor in spec's order
+func (spec sortSpec) afterFilter(cursor *sortKey) bson.M {
+	if cursor.isNull() {
+		return bson.M{spec.field: nil, spec.idField: bson.M{"$gt": cursor.ID}}
+	}
+
+	var value interface{}
+	switch {
+	case cursor.Num != nil && spec.date:
+		value = primitive.DateTime(int64(*cursor.Num))
+	case cursor.Num != nil:
+		value = *cursor.Num
+	default:
+		value = *cursor.Str
+	}
+
+	next := "$gt"
+	if spec.desc {
+		next = "$lt"
+	}
+	or := bson.A{
+		bson.M{spec.field: bson.M{next: value}},
+		bson.M{spec.field: value, spec.idField: bson.M{"$gt": cursor.ID}},
+	}
+	if spec.desc {
+		// Documents without the field sort last
+		or = append(or, bson.M{spec.field: nil})
+	}
+	return bson.M{"$or": or}
+}
+
+// pageLimit clamps a requested page size
+func pageLimit(limit int64) int64 {
+	switch {
+	case limit <= 0:
+		return DefaultPageSize
+	case limit > MaxPageSize:
+		return MaxPageSize
+	}
+	return limit
+}
+
+// yearRange adds a year range on a "YYYY-MM-DD" date field to conditions.
+// Dates compare as strings, so the years don't have to be parsed back out.
+func yearRange(conditions bson.A, field string, from, to int) bson.A {
+	if from > 0 {
+		conditions = append(conditions, bson.M{field: bson.M{"$gte": fmt.Sprintf("%04d", from)}})
+	}
+	if to > 0 {
+		conditions = append(conditions, bson.M{field: bson.M{"$lt": fmt.Sprintf("%04d", to+1)}})
+	}
+	return conditions
+}
+
+func inYearRange(date string, from, to int) bool {
+	if from > 0 && date < fmt.Sprintf("%04d", from) {
+		return false
+	}
+	if to > 0 && date >= fmt.Sprintf("%04d", to+1) {
+		return false
+	}
+	return true
+}
+
+func movieConditions(filter MovieFilter) bson.A {
+	conditions := bson.A{}
+	if filter.GenreID > 0 {
+		conditions = append(conditions, bson.M{"genres.id": filter.GenreID})
+	}
+	conditions = yearRange(conditions, "release_date", filter.YearFrom, filter.YearTo)
+	if filter.MinVoteAverage > 0 {
+		conditions = append(conditions, bson.M{"vote_average": bson.M{"$gte": filter.MinVoteAverage}})
+	}
+	if filter.MinVoteCount > 0 {
+		conditions = append(conditions, bson.M{"vote_count": bson.M{"$gte": filter.MinVoteCount}})
+	}
+	if filter.RuntimeMin > 0 {
+		conditions = append(conditions, bson.M{"runtime": bson.M{"$gte": filter.RuntimeMin}})
+	}
+	if filter.RuntimeMax > 0 {
+		conditions = append(conditions, bson.M{"runtime": bson.M{"$lte": filter.RuntimeMax}})
+	}
+	if filter.HasIMDbID {
+		conditions = append(conditions, bson.M{"imdb_id": bson.M{"$gt": ""}})
+	}
+	if filter.MatchStatus != "" {
+		conditions = append(conditions, bson.M{"match.status": filter.MatchStatus})
+	}
+	return conditions
+}
+
+// matchesMovieFilter is the in-memory equivalent of movieConditions
+func matchesMovieFilter(movie *models.Movie, filter MovieFilter) bool {
+	if filter.GenreID > 0 && !hasGenre(movie.Genres, filter.GenreID) {
+		return false
+	}
+	if !inYearRange(movie.ReleaseDate, filter.YearFrom, filter.YearTo) {
+		return false
+	}
+	if filter.MinVoteAverage > 0 && movie.VoteAverage < filter.MinVoteAverage {
+		return false
+	}
+	if filter.MinVoteCount > 0 && movie.VoteCount < filter.MinVoteCount {
+		return false
+	}
+	if filter.RuntimeMin > 0 && movie.Runtime < filter.RuntimeMin {
+		return false
+	}
+	if filter.RuntimeMax > 0 && movie.Runtime > filter.RuntimeMax {
+		return false
+	}
+	if filter.HasIMDbID && movie.IMDbID == "" {
+		return false
+	}
+	if filter.MatchStatus != "" && matchStatus(movie.Match) != filter.MatchStatus {
+		return false
+	}
+	return true
+}
+
+func tvConditions(filter TVFilter) bson.A {
+	conditions := bson.A{}
+	if filter.GenreID > 0 {
+		conditions = append(conditions, bson.M{"genres.id": filter.GenreID})
+	}
+	conditions = yearRange(conditions, "first_air_date", filter.YearFrom, filter.YearTo)
+	if filter.MinVoteAverage > 0 {
+		conditions = append(conditions, bson.M{"vote_average": bson.M{"$gte": filter.MinVoteAverage}})
+	}
+	if filter.MinVoteCount > 0 {
+		conditions = append(conditions, bson.M{"vote_count": bson.M{"$gte": filter.MinVoteCount}})
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, bson.M{"status": filter.Status})
+	}
+	if filter.NetworkID > 0 {
+		conditions = append(conditions, bson.M{"networks.id": filter.NetworkID})
+	}
+	if filter.HasIMDbID {
+		conditions = append(conditions, bson.M{"imdb_id": bson.M{"$gt": ""}})
+	}
+	if filter.MatchStatus != "" {
+		conditions = append(conditions, bson.M{"match.status": filter.MatchStatus})
+	}
+	return conditions
+}
+
+// matchesTVFilter is the in-memory equivalent of tvConditions
+func matchesTVFilter(tv *models.TV, filter TVFilter) bool {
+	if filter.GenreID > 0 && !hasGenre(tv.Genres, filter.GenreID) {
+		return false
+	}
+	if !inYearRange(tv.FirstAirDate, filter.YearFrom, filter.YearTo) {
+		return false
+	}
+	if filter.MinVoteAverage > 0 && tv.VoteAverage < filter.MinVoteAverage {
+		return false
+	}
+	if filter.MinVoteCount > 0 && tv.VoteCount < filter.MinVoteCount {
+		return false
+	}
+	if filter.Status != "" && tv.Status != filter.Status {
+		return false
+	}
+	if filter.HasIMDbID && tv.IMDbID == "" {
+		return false
+	}
+	if filter.MatchStatus != "" && matchStatus(tv.Match) != filter.MatchStatus {
+		return false
+	}
+	if filter.NetworkID > 0 {
+		found := false
+		for _, network := range tv.Networks {
+			if network.ID == filter.NetworkID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func hasGenre(genres []models.Genre, id int) bool {
+	for _, genre := range genres {
+		if genre.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// listFilter combines the filter conditions with the cursor position
+func listFilter(conditions bson.A, spec sortSpec, cursor *sortKey) bson.M {
+	if cursor != nil {
+		conditions = append(conditions, spec.afterFilter(cursor))
+	}
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": conditions}
+}
+
+func matchStatus(match *models.Match) string {
+	if match == nil {
+		return ""
+	}
+	return match.Status
+}
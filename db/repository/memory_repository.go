@@ -0,0 +1,634 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryRepo is a thread-safe, in-memory Repository meant for tests and
+// local development without a MongoDB instance. Documents are deep-copied on
+// the way in and out so callers can never mutate the stored state.
+type MemoryRepo struct {
+	mu      sync.RWMutex
+	movies  map[string]*models.Movie
+	tvShows map[string]*models.TV
+	states  map[string]*models.SyncState
+
+	// Insertion order, so GetAllMovies is deterministic like a collection scan
+	movieOrder []string
+	tvOrder    []string
+}
+
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{
+		movies:  make(map[string]*models.Movie),
+		tvShows: make(map[string]*models.TV),
+		states:  make(map[string]*models.SyncState),
+	}
+}
+
+func (m *MemoryRepo) CreateMovie(ctx context.Context, movie *models.Movie) error {
+	stored, err := clone(movie)
+	if err != nil {
+		return err
+	}
+	if stored.ID.IsZero() {
+		stored.ID = primitive.NewObjectID()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.movies[stored.MovieID]; exists {
+		return nil // Ignore duplicates, same as the unique index on movie_id
+	}
+	m.movies[stored.MovieID] = stored
+	m.movieOrder = append(m.movieOrder, stored.MovieID)
+	return nil
+}
+
+func (m *MemoryRepo) GetMovieById(ctx context.Context, id string) (*models.Movie, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movie, ok := m.movies[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: no movie found with id %s", ErrNotFound, id)
+	}
+	return clone(movie)
+}
+
+func (m *MemoryRepo) SearchMovieByQuery(ctx context.Context, query string) ([]models.Movie, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	terms := searchTerms(query)
+	type hit struct {
+		id    string
+		score float64
+	}
+	var hits []hit
+	for _, id := range m.movieOrder {
+		movie := m.movies[id]
+		if score := textScore(terms, movie.Title, movie.Description); score > 0 {
+			hits = append(hits, hit{id, score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+
+	var movies []models.Movie
+	for _, h := range hits {
+		movie, err := clone(m.movies[h.id])
+		if err != nil {
+			return nil, err
+		}
+		movie.Files = nil
+		movies = append(movies, *movie)
+	}
+	return movies, nil
+}
+
+func (m *MemoryRepo) UpdateMovie(ctx context.Context, movie *models.Movie) error {
+	stored, err := clone(movie)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.movies[stored.MovieID]
+	if !ok {
+		return nil // UpdateOne without upsert is a no-op for unknown documents
+	}
+	stored.ID = existing.ID
+	m.movies[stored.MovieID] = stored
+	return nil
+}
+
+func (m *MemoryRepo) GetAllMovies(ctx context.Context) ([]models.Movie, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movies := make([]models.Movie, 0, len(m.movieOrder))
+	for _, id := range m.movieOrder {
+		movie, err := clone(m.movies[id])
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, *movie)
+	}
+	return movies, nil
+}
+
+func (m *MemoryRepo) GetMoviesWithLimitAndSkip(ctx context.Context, limit, skip int64) ([]models.Movie, error) {
+	all, err := m.GetAllMovies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Title < all[j].Title
+	})
+	return paginate(all, limit, skip), nil
+}
+
+func (m *MemoryRepo) CreateTV(ctx context.Context, tv *models.TV) error {
+	stored, err := clone(tv)
+	if err != nil {
+		return err
+	}
+	if stored.ID == "" {
+		stored.ID = primitive.NewObjectID().Hex()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.tvShows[stored.TVID]; exists {
+		return nil // Ignore duplicates, same as the unique index on tv_id
+	}
+	m.tvShows[stored.TVID] = stored
+	m.tvOrder = append(m.tvOrder, stored.TVID)
+	return nil
+}
+
+func (m *MemoryRepo) GetTVById(ctx context.Context, id string) (*models.TV, error) {
+	tv, err := m.getTV(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove sources from episodes to save bandwidth when just getting show info
+	for i := range tv.Seasons {
+		for j := range tv.Seasons[i].Episodes {
+			tv.Seasons[i].Episodes[j].Sources = nil
+		}
+	}
+	return tv, nil
+}
+
+func (m *MemoryRepo) GetFullTVById(ctx context.Context, id string) (*models.TV, error) {
+	return m.getTV(id)
+}
+
+func (m *MemoryRepo) GetTVSeasonById(ctx context.Context, tvID string, seasonNum int) (*models.Season, error) {
+	tv, err := m.getTV(tvID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, season := range tv.Seasons {
+		if season.SeasonNumber == seasonNum {
+			for i := range season.Episodes {
+				season.Episodes[i].Sources = nil
+			}
+			return &season, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no season %d found for TV series with id %s", ErrNotFound, seasonNum, tvID)
+}
+
+func (m *MemoryRepo) GetTVEpisodeById(ctx context.Context, tvID string, seasonNum int, episodeNum int) (*models.Episode, error) {
+	tv, err := m.getTV(tvID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, season := range tv.Seasons {
+		if season.SeasonNumber == seasonNum {
+			for _, episode := range season.Episodes {
+				if episode.EpisodeNo == episodeNum {
+					return &episode, nil
+				}
+			}
+			return nil, fmt.Errorf("%w: no episode %d found in season %d for TV series with id %s", ErrNotFound, episodeNum, seasonNum, tvID)
+		}
+	}
+	return nil, fmt.Errorf("%w: no season %d found for TV series with id %s", ErrNotFound, seasonNum, tvID)
+}
+
+func (m *MemoryRepo) SearchTVByQuery(ctx context.Context, query string) ([]models.TV, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	terms := searchTerms(query)
+	type hit struct {
+		id    string
+		score float64
+	}
+	var hits []hit
+	for _, id := range m.tvOrder {
+		tv := m.tvShows[id]
+		if score := textScore(terms, tv.Title, tv.Description); score > 0 {
+			hits = append(hits, hit{id, score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+
+	var tvShows []models.TV
+	for _, h := range hits {
+		tv, err := clone(m.tvShows[h.id])
+		if err != nil {
+			return nil, err
+		}
+		// Don't return episode details in search results to reduce payload size
+		for i := range tv.Seasons {
+			tv.Seasons[i].Episodes = nil
+		}
+		tvShows = append(tvShows, *tv)
+	}
+	return tvShows, nil
+}
+
+func (m *MemoryRepo) UpdateTV(ctx context.Context, tv *models.TV) error {
+	stored, err := clone(tv)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.tvShows[stored.TVID]
+	if !ok {
+		return nil // UpdateOne without upsert is a no-op for unknown documents
+	}
+	stored.ID = existing.ID
+	m.tvShows[stored.TVID] = stored
+	return nil
+}
+
+func (m *MemoryRepo) GetAllTVShows(ctx context.Context, limit, skip int64) ([]models.TV, error) {
+	m.mu.RLock()
+	tvShows := make([]models.TV, 0, len(m.tvOrder))
+	for _, id := range m.tvOrder {
+		tv, err := clone(m.tvShows[id])
+		if err != nil {
+			m.mu.RUnlock()
+			return nil, err
+		}
+		// Don't return episode details in list results to reduce payload size
+		for i := range tv.Seasons {
+			tv.Seasons[i].Episodes = nil
+		}
+		tvShows = append(tvShows, *tv)
+	}
+	m.mu.RUnlock()
+
+	sort.SliceStable(tvShows, func(i, j int) bool {
+		return tvShows[i].Title < tvShows[j].Title
+	})
+	return paginate(tvShows, limit, skip), nil
+}
+
+func (m *MemoryRepo) GetMoviesWithExpiringLinks(ctx context.Context, before time.Time, limit, skip int64) ([]models.Movie, error) {
+	all, err := m.GetAllMovies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var movies []models.Movie
+	for _, movie := range all {
+		for _, file := range movie.Files {
+			if len(file.Links) > 0 && utils.LinksExpireBefore(file.Links, before) {
+				movies = append(movies, movie)
+				break
+			}
+		}
+	}
+	sort.SliceStable(movies, func(i, j int) bool {
+		return movies[i].MovieID < movies[j].MovieID
+	})
+	return paginate(movies, limit, skip), nil
+}
+
+func (m *MemoryRepo) GetTVShowsWithExpiringLinks(ctx context.Context, before time.Time, limit, skip int64) ([]models.TV, error) {
+	m.mu.RLock()
+	var tvShows []models.TV
+	for _, id := range m.tvOrder {
+		if !tvHasExpiringLinks(m.tvShows[id], before) {
+			continue
+		}
+		tv, err := clone(m.tvShows[id])
+		if err != nil {
+			m.mu.RUnlock()
+			return nil, err
+		}
+		tvShows = append(tvShows, *tv)
+	}
+	m.mu.RUnlock()
+
+	sort.SliceStable(tvShows, func(i, j int) bool {
+		return tvShows[i].TVID < tvShows[j].TVID
+	})
+	return paginate(tvShows, limit, skip), nil
+}
+
+func (m *MemoryRepo) ListMovies(ctx context.Context, filter MovieFilter) (*Page[models.Movie], error) {
+	spec, err := movieSortSpec(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(filter.Cursor, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := m.GetAllMovies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	movies := []models.Movie{}
+	for i := range all {
+		if !matchesMovieFilter(&all[i], filter) {
+			continue
+		}
+		if cursor != nil && compareKeys(movieSortKey(&all[i], spec), *cursor, spec) <= 0 {
+			continue
+		}
+		all[i].Files = nil
+		movies = append(movies, all[i])
+	}
+	sort.Slice(movies, func(i, j int) bool {
+		return compareKeys(movieSortKey(&movies[i], spec), movieSortKey(&movies[j], spec), spec) < 0
+	})
+
+	movies = paginate(movies, 0, filter.Skip)
+	page := &Page[models.Movie]{Results: movies}
+	if limit := pageLimit(filter.Limit); int64(len(movies)) > limit {
+		page.Results = movies[:limit]
+		page.NextCursor = encodeCursor(movieSortKey(&page.Results[limit-1], spec))
+	}
+	return page, nil
+}
+
+func (m *MemoryRepo) ListTV(ctx context.Context, filter TVFilter) (*Page[models.TV], error) {
+	spec, err := tvSortSpec(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(filter.Cursor, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := m.GetAllTVShows(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	tvShows := []models.TV{}
+	for i := range all {
+		if !matchesTVFilter(&all[i], filter) {
+			continue
+		}
+		if cursor != nil && compareKeys(tvSortKey(&all[i], spec), *cursor, spec) <= 0 {
+			continue
+		}
+		tvShows = append(tvShows, all[i])
+	}
+	sort.Slice(tvShows, func(i, j int) bool {
+		return compareKeys(tvSortKey(&tvShows[i], spec), tvSortKey(&tvShows[j], spec), spec) < 0
+	})
+
+	tvShows = paginate(tvShows, 0, filter.Skip)
+	page := &Page[models.TV]{Results: tvShows}
+	if limit := pageLimit(filter.Limit); int64(len(tvShows)) > limit {
+		page.Results = tvShows[:limit]
+		page.NextCursor = encodeCursor(tvSortKey(&page.Results[limit-1], spec))
+	}
+	return page, nil
+}
+
+func (m *MemoryRepo) GetMovieByIMDbID(ctx context.Context, imdbID string) (*models.Movie, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, id := range m.movieOrder {
+		if movie := m.movies[id]; imdbID != "" && movie.IMDbID == imdbID {
+			return clone(movie)
+		}
+	}
+	return nil, fmt.Errorf("%w: no movie with IMDb ID %s", ErrNotFound, imdbID)
+}
+
+func (m *MemoryRepo) GetTVByIMDbID(ctx context.Context, imdbID string) (*models.TV, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, id := range m.tvOrder {
+		if tv := m.tvShows[id]; imdbID != "" && tv.IMDbID == imdbID {
+			return clone(tv)
+		}
+	}
+	return nil, fmt.Errorf("%w: no TV series with IMDb ID %s", ErrNotFound, imdbID)
+}
+
+func (m *MemoryRepo) GetMovieByTMDBID(ctx context.Context, tmdbID int) (*models.Movie, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, id := range m.movieOrder {
+		if movie := m.movies[id]; tmdbID != 0 && movie.TMDBID == tmdbID {
+			return clone(movie)
+		}
+	}
+	return nil, fmt.Errorf("%w: no movie with TMDB ID %d", ErrNotFound, tmdbID)
+}
+
+func (m *MemoryRepo) GetTVByTMDBID(ctx context.Context, tmdbID int) (*models.TV, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, id := range m.tvOrder {
+		if tv := m.tvShows[id]; tmdbID != 0 && tv.TMDBID == tmdbID {
+			return clone(tv)
+		}
+	}
+	return nil, fmt.Errorf("%w: no TV series with TMDB ID %d", ErrNotFound, tmdbID)
+}
+
+func tvHasExpiringLinks(tv *models.TV, before time.Time) bool {
+	for _, season := range tv.Seasons {
+		for _, episode := range season.Episodes {
+			for _, source := range episode.Sources {
+				for _, file := range source.Files {
+					if len(file.Links) > 0 && utils.LinksExpireBefore(file.Links, before) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (m *MemoryRepo) GetOngoingTVShows(ctx context.Context, airedSince time.Time) ([]models.TV, error) {
+	m.mu.RLock()
+	var tvShows []models.TV
+	for _, id := range m.tvOrder {
+		if !m.tvShows[id].IsOngoing(airedSince) {
+			continue
+		}
+		tv, err := clone(m.tvShows[id])
+		if err != nil {
+			m.mu.RUnlock()
+			return nil, err
+		}
+		tvShows = append(tvShows, *tv)
+	}
+	m.mu.RUnlock()
+
+	sort.SliceStable(tvShows, func(i, j int) bool {
+		return tvShows[i].TVID < tvShows[j].TVID
+	})
+	return tvShows, nil
+}
+
+// getTV returns a private copy of the full TV document
+func (m *MemoryRepo) getTV(id string) (*models.TV, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tv, ok := m.tvShows[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: no TV series found with id %s", ErrNotFound, id)
+	}
+	return clone(tv)
+}
+
+func (m *MemoryRepo) GetSyncState(ctx context.Context, name string) (*models.SyncState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, ok := m.states[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: no sync state %s", ErrNotFound, name)
+	}
+	copied := *state
+	return &copied, nil
+}
+
+func (m *MemoryRepo) SaveSyncState(ctx context.Context, state *models.SyncState) error {
+	state.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	stored := *state
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.states[stored.Name] = &stored
+	return nil
+}
+
+// clone deep-copies a document by round-tripping it through BSON, so the copy
+// matches exactly what MongoDB would hand back
+func clone[T any](doc *T) (*T, error) {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy document: %w", err)
+	}
+	var out T
+	if err := bson.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to copy document: %w", err)
+	}
+	return &out, nil
+}
+
+// paginate applies a Mongo-style skip and limit, where a limit of 0 means no
+// limit. It never returns nil, so empty pages serialise as [] like Mongo's.
+func paginate[T any](items []T, limit, skip int64) []T {
+	if skip >= int64(len(items)) {
+		return []T{}
+	}
+	items = items[skip:]
+	if limit > 0 && limit < int64(len(items)) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// searchTerms splits a query into lower-cased words, the way a text index
+// tokenizes its input
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// textScore is a crude stand-in for MongoDB's textScore: every query term
+// found in the title counts double, terms found in the description count once
+func textScore(terms []string, title, description string) float64 {
+	titleWords := make(map[string]bool)
+	for _, w := range searchTerms(title) {
+		titleWords[w] = true
+	}
+	descWords := make(map[string]bool)
+	for _, w := range searchTerms(description) {
+		descWords[w] = true
+	}
+
+	var score float64
+	for _, term := range terms {
+		if titleWords[term] {
+			score += 2
+		}
+		if descWords[term] {
+			score++
+		}
+	}
+	return score
+}
+
+// IterateMovies calls fn outside the lock, so fn may write to the repository
+func (m *MemoryRepo) IterateMovies(ctx context.Context, fn func(*models.Movie) error) error {
+	m.mu.RLock()
+	ids := append([]string(nil), m.movieOrder...)
+	m.mu.RUnlock()
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		movie, err := m.GetMovieById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(movie); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryRepo) IterateTVShows(ctx context.Context, fn func(*models.TV) error) error {
+	m.mu.RLock()
+	ids := append([]string(nil), m.tvOrder...)
+	m.mu.RUnlock()
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		tv, err := m.getTV(id)
+		if err != nil {
+			return err
+		}
+		if err := fn(tv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
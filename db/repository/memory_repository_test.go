@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+)
+
+func sampleTV() *models.TV {
+	return &models.TV{
+		TVID:   "2001",
+		Title:  "Sample Show",
+		IMDbID: "tt0000201",
+		TMDBID: 201,
+		Seasons: []models.Season{{
+			SeasonNumber: 1,
+			Episodes: []models.Episode{{
+				EpisodeNo: 1,
+				Sources: []models.Source{{
+					SourceName: "HEVC/x265",
+					Files:      []models.File{{FID: 101}},
+				}},
+			}},
+		}},
+	}
+}
+
+func TestMemoryRepoMovieCRUD(t *testing.T) {
+	repo := NewMemoryRepo()
+	ctx := context.Background()
+
+	movie := &models.Movie{MovieID: "1056", Title: "The Matrix", Files: []models.File{{FID: 301}}}
+	if err := repo.CreateMovie(ctx, movie); err != nil {
+		t.Fatalf("CreateMovie: %v", err)
+	}
+	// Duplicates are ignored like the unique index does
+	if err := repo.CreateMovie(ctx, &models.Movie{MovieID: "1056", Title: "Duplicate"}); err != nil {
+		t.Fatalf("CreateMovie duplicate: %v", err)
+	}
+	// The stored copy doesn't follow changes to the caller's value
+	movie.Files[0].FID = 999
+
+	got, err := repo.GetMovieById(ctx, "1056")
+	if err != nil {
+		t.Fatalf("GetMovieById: %v", err)
+	}
+	if got.Title != "The Matrix" || got.Files[0].FID != 301 || got.ID.IsZero() {
+		t.Errorf("got %+v, want the first movie with fid 301 and an ID", got)
+	}
+
+	got.Title = "The Matrix (1999)"
+	if err := repo.UpdateMovie(ctx, got); err != nil {
+		t.Fatalf("UpdateMovie: %v", err)
+	}
+	// Unknown documents aren't upserted
+	if err := repo.UpdateMovie(ctx, &models.Movie{MovieID: "9999"}); err != nil {
+		t.Fatalf("UpdateMovie unknown: %v", err)
+	}
+
+	all, err := repo.GetAllMovies(ctx)
+	if err != nil {
+		t.Fatalf("GetAllMovies: %v", err)
+	}
+	if len(all) != 1 || all[0].Title != "The Matrix (1999)" {
+		t.Errorf("got movies %+v, want only the updated one", all)
+	}
+}
+
+func TestMemoryRepoTVLookups(t *testing.T) {
+	repo := NewMemoryRepo()
+	ctx := context.Background()
+	if err := repo.CreateTV(ctx, sampleTV()); err != nil {
+		t.Fatalf("CreateTV: %v", err)
+	}
+
+	tv, err := repo.GetTVById(ctx, "2001")
+	if err != nil {
+		t.Fatalf("GetTVById: %v", err)
+	}
+	if sources := tv.Seasons[0].Episodes[0].Sources; sources != nil {
+		t.Errorf("GetTVById returned sources %+v", sources)
+	}
+
+	full, err := repo.GetFullTVById(ctx, "2001")
+	if err != nil {
+		t.Fatalf("GetFullTVById: %v", err)
+	}
+	if !reflect.DeepEqual(full.Seasons, sampleTV().Seasons) {
+		t.Errorf("got seasons %+v, want %+v", full.Seasons, sampleTV().Seasons)
+	}
+
+	season, err := repo.GetTVSeasonById(ctx, "2001", 1)
+	if err != nil {
+		t.Fatalf("GetTVSeasonById: %v", err)
+	}
+	if len(season.Episodes) != 1 || season.Episodes[0].Sources != nil {
+		t.Errorf("got season %+v, want one episode without sources", season)
+	}
+
+	episode, err := repo.GetTVEpisodeById(ctx, "2001", 1, 1)
+	if err != nil {
+		t.Fatalf("GetTVEpisodeById: %v", err)
+	}
+	if len(episode.Sources) != 1 {
+		t.Errorf("got episode %+v, want its source", episode)
+	}
+
+	byIMDb, err := repo.GetTVByIMDbID(ctx, "tt0000201")
+	if err != nil || byIMDb.TVID != "2001" {
+		t.Errorf("GetTVByIMDbID = %+v, %v", byIMDb, err)
+	}
+	byTMDB, err := repo.GetTVByTMDBID(ctx, 201)
+	if err != nil || byTMDB.TVID != "2001" {
+		t.Errorf("GetTVByTMDBID = %+v, %v", byTMDB, err)
+	}
+}
+
+func TestMemoryRepoNotFound(t *testing.T) {
+	repo := NewMemoryRepo()
+	ctx := context.Background()
+	if err := repo.CreateTV(ctx, sampleTV()); err != nil {
+		t.Fatalf("CreateTV: %v", err)
+	}
+	// Stored without external IDs, which empty lookups must not find
+	if err := repo.CreateMovie(ctx, &models.Movie{MovieID: "1057", Title: "Inception"}); err != nil {
+		t.Fatalf("CreateMovie: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		lookup func() error
+	}{
+		{"movie", func() error { _, err := repo.GetMovieById(ctx, "nope"); return err }},
+		{"tv", func() error { _, err := repo.GetTVById(ctx, "nope"); return err }},
+		{"full tv", func() error { _, err := repo.GetFullTVById(ctx, "nope"); return err }},
+		{"season of unknown tv", func() error { _, err := repo.GetTVSeasonById(ctx, "nope", 1); return err }},
+		{"unknown season", func() error { _, err := repo.GetTVSeasonById(ctx, "2001", 2); return err }},
+		{"episode of unknown season", func() error { _, err := repo.GetTVEpisodeById(ctx, "2001", 2, 1); return err }},
+		{"unknown episode", func() error { _, err := repo.GetTVEpisodeById(ctx, "2001", 1, 2); return err }},
+		{"movie imdb", func() error { _, err := repo.GetMovieByIMDbID(ctx, "tt404"); return err }},
+		{"empty movie imdb", func() error { _, err := repo.GetMovieByIMDbID(ctx, ""); return err }},
+		{"empty tv imdb", func() error { _, err := repo.GetTVByIMDbID(ctx, ""); return err }},
+		{"zero movie tmdb", func() error { _, err := repo.GetMovieByTMDBID(ctx, 0); return err }},
+		{"tv tmdb", func() error { _, err := repo.GetTVByTMDBID(ctx, 404); return err }},
+		{"sync state", func() error { _, err := repo.GetSyncState(ctx, "tmdb"); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.lookup(); !errors.Is(err, ErrNotFound) {
+				t.Errorf("got error %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestMemoryRepoSearch(t *testing.T) {
+	repo := NewMemoryRepo()
+	ctx := context.Background()
+	movies := []models.Movie{
+		{MovieID: "1", Title: "Space Station", Description: "A crew adrift."},
+		{MovieID: "2", Title: "Deep Sea", Description: "Divers find a station in space."},
+		{MovieID: "3", Title: "Station Eleven", Files: []models.File{{FID: 1}}},
+	}
+	for i := range movies {
+		if err := repo.CreateMovie(ctx, &movies[i]); err != nil {
+			t.Fatalf("CreateMovie: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Title matches count double, ties keep insertion order
+		{"space station", []string{"1", "2", "3"}},
+		{"STATION", []string{"1", "3", "2"}},
+		{"divers", []string{"2"}},
+		{"nothing here", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := repo.SearchMovieByQuery(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("SearchMovieByQuery: %v", err)
+			}
+			var ids []string
+			for _, movie := range got {
+				ids = append(ids, movie.MovieID)
+				if movie.Files != nil {
+					t.Errorf("movie %s returned with files", movie.MovieID)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("got %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		name        string
+		limit, skip int64
+		want        []int
+	}{
+		{"no limit", 0, 0, []int{1, 2, 3, 4, 5}},
+		{"limit", 2, 0, []int{1, 2}},
+		{"skip", 0, 3, []int{4, 5}},
+		{"limit and skip", 2, 1, []int{2, 3}},
+		{"limit past the end", 10, 3, []int{4, 5}},
+		{"skip everything", 2, 5, []int{}},
+		{"skip past the end", 0, 7, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := paginate(items, tt.limit, tt.skip)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryRepoEmptyListIsNotNil(t *testing.T) {
+	page, err := NewMemoryRepo().ListMovies(context.Background(), MovieFilter{})
+	if err != nil {
+		t.Fatalf("ListMovies: %v", err)
+	}
+	if page.Results == nil {
+		t.Error("got nil results, want an empty slice")
+	}
+}
@@ -4,12 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"time"
 
 	"github.com/amankumarsingh77/go-showbox-api/db/models"
-	"github.com/amankumarsingh77/go-showbox-api/db/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -17,12 +16,16 @@ import (
 type MongoRepo struct {
 	moviecol *mongo.Collection
 	tvcol    *mongo.Collection
+	statecol *mongo.Collection
 }
 
+// NewMongoRepo keeps sync state in the sync_state collection of the movie
+// collection's database
 func NewMongoRepo(moviecol *mongo.Collection, tvcol *mongo.Collection) *MongoRepo {
 	return &MongoRepo{
 		moviecol: moviecol,
 		tvcol:    tvcol,
+		statecol: moviecol.Database().Collection("sync_state"),
 	}
 }
 
@@ -45,54 +48,16 @@ func (m *MongoRepo) GetMovieById(ctx context.Context, id string) (*models.Movie,
 	defer cancel()
 	var movie models.Movie
 	err := m.moviecol.FindOne(ctx, bson.M{"movie_id": id}).Decode(&movie)
-	if movie.MovieID == "" {
-		return nil, fmt.Errorf("no movie found with id %s", id)
-	}
-	getUpdatedStream(&movie)
 	if err != nil {
-		return nil, err
-	}
-	return &movie, nil
-}
-
-func getUpdatedStream(movie *models.Movie) {
-	url := movie.Files[0].Links[0].URL
-	resp, err := http.Head(url)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if resp.StatusCode == http.StatusGone {
-		err = utils.UpdateStream(movie)
-		if err != nil {
-			log.Fatal(err)
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: no movie found with id %s", ErrNotFound, id)
 		}
+		return nil, err
 	}
-}
-
-// getUpdatedEpisodeStream checks if episode links are valid and updates them if needed
-func getUpdatedEpisodeStream(episode *models.Episode) {
-	// Skip if there are no sources or files
-	if len(episode.Sources) == 0 || len(episode.Sources[0].Files) == 0 || len(episode.Sources[0].Files[0].Links) == 0 {
-		return
-	}
-
-	// Check the first link of the first file of the first source
-	url := episode.Sources[0].Files[0].Links[0].URL
-	resp, err := http.Head(url)
-	if err != nil {
-		log.Printf("Error checking episode link: %v", err)
-		return
-	}
-
-	// If link is expired (status 410 Gone), update all sources
-	if resp.StatusCode == http.StatusGone {
-		for i := range episode.Sources {
-			err = utils.UpdateEpisodeStream(&episode.Sources[i])
-			if err != nil {
-				log.Printf("Error updating episode source: %v", err)
-			}
-		}
+	if movie.MovieID == "" {
+		return nil, fmt.Errorf("%w: no movie found with id %s", ErrNotFound, id)
 	}
+	return &movie, nil
 }
 
 func (m *MongoRepo) SearchMovieByQuery(ctx context.Context, query string) ([]models.Movie, error) {
@@ -152,13 +117,13 @@ func (m *MongoRepo) GetTVById(ctx context.Context, id string) (*models.TV, error
 	err := m.tvcol.FindOne(ctx, bson.M{"tv_id": id}).Decode(&tv)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("no TV series found with id %s", id)
+			return nil, fmt.Errorf("%w: no TV series found with id %s", ErrNotFound, id)
 		}
 		return nil, err
 	}
 
 	if tv.TVID == "" {
-		return nil, fmt.Errorf("no TV series found with id %s", id)
+		return nil, fmt.Errorf("%w: no TV series found with id %s", ErrNotFound, id)
 	}
 
 	// Remove sources from episodes to save bandwidth when just getting show info
@@ -171,6 +136,23 @@ func (m *MongoRepo) GetTVById(ctx context.Context, id string) (*models.TV, error
 	return &tv, nil
 }
 
+// GetFullTVById retrieves a full TV document, episode sources included, by
+// its showbox ID
+func (m *MongoRepo) GetFullTVById(ctx context.Context, id string) (*models.TV, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var tv models.TV
+	err := m.tvcol.FindOne(ctx, bson.M{"tv_id": id}).Decode(&tv)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: no TV series found with id %s", ErrNotFound, id)
+		}
+		return nil, err
+	}
+	return &tv, nil
+}
+
 // GetTVSeasonById retrieves a specific season of a TV show by ID and season number
 func (m *MongoRepo) GetTVSeasonById(ctx context.Context, tvID string, seasonNum int) (*models.Season, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -180,7 +162,7 @@ func (m *MongoRepo) GetTVSeasonById(ctx context.Context, tvID string, seasonNum
 	err := m.tvcol.FindOne(ctx, bson.M{"tv_id": tvID}).Decode(&tv)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("no TV series found with id %s", tvID)
+			return nil, fmt.Errorf("%w: no TV series found with id %s", ErrNotFound, tvID)
 		}
 		return nil, err
 	}
@@ -195,7 +177,7 @@ func (m *MongoRepo) GetTVSeasonById(ctx context.Context, tvID string, seasonNum
 		}
 	}
 
-	return nil, fmt.Errorf("no season %d found for TV series with id %s", seasonNum, tvID)
+	return nil, fmt.Errorf("%w: no season %d found for TV series with id %s", ErrNotFound, seasonNum, tvID)
 }
 
 // GetTVEpisodeById retrieves a specific episode of a TV show by ID, season number, and episode number
@@ -207,7 +189,7 @@ func (m *MongoRepo) GetTVEpisodeById(ctx context.Context, tvID string, seasonNum
 	err := m.tvcol.FindOne(ctx, bson.M{"tv_id": tvID}).Decode(&tv)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("no TV series found with id %s", tvID)
+			return nil, fmt.Errorf("%w: no TV series found with id %s", ErrNotFound, tvID)
 		}
 		return nil, err
 	}
@@ -216,20 +198,14 @@ func (m *MongoRepo) GetTVEpisodeById(ctx context.Context, tvID string, seasonNum
 		if season.SeasonNumber == seasonNum {
 			for _, episode := range season.Episodes {
 				if episode.EpisodeNo == episodeNum {
-					// Create a copy to avoid modifying the stored document
-					episodeCopy := episode
-
-					// Check and update links if necessary
-					getUpdatedEpisodeStream(&episodeCopy)
-
-					return &episodeCopy, nil
+					return &episode, nil
 				}
 			}
-			return nil, fmt.Errorf("no episode %d found in season %d for TV series with id %s", episodeNum, seasonNum, tvID)
+			return nil, fmt.Errorf("%w: no episode %d found in season %d for TV series with id %s", ErrNotFound, episodeNum, seasonNum, tvID)
 		}
 	}
 
-	return nil, fmt.Errorf("no season %d found for TV series with id %s", seasonNum, tvID)
+	return nil, fmt.Errorf("%w: no season %d found for TV series with id %s", ErrNotFound, seasonNum, tvID)
 }
 
 func (m *MongoRepo) SearchTVByQuery(ctx context.Context, query string) ([]models.TV, error) {
@@ -371,3 +347,297 @@ func (m *MongoRepo) GetMoviesWithLimitAndSkip(ctx context.Context, limit, skip i
 	log.Printf("Retrieved %d movies (limit: %d, skip: %d)", len(movies), limit, skip)
 	return movies, nil
 }
+
+// expiringLinksFilter matches links that are already expired at before, or
+// that were stored before expiry tracking existed
+func expiringLinksFilter(before time.Time) bson.M {
+	return bson.M{
+		"$elemMatch": bson.M{
+			"$or": bson.A{
+				bson.M{"expires_at": bson.M{"$exists": false}},
+				bson.M{"expires_at": bson.M{"$lt": primitive.NewDateTimeFromTime(before)}},
+			},
+		},
+	}
+}
+
+// GetMoviesWithExpiringLinks retrieves full movie documents that have at least
+// one link expiring before the given time
+func (m *MongoRepo) GetMoviesWithExpiringLinks(ctx context.Context, before time.Time, limit, skip int64) ([]models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"files.links": expiringLinksFilter(before)}
+	options := options.Find().SetLimit(limit).SetSkip(skip).SetSort(bson.M{"movie_id": 1})
+	cursor, err := m.moviecol.Find(ctx, filter, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find movies with expiring links: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var movies []models.Movie
+	if err := cursor.All(ctx, &movies); err != nil {
+		return nil, fmt.Errorf("failed to decode movies: %w", err)
+	}
+	return movies, nil
+}
+
+// GetTVShowsWithExpiringLinks retrieves full TV documents, sources included,
+// that have at least one episode link expiring before the given time
+func (m *MongoRepo) GetTVShowsWithExpiringLinks(ctx context.Context, before time.Time, limit, skip int64) ([]models.TV, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"seasons.episodes.sources.files.links": expiringLinksFilter(before)}
+	options := options.Find().SetLimit(limit).SetSkip(skip).SetSort(bson.M{"tv_id": 1})
+	cursor, err := m.tvcol.Find(ctx, filter, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find TV shows with expiring links: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tvShows []models.TV
+	if err := cursor.All(ctx, &tvShows); err != nil {
+		return nil, fmt.Errorf("failed to decode TV shows: %w", err)
+	}
+	return tvShows, nil
+}
+
+// ListMovies returns one page of movies matching filter, without files
+func (m *MongoRepo) ListMovies(ctx context.Context, filter MovieFilter) (*Page[models.Movie], error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	spec, err := movieSortSpec(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(filter.Cursor, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra document to know whether there's a next page
+	limit := pageLimit(filter.Limit)
+	opts := options.Find().
+		SetSort(spec.mongoSort()).
+		SetSkip(filter.Skip).
+		SetLimit(limit + 1).
+		SetProjection(bson.M{"files": 0})
+	results, err := m.moviecol.Find(ctx, listFilter(movieConditions(filter), spec, cursor), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list movies: %w", err)
+	}
+	defer results.Close(ctx)
+
+	movies := []models.Movie{}
+	if err := results.All(ctx, &movies); err != nil {
+		return nil, fmt.Errorf("failed to decode movies: %w", err)
+	}
+
+	page := &Page[models.Movie]{Results: movies}
+	if int64(len(movies)) > limit {
+		page.Results = movies[:limit]
+		page.NextCursor = encodeCursor(movieSortKey(&page.Results[limit-1], spec))
+	}
+	return page, nil
+}
+
+// ListTV returns one page of TV shows matching filter, without episodes
+func (m *MongoRepo) ListTV(ctx context.Context, filter TVFilter) (*Page[models.TV], error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	spec, err := tvSortSpec(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(filter.Cursor, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := pageLimit(filter.Limit)
+	opts := options.Find().
+		SetSort(spec.mongoSort()).
+		SetSkip(filter.Skip).
+		SetLimit(limit + 1).
+		SetProjection(bson.M{"seasons.episodes": 0})
+	results, err := m.tvcol.Find(ctx, listFilter(tvConditions(filter), spec, cursor), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TV shows: %w", err)
+	}
+	defer results.Close(ctx)
+
+	tvShows := []models.TV{}
+	if err := results.All(ctx, &tvShows); err != nil {
+		return nil, fmt.Errorf("failed to decode TV shows: %w", err)
+	}
+
+	page := &Page[models.TV]{Results: tvShows}
+	if int64(len(tvShows)) > limit {
+		page.Results = tvShows[:limit]
+		page.NextCursor = encodeCursor(tvSortKey(&page.Results[limit-1], spec))
+	}
+	return page, nil
+}
+
+// GetMovieByIMDbID retrieves a movie, files included, by its IMDb ID
+func (m *MongoRepo) GetMovieByIMDbID(ctx context.Context, imdbID string) (*models.Movie, error) {
+	// An empty IMDb or TMDB ID never matches, as in MemoryRepo
+	if imdbID == "" {
+		return nil, fmt.Errorf("%w: no movie with IMDb ID %s", ErrNotFound, imdbID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var movie models.Movie
+	err := m.moviecol.FindOne(ctx, bson.M{"imdb_id": imdbID}).Decode(&movie)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: no movie with IMDb ID %s", ErrNotFound, imdbID)
+		}
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// GetTVByIMDbID retrieves a full TV document, episode sources included, by
+// its IMDb ID
+func (m *MongoRepo) GetTVByIMDbID(ctx context.Context, imdbID string) (*models.TV, error) {
+	if imdbID == "" {
+		return nil, fmt.Errorf("%w: no TV series with IMDb ID %s", ErrNotFound, imdbID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var tv models.TV
+	err := m.tvcol.FindOne(ctx, bson.M{"imdb_id": imdbID}).Decode(&tv)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: no TV series with IMDb ID %s", ErrNotFound, imdbID)
+		}
+		return nil, err
+	}
+	return &tv, nil
+}
+
+// GetMovieByTMDBID retrieves a movie, files included, by its TMDB ID
+func (m *MongoRepo) GetMovieByTMDBID(ctx context.Context, tmdbID int) (*models.Movie, error) {
+	if tmdbID == 0 {
+		return nil, fmt.Errorf("%w: no movie with TMDB ID %d", ErrNotFound, tmdbID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var movie models.Movie
+	err := m.moviecol.FindOne(ctx, bson.M{"tmdb_id": tmdbID}).Decode(&movie)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: no movie with TMDB ID %d", ErrNotFound, tmdbID)
+		}
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// GetTVByTMDBID retrieves a full TV document, episode sources included, by
+// its TMDB ID
+func (m *MongoRepo) GetTVByTMDBID(ctx context.Context, tmdbID int) (*models.TV, error) {
+	if tmdbID == 0 {
+		return nil, fmt.Errorf("%w: no TV series with TMDB ID %d", ErrNotFound, tmdbID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var tv models.TV
+	err := m.tvcol.FindOne(ctx, bson.M{"tmdb_id": tmdbID}).Decode(&tv)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: no TV series with TMDB ID %d", ErrNotFound, tmdbID)
+		}
+		return nil, err
+	}
+	return &tv, nil
+}
+
+// IterateMovies streams all movies in _id order. There's no overall timeout,
+// as fn may take as long as it needs; ctx bounds the whole scan.
+func (m *MongoRepo) IterateMovies(ctx context.Context, fn func(*models.Movie) error) error {
+	return iterate(ctx, m.moviecol, fn)
+}
+
+// IterateTVShows streams all TV shows in _id order, like IterateMovies
+func (m *MongoRepo) IterateTVShows(ctx context.Context, fn func(*models.TV) error) error {
+	return iterate(ctx, m.tvcol, fn)
+}
+
+func iterate[T any](ctx context.Context, col *mongo.Collection, fn func(*T) error) error {
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetBatchSize(100)
+	cursor, err := col.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %w", col.Name(), err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc T
+		if err := cursor.Decode(&doc); err != nil {
+			return fmt.Errorf("failed to decode %s document: %w", col.Name(), err)
+		}
+		if err := fn(&doc); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (m *MongoRepo) GetSyncState(ctx context.Context, name string) (*models.SyncState, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var state models.SyncState
+	err := m.statecol.FindOne(ctx, bson.M{"_id": name}).Decode(&state)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: no sync state %s", ErrNotFound, name)
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (m *MongoRepo) SaveSyncState(ctx context.Context, state *models.SyncState) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	state.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	_, err := m.statecol.ReplaceOne(ctx, bson.M{"_id": state.Name}, state, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save sync state %s: %w", state.Name, err)
+	}
+	return nil
+}
+
+// GetOngoingTVShows retrieves the full documents of shows that still get new
+// episodes. last_air_date is stored as YYYY-MM-DD, so it compares as a string.
+func (m *MongoRepo) GetOngoingTVShows(ctx context.Context, airedSince time.Time) ([]models.TV, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": bson.M{"$in": bson.A{models.StatusReturningSeries, models.StatusInProduction}}},
+		bson.M{"last_air_date": bson.M{"$gte": airedSince.Format(time.DateOnly)}},
+	}}
+	cursor, err := m.tvcol.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "tv_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ongoing TV shows: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tvShows []models.TV
+	if err := cursor.All(ctx, &tvShows); err != nil {
+		return nil, fmt.Errorf("failed to decode ongoing TV shows: %w", err)
+	}
+	return tvShows, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+)
+
+// ErrNotFound is wrapped by lookups that match no document
+var ErrNotFound = errors.New("not found")
+
+// MovieRepository is the storage contract for movies
+type MovieRepository interface {
+	CreateMovie(ctx context.Context, movie *models.Movie) error
+	GetMovieById(ctx context.Context, id string) (*models.Movie, error)
+	SearchMovieByQuery(ctx context.Context, query string) ([]models.Movie, error)
+	UpdateMovie(ctx context.Context, movie *models.Movie) error
+	GetAllMovies(ctx context.Context) ([]models.Movie, error)
+	GetMoviesWithLimitAndSkip(ctx context.Context, limit, skip int64) ([]models.Movie, error)
+	GetMoviesWithExpiringLinks(ctx context.Context, before time.Time, limit, skip int64) ([]models.Movie, error)
+	ListMovies(ctx context.Context, filter MovieFilter) (*Page[models.Movie], error)
+	GetMovieByIMDbID(ctx context.Context, imdbID string) (*models.Movie, error)
+	GetMovieByTMDBID(ctx context.Context, tmdbID int) (*models.Movie, error)
+	// IterateMovies streams every movie, files included, to fn without
+	// loading the collection into memory. It stops at the first error fn
+	// returns and passes it on.
+	IterateMovies(ctx context.Context, fn func(*models.Movie) error) error
+}
+
+// TVRepository is the storage contract for TV shows
+type TVRepository interface {
+	CreateTV(ctx context.Context, tv *models.TV) error
+	GetTVById(ctx context.Context, id string) (*models.TV, error)
+	// GetFullTVById returns the full document, episode sources included, for
+	// callers that modify and save it
+	GetFullTVById(ctx context.Context, id string) (*models.TV, error)
+	GetTVSeasonById(ctx context.Context, tvID string, seasonNum int) (*models.Season, error)
+	GetTVEpisodeById(ctx context.Context, tvID string, seasonNum int, episodeNum int) (*models.Episode, error)
+	SearchTVByQuery(ctx context.Context, query string) ([]models.TV, error)
+	UpdateTV(ctx context.Context, tv *models.TV) error
+	GetAllTVShows(ctx context.Context, limit, skip int64) ([]models.TV, error)
+	GetTVShowsWithExpiringLinks(ctx context.Context, before time.Time, limit, skip int64) ([]models.TV, error)
+	ListTV(ctx context.Context, filter TVFilter) (*Page[models.TV], error)
+	// GetTVByIMDbID returns the full document, episode sources included
+	GetTVByIMDbID(ctx context.Context, imdbID string) (*models.TV, error)
+	GetTVByTMDBID(ctx context.Context, tmdbID int) (*models.TV, error)
+	// IterateTVShows streams every full TV document to fn, like IterateMovies
+	IterateTVShows(ctx context.Context, fn func(*models.TV) error) error
+	// GetOngoingTVShows returns the full documents of the shows TMDB lists as
+	// returning or in production, or whose last episode aired on or after
+	// airedSince
+	GetOngoingTVShows(ctx context.Context, airedSince time.Time) ([]models.TV, error)
+}
+
+// SyncStateRepository keeps the watermarks of recurring syncs
+type SyncStateRepository interface {
+	// GetSyncState wraps ErrNotFound when name has never been saved
+	GetSyncState(ctx context.Context, name string) (*models.SyncState, error)
+	// SaveSyncState creates or replaces the state stored under state.Name
+	SaveSyncState(ctx context.Context, state *models.SyncState) error
+}
+
+// Repository combines the movie, TV and sync state contracts, which is what
+// the API, the TMDB sync and the scrapers depend on
+type Repository interface {
+	MovieRepository
+	TVRepository
+	SyncStateRepository
+}
+
+var (
+	_ Repository = (*MongoRepo)(nil)
+	_ Repository = (*MemoryRepo)(nil)
+	_ Repository = (*DryRunRepo)(nil)
+)
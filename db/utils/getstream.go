@@ -1,20 +1,32 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/cookiepool"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-const feboxBase = "https://www.febbox.com"
+// FebboxBase is the default febbox host
+const FebboxBase = "https://www.febbox.com"
+
+// LinkTTL is how long a freshly resolved febbox link is trusted before it
+// has to be resolved again
+const LinkTTL = 4 * time.Hour
 
 type FileInfo struct {
 	Fid       int64  `json:"fid"`
@@ -29,60 +41,198 @@ type VideoQuality struct {
 	Size    string `json:"size"`
 }
 
-func UpdateStream(movie *models.Movie) error {
-	for i := range movie.Files {
-		file := &movie.Files[i]
+// Streamer resolves febbox stream links. The zero value is not usable; use
+// NewStreamer.
+type Streamer struct {
+	client  *http.Client
+	baseURL string
+	cookies *cookiepool.Pool
+}
 
-		url := fmt.Sprintf("%s/console/video_quality_list?fid=%s?type=1", feboxBase, strconv.FormatInt(file.FID, 10))
-		client := &http.Client{
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return nil
-			},
-		}
+// NewStreamer creates a streamer that talks to febbox with the given client.
+// A nil client uses http.DefaultClient.
+func NewStreamer(client *http.Client) *Streamer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Streamer{
+		client:  client,
+		baseURL: FebboxBase,
+		cookies: cookiepool.Single(os.Getenv("FEBBOX_COOKIE")),
+	}
+}
 
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			log.Printf("Error creating request: %v", err)
+// WithBaseURL points the streamer at another febbox host, e.g. a test server
+func (s *Streamer) WithBaseURL(baseURL string) *Streamer {
+	s.baseURL = strings.TrimSuffix(baseURL, "/")
+	return s
+}
+
+// WithCookie sets the febbox session cookie, FEBBOX_COOKIE by default
+func (s *Streamer) WithCookie(cookie string) *Streamer {
+	s.cookies = cookiepool.Single(cookie)
+	return s
+}
+
+// WithCookiePool rotates requests over the cookies of several accounts
+func (s *Streamer) WithCookiePool(pool *cookiepool.Pool) *Streamer {
+	s.cookies = pool
+	return s
+}
+
+// Cookies returns the pool of accounts the streamer uses
+func (s *Streamer) Cookies() *cookiepool.Pool {
+	return s.cookies
+}
+
+// UpdateStream re-resolves the links of every file of a movie
+func (s *Streamer) UpdateStream(ctx context.Context, movie *models.Movie) error {
+	for i := range movie.Files {
+		if err := s.UpdateFileStream(ctx, &movie.Files[i]); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		req.Header.Add("Cookie", os.Getenv("FEBBOX_COOKIE"))
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Printf("Error getting qualities: %v", err)
+// UpdateEpisodeStream updates the links for TV episode sources
+func (s *Streamer) UpdateEpisodeStream(ctx context.Context, source *models.Source) error {
+	for i := range source.Files {
+		if err := s.UpdateFileStream(ctx, &source.Files[i]); err != nil {
 			return err
 		}
-		defer resp.Body.Close()
+	}
+	return nil
+}
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("Error reading response body: %v", err)
-			return err
+// UpdateFileStream re-resolves the links of a single file. The existing links
+// are kept if febbox does not hand back any new ones.
+func (s *Streamer) UpdateFileStream(ctx context.Context, file *models.File) error {
+	links, err := s.FetchLinks(ctx, file.FID)
+	if err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		return fmt.Errorf("no links returned for fid %d", file.FID)
+	}
+	file.Links = links
+	return nil
+}
+
+// StampLinks records when links were resolved and when they will expire
+func StampLinks(links []models.Link, fetchedAt time.Time) {
+	for i := range links {
+		links[i].FetchedAt = primitive.NewDateTimeFromTime(fetchedAt)
+		links[i].ExpiresAt = primitive.NewDateTimeFromTime(fetchedAt.Add(LinkTTL))
+	}
+}
+
+// LinksExpireBefore reports whether any of the links is already expired at t.
+// Links stored before expiry tracking existed count as expired.
+func LinksExpireBefore(links []models.Link, t time.Time) bool {
+	for _, link := range links {
+		if link.ExpiresAt == 0 || link.ExpiresAt.Time().Before(t) {
+			return true
 		}
+	}
+	return false
+}
 
-		var input map[string]interface{}
-		if err = json.Unmarshal(body, &input); err != nil {
-			log.Printf("Error unmarshaling response: %v", err)
-			return err
+// FetchLinks resolves the stream links of a file, stamped with their expiry.
+// When febbox answers that the account is logged out or over its quota, the
+// account is taken out of the pool and the next one is tried.
+func (s *Streamer) FetchLinks(ctx context.Context, fid int64) ([]models.Link, error) {
+	for {
+		account, err := s.cookies.Acquire()
+		if err != nil {
+			return nil, err
+		}
+		links, err := s.fetchLinks(ctx, fid, account.Cookie)
+		if account.Name != "" && (errors.Is(err, cookiepool.ErrLoggedOut) || errors.Is(err, cookiepool.ErrQuotaExceeded)) {
+			log.Printf("Febbox account %s: %v, rotating to the next account", account.Name, err)
+			s.cookies.Report(account.Name, err)
+			continue
 		}
+		return links, err
+	}
+}
+
+func (s *Streamer) fetchLinks(ctx context.Context, fid int64, cookie string) ([]models.Link, error) {
+	url := fmt.Sprintf("%s/console/video_quality_list?fid=%s?type=1", s.baseURL, strconv.FormatInt(fid, 10))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		log.Printf("Error creating request: %v", err)
+		return nil, err
+	}
 
-		html, ok := input["html"].(string)
-		if !ok {
+	req.Header.Add("Cookie", cookie)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Printf("Error getting qualities: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, cookiepool.ErrLoggedOut
+	case resp.StatusCode != http.StatusOK:
+		return nil, upstream.NewStatusError("febbox", resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading response body: %v", err)
+		return nil, err
+	}
+
+	var input struct {
+		Msg  string `json:"msg"`
+		HTML string `json:"html"`
+	}
+	if err = json.Unmarshal(body, &input); err != nil {
+		log.Printf("Error unmarshaling response: %v", err)
+		return nil, &upstream.ParseError{Service: "febbox", What: "video quality list", Err: err}
+	}
+
+	data := parseHtmlToJson(input.HTML)
+	if len(data) == 0 {
+		// Without qualities febbox explains itself in msg or in the HTML
+		if err := accountError(input.Msg + " " + input.HTML); err != nil {
+			return nil, err
+		}
+		if input.HTML == "" {
 			log.Println("HTML field not found in response")
-			return fmt.Errorf("HTML field not found in response")
+			return nil, &upstream.ParseError{Service: "febbox", What: "video quality list", Err: errors.New("HTML field not found in response")}
 		}
+	}
 
-		data := parseHtmlToJson(html)
-		var links []models.Link
-		for _, moviee := range data {
-			link := models.Link{
-				Quality: moviee.Quality,
-				URL:     moviee.URL,
-				Size:    moviee.Size,
-			}
-			links = append(links, link)
+	var links []models.Link
+	for _, quality := range data {
+		link := models.Link{
+			Quality: quality.Quality,
+			URL:     quality.URL,
+			Size:    quality.Size,
 		}
-		file.Links = links
+		links = append(links, link)
+	}
+	StampLinks(links, time.Now())
+	return links, nil
+}
+
+var (
+	loggedOutPattern = regexp.MustCompile(`(?i)\b(?:log ?in|sign ?in|not logged|session expired)\b`)
+	quotaPattern     = regexp.MustCompile(`(?i)\b(?:quota|traffic limit|limit exceeded|exceeded the limit|too many downloads)\b`)
+)
+
+// accountError tells from the message of a video quality list without
+// qualities whether the account is logged out or over its quota
+func accountError(text string) error {
+	switch {
+	case quotaPattern.MatchString(text):
+		return cookiepool.ErrQuotaExceeded
+	case loggedOutPattern.MatchString(text):
+		return cookiepool.ErrLoggedOut
 	}
 	return nil
 }
@@ -105,63 +255,3 @@ func parseHtmlToJson(html string) []VideoQuality {
 	})
 	return videos
 }
-
-// UpdateEpisodeStream updates the links for TV episode sources
-func UpdateEpisodeStream(source *models.Source) error {
-	for i := range source.Files {
-		file := &source.Files[i]
-
-		url := fmt.Sprintf("%s/console/video_quality_list?fid=%s?type=1", feboxBase, strconv.FormatInt(file.FID, 10))
-		client := &http.Client{
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return nil
-			},
-		}
-
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			log.Printf("Error creating request: %v", err)
-			return err
-		}
-
-		req.Header.Add("Cookie", os.Getenv("FEBBOX_COOKIE"))
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Printf("Error getting qualities: %v", err)
-			return err
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("Error reading response body: %v", err)
-			return err
-		}
-
-		var input map[string]interface{}
-		if err = json.Unmarshal(body, &input); err != nil {
-			log.Printf("Error unmarshaling response: %v", err)
-			return err
-		}
-
-		html, ok := input["html"].(string)
-		if !ok {
-			log.Println("HTML field not found in response")
-			return fmt.Errorf("HTML field not found in response")
-		}
-
-		data := parseHtmlToJson(html)
-		var links []models.Link
-		for _, quality := range data {
-			link := models.Link{
-				Quality: quality.Quality,
-				URL:     quality.URL,
-				Size:    quality.Size,
-			}
-			links = append(links, link)
-		}
-		file.Links = links
-	}
-
-	return nil
-}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/pkg/cookiepool"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/httpclient"
+)
+
+// newReplayStreamer creates a streamer that serves every request from the
+// responses recorded under testdata/fixtures
+func newReplayStreamer(t *testing.T) *Streamer {
+	t.Helper()
+	client, err := httpclient.New(httpclient.Config{
+		Mode:        httpclient.ModeReplay,
+		FixturesDir: "testdata/fixtures",
+	})
+	if err != nil {
+		t.Fatalf("httpclient.New: %v", err)
+	}
+	return NewStreamer(client).WithCookie("ui=fixture")
+}
+
+func TestFetchLinksFromFixture(t *testing.T) {
+	before := time.Now()
+	links, err := newReplayStreamer(t).FetchLinks(context.Background(), 501)
+	if err != nil {
+		t.Fatalf("FetchLinks: %v", err)
+	}
+
+	want := []struct{ quality, url, size string }{
+		{"ORG", "https://cdn.febbox.com/stream/501/org.mp4", "3.4 GB"},
+		{"1080P", "https://cdn.febbox.com/stream/501/1080p.mp4", "2.2 GB"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("got %d links, want %d", len(links), len(want))
+	}
+	for i, link := range links {
+		if link.Quality != want[i].quality || link.URL != want[i].url || link.Size != want[i].size {
+			t.Errorf("link %d: got %+v, want %+v", i, link, want[i])
+		}
+		if expires := link.ExpiresAt.Time(); expires.Before(before.Add(LinkTTL - time.Second)) {
+			t.Errorf("link %d expires at %s, want about %s from now", i, expires, LinkTTL)
+		}
+	}
+}
+
+func TestFetchLinksAccountErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fid  int64
+		want cookiepool.State
+	}{
+		{"logged out", 502, cookiepool.StateLoggedOut},
+		{"over quota", 503, cookiepool.StateQuotaExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamer := newReplayStreamer(t)
+			_, err := streamer.FetchLinks(context.Background(), tt.fid)
+
+			// The only account is taken out of the rotation, which leaves
+			// none to retry with
+			if !errors.Is(err, cookiepool.ErrExhausted) {
+				t.Fatalf("got error %v, want %v", err, cookiepool.ErrExhausted)
+			}
+			usage := streamer.Cookies().Usage()
+			if len(usage) != 1 || usage[0].State != tt.want || usage[0].Failures != 1 {
+				t.Fatalf("got account usage %+v, want one failure leaving it %s", usage, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,494 @@
+// Package config loads the settings shared by every showbox command from a
+// YAML file, with environment variables taking precedence over the file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/utils"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/proxypool"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// languagePattern matches the language codes TMDB accepts: ISO 639-1,
+// optionally with an ISO 3166-1 region
+var languagePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// DefaultPath is the config file used when neither a path nor SHOWBOX_CONFIG is given
+const DefaultPath = "config.yaml"
+
+// Config is the full configuration of every showbox command
+type Config struct {
+	Mongo     MongoConfig     `yaml:"mongo"`
+	Server    ServerConfig    `yaml:"server"`
+	Showbox   ShowboxConfig   `yaml:"showbox"`
+	Febbox    FebboxConfig    `yaml:"febbox"`
+	TMDB      TMDBConfig      `yaml:"tmdb"`
+	Refresher RefresherConfig `yaml:"refresher"`
+	HTTP      HTTPConfig      `yaml:"http"`
+	Proxies   ProxiesConfig   `yaml:"proxies"`
+}
+
+type MongoConfig struct {
+	URI      string `yaml:"uri"`
+	Database string `yaml:"database"`
+}
+
+type ServerConfig struct {
+	Addr string `yaml:"addr"`
+}
+
+// ShowboxConfig configures the showbox.media catalog crawler
+type ShowboxConfig struct {
+	BaseURL       string   `yaml:"base_url"`
+	DirectBaseURL string   `yaml:"direct_base_url"`
+	ProxyURL      string   `yaml:"proxy_url"` // prefix proxy, added to proxies.servers
+	StartPage     int      `yaml:"start_page"`
+	EndPage       int      `yaml:"end_page"`
+	Parallelism   int      `yaml:"parallelism"`
+	RandomDelay   Duration `yaml:"random_delay"`
+	Timeout       Duration `yaml:"timeout"`
+	UserAgent     string   `yaml:"user_agent"`
+}
+
+// FebboxConfig configures the febbox file scraper
+type FebboxConfig struct {
+	ShowboxBase     string   `yaml:"showbox_base"`
+	FebboxBase      string   `yaml:"febbox_base"`
+	ProxyURL        string   `yaml:"proxy_url"`  // prefix proxy, added to proxies.servers
+	HTTPProxy       string   `yaml:"http_proxy"` // HTTP or SOCKS5 proxy, added to proxies.servers
+	Cookie          string   `yaml:"cookie"`
+	MaxConcurrency  int      `yaml:"max_concurrency"`
+	RequestInterval Duration `yaml:"request_interval"` // replaced by rate_limit, rejected when set
+	MaxRetries      int      `yaml:"max_retries"`
+	RetryDelay      Duration `yaml:"retry_delay"`
+	Timeout         Duration `yaml:"timeout"`
+	DrainTimeout    Duration `yaml:"drain_timeout"`
+
+	// Accounts are rotated when febbox reports one logged out or over its
+	// quota, which leaves it out for QuotaCooldown. Cookie, if set, is the
+	// first of them.
+	Accounts      []FebboxAccount `yaml:"accounts"`
+	QuotaCooldown Duration        `yaml:"quota_cooldown"`
+
+	// RateLimit paces the requests to each host
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+}
+
+// RateLimitConfig configures the adaptive per-host rate limiter. Every host
+// starts at Initial requests per second; while responses are healthy the
+// rate climbs by Increase per second up to Max, and every burst of 429 or
+// 5xx responses halves it down to Min.
+type RateLimitConfig struct {
+	Initial  float64 `yaml:"initial"`
+	Min      float64 `yaml:"min"`
+	Max      float64 `yaml:"max"`
+	Increase float64 `yaml:"increase"`
+}
+
+// FebboxAccount is the session cookie of one febbox account. Name identifies
+// it in logs and usage reports.
+type FebboxAccount struct {
+	Name   string `yaml:"name"`
+	Cookie string `yaml:"cookie"`
+}
+
+// TMDBConfig configures the TMDB client. RequestsPerSecond and Burst size the
+// token bucket shared by all requests; 429 and 5xx responses are retried up
+// to MaxRetries times. Languages lists the languages besides English whose
+// metadata is synced.
+type TMDBConfig struct {
+	APIKey            string          `yaml:"api_key"`
+	BaseURL           string          `yaml:"base_url"`
+	Timeout           Duration        `yaml:"timeout"`
+	RequestsPerSecond float64         `yaml:"requests_per_second"`
+	Burst             int             `yaml:"burst"`
+	MaxRetries        int             `yaml:"max_retries"`
+	Languages         []string        `yaml:"languages"`
+	Cache             TMDBCacheConfig `yaml:"cache"`
+}
+
+// TMDBCacheConfig configures the on-disk cache of TMDB responses. An empty
+// Dir disables it, Refresh ignores cached responses but stores fresh ones,
+// and a TTL of 0 disables caching for that resource type.
+type TMDBCacheConfig struct {
+	Dir        string   `yaml:"dir"`
+	Refresh    bool     `yaml:"refresh"`
+	FindTTL    Duration `yaml:"find_ttl"`
+	SearchTTL  Duration `yaml:"search_ttl"`
+	MovieTTL   Duration `yaml:"movie_ttl"`
+	TVTTL      Duration `yaml:"tv_ttl"`
+	SeasonTTL  Duration `yaml:"season_ttl"`
+	EpisodeTTL Duration `yaml:"episode_ttl"`
+}
+
+// RefresherConfig configures the background stream link refresher of the
+// API server. An Interval of 0 disables it.
+type RefresherConfig struct {
+	Interval  Duration `yaml:"interval"`
+	Lead      Duration `yaml:"lead"`
+	BatchSize int64    `yaml:"batch_size"`
+}
+
+// HTTPConfig selects live, record or replay mode for outbound requests
+type HTTPConfig struct {
+	Mode        string `yaml:"mode"`
+	FixturesDir string `yaml:"fixtures_dir"`
+}
+
+// ProxiesConfig configures the proxies requests to showbox and febbox go
+// through. Servers are http, https or socks5 proxies, or prefix proxies the
+// target URL is appended to. A proxy failing QuarantineAfter times in a row
+// with 429, 5xx or network errors is left out for QuarantineFor.
+type ProxiesConfig struct {
+	Selection       string        `yaml:"selection"` // round_robin or least_errors
+	QuarantineAfter int           `yaml:"quarantine_after"`
+	QuarantineFor   Duration      `yaml:"quarantine_for"`
+	Servers         []ProxyServer `yaml:"servers"`
+}
+
+// ProxyServer is one proxy of the pool
+type ProxyServer struct {
+	URL    string `yaml:"url"`
+	Prefix bool   `yaml:"prefix"`
+}
+
+// Duration is a time.Duration written as "10m" or "90s" in the config file
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	parsed, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q", value.Line, value.Value)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// Std returns the duration as a time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// Default returns the configuration used when a setting is in neither the
+// file nor the environment
+func Default() *Config {
+	return &Config{
+		Mongo: MongoConfig{
+			Database: "showbox",
+		},
+		Server: ServerConfig{
+			Addr: ":8080",
+		},
+		Showbox: ShowboxConfig{
+			BaseURL:     "https://www.showbox.media",
+			StartPage:   1,
+			EndPage:     258,
+			Parallelism: 2,
+			RandomDelay: Duration(3 * time.Second),
+			Timeout:     Duration(120 * time.Second),
+			UserAgent:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
+		},
+		Febbox: FebboxConfig{
+			ShowboxBase:    "https://showbox.media",
+			FebboxBase:     "https://www.febbox.com",
+			MaxConcurrency: 5,
+			MaxRetries:     3,
+			RetryDelay:     Duration(2 * time.Second),
+			Timeout:        Duration(120 * time.Second),
+			DrainTimeout:   Duration(5 * time.Minute),
+			QuotaCooldown:  Duration(time.Hour),
+			RateLimit: RateLimitConfig{
+				Initial:  2,
+				Min:      0.2,
+				Max:      10,
+				Increase: 0.2,
+			},
+		},
+		TMDB: TMDBConfig{
+			BaseURL:           "https://api.themoviedb.org/3",
+			Timeout:           Duration(10 * time.Second),
+			RequestsPerSecond: 40,
+			Burst:             20,
+			MaxRetries:        5,
+			Cache: TMDBCacheConfig{
+				Dir:        "cache/tmdb",
+				FindTTL:    Duration(30 * 24 * time.Hour),
+				SearchTTL:  Duration(7 * 24 * time.Hour),
+				MovieTTL:   Duration(7 * 24 * time.Hour),
+				TVTTL:      Duration(24 * time.Hour),
+				SeasonTTL:  Duration(24 * time.Hour),
+				EpisodeTTL: Duration(7 * 24 * time.Hour),
+			},
+		},
+		Refresher: RefresherConfig{
+			Interval:  Duration(10 * time.Minute),
+			Lead:      Duration(30 * time.Minute),
+			BatchSize: 50,
+		},
+		HTTP: HTTPConfig{
+			Mode:        "live",
+			FixturesDir: "testdata/fixtures",
+		},
+		Proxies: ProxiesConfig{
+			Selection:       "round_robin",
+			QuarantineAfter: 3,
+			QuarantineFor:   Duration(5 * time.Minute),
+		},
+	}
+}
+
+// Load reads the config file at path, or SHOWBOX_CONFIG, or config.yaml if
+// it exists, then applies environment overrides (including a .env file) and
+// validates the result. Running without any config file is fine as long as
+// the environment provides the required settings.
+func Load(path string) (*Config, error) {
+	// Load .env but don't fail if it doesn't exist
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Warning: failed to load .env file: %v", err)
+	}
+
+	cfg := Default()
+
+	explicit := true
+	if path == "" {
+		path = os.Getenv("SHOWBOX_CONFIG")
+	}
+	if path == "" {
+		path, explicit = DefaultPath, false
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// No config file, rely on defaults and the environment
+	default:
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides file settings with the environment variables the
+// binaries have always read
+func (c *Config) applyEnv() error {
+	setString(&c.Mongo.URI, "MONGO_URI")
+	setString(&c.Mongo.Database, "DB_NAME")
+	setString(&c.Server.Addr, "SERVER_ADDR")
+	if port := os.Getenv("PORT"); port != "" {
+		c.Server.Addr = ":" + port
+	}
+	setString(&c.Febbox.Cookie, "FEBBOX_COOKIE")
+	setString(&c.Febbox.HTTPProxy, "PROXY_URL")
+	setString(&c.TMDB.APIKey, "TMDB_API_KEY")
+	setString(&c.TMDB.BaseURL, "TMDB_BASE_URL")
+	setString(&c.TMDB.Cache.Dir, "TMDB_CACHE_DIR")
+	setList(&c.TMDB.Languages, "TMDB_LANGUAGES")
+	setString(&c.HTTP.Mode, "HTTP_MODE")
+	setString(&c.HTTP.FixturesDir, "HTTP_FIXTURES_DIR")
+
+	if err := setDuration(&c.Refresher.Interval, "LINK_REFRESH_INTERVAL"); err != nil {
+		return err
+	}
+	if err := setInt(&c.Febbox.MaxConcurrency, "FEBBOX_MAX_CONCURRENCY"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Validate checks the settings every binary depends on. Settings only some
+// commands need, like MongoDB and the TMDB API key, are checked by those
+// commands.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr must not be empty"))
+	}
+	if c.Febbox.MaxConcurrency < 1 {
+		errs = append(errs, errors.New("febbox.max_concurrency must be at least 1"))
+	}
+	if c.Febbox.MaxRetries < 1 {
+		errs = append(errs, errors.New("febbox.max_retries must be at least 1"))
+	}
+	if c.Febbox.RequestInterval != 0 {
+		errs = append(errs, errors.New("febbox.request_interval is no longer supported, remove it and pace requests with febbox.rate_limit instead"))
+	}
+	rate := c.Febbox.RateLimit
+	if rate.Min <= 0 || rate.Max < rate.Min || rate.Initial < rate.Min || rate.Initial > rate.Max {
+		errs = append(errs, errors.New("febbox.rate_limit needs 0 < min <= initial <= max"))
+	}
+	if rate.Increase <= 0 {
+		errs = append(errs, errors.New("febbox.rate_limit.increase must be positive"))
+	}
+	accountNames := make(map[string]bool)
+	for i, account := range c.Febbox.Accounts {
+		if account.Cookie == "" {
+			errs = append(errs, fmt.Errorf("febbox.accounts[%d] has no cookie", i))
+		}
+		if account.Name != "" && accountNames[account.Name] {
+			errs = append(errs, fmt.Errorf("febbox.accounts: name %q is used twice", account.Name))
+		}
+		accountNames[account.Name] = true
+	}
+	if c.Febbox.QuotaCooldown < 0 {
+		errs = append(errs, errors.New("febbox.quota_cooldown must not be negative"))
+	}
+	if c.Showbox.Parallelism < 1 {
+		errs = append(errs, errors.New("showbox.parallelism must be at least 1"))
+	}
+	if c.Showbox.StartPage < 1 || c.Showbox.EndPage < c.Showbox.StartPage {
+		errs = append(errs, fmt.Errorf("showbox page range %d-%d is invalid", c.Showbox.StartPage, c.Showbox.EndPage))
+	}
+	if c.TMDB.RequestsPerSecond <= 0 || c.TMDB.Burst < 1 {
+		errs = append(errs, errors.New("tmdb.requests_per_second must be positive and tmdb.burst at least 1"))
+	}
+	if c.TMDB.MaxRetries < 0 {
+		errs = append(errs, errors.New("tmdb.max_retries must not be negative"))
+	}
+	for _, language := range c.TMDB.Languages {
+		if !languagePattern.MatchString(language) {
+			errs = append(errs, fmt.Errorf("tmdb.languages: %q is not a language code like de or pt-BR", language))
+		}
+	}
+	cache := c.TMDB.Cache
+	if cache.FindTTL < 0 || cache.SearchTTL < 0 || cache.MovieTTL < 0 || cache.TVTTL < 0 || cache.SeasonTTL < 0 || cache.EpisodeTTL < 0 {
+		errs = append(errs, errors.New("tmdb.cache TTLs must not be negative"))
+	}
+	if c.Refresher.Interval < 0 || c.Refresher.Lead < 0 {
+		errs = append(errs, errors.New("refresher.interval and refresher.lead must not be negative"))
+	}
+	if c.Refresher.Lead.Std() >= utils.LinkTTL {
+		errs = append(errs, fmt.Errorf("refresher.lead must be shorter than the %s link lifetime", utils.LinkTTL))
+	}
+	if c.Refresher.Interval > 0 && c.Refresher.BatchSize < 1 {
+		errs = append(errs, errors.New("refresher.batch_size must be at least 1"))
+	}
+	switch c.HTTP.Mode {
+	case "live", "record", "replay":
+	default:
+		errs = append(errs, fmt.Errorf("http.mode %q must be live, record or replay", c.HTTP.Mode))
+	}
+	switch proxypool.Selection(c.Proxies.Selection) {
+	case proxypool.RoundRobin, proxypool.LeastErrors:
+	default:
+		errs = append(errs, fmt.Errorf("proxies.selection %q must be round_robin or least_errors", c.Proxies.Selection))
+	}
+	if c.Proxies.QuarantineAfter < 1 {
+		errs = append(errs, errors.New("proxies.quarantine_after must be at least 1"))
+	}
+	if c.Proxies.QuarantineFor < 0 {
+		errs = append(errs, errors.New("proxies.quarantine_for must not be negative"))
+	}
+	for i, server := range c.Proxies.Servers {
+		if err := (proxypool.Proxy{URL: server.URL, Prefix: server.Prefix}).Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("proxies.servers[%d]: %w", i, err))
+		}
+	}
+	for _, proxy := range proxypool.FromURLs(c.Showbox.ProxyURL, "") {
+		if err := proxy.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("showbox.proxy_url: %w", err))
+		}
+	}
+	for _, proxy := range proxypool.FromURLs(c.Febbox.ProxyURL, c.Febbox.HTTPProxy) {
+		if err := proxy.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("febbox proxy: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// RequireMongo checks the settings needed to connect to MongoDB
+func (c *Config) RequireMongo() error {
+	var errs []error
+	if c.Mongo.URI == "" {
+		errs = append(errs, errors.New("mongo.uri (MONGO_URI) is required"))
+	}
+	if c.Mongo.Database == "" {
+		errs = append(errs, errors.New("mongo.database (DB_NAME) is required"))
+	}
+	return errors.Join(errs...)
+}
+
+// RequireTMDB checks the settings needed to talk to TMDB
+func (c *Config) RequireTMDB() error {
+	if c.TMDB.APIKey == "" {
+		return errors.New("tmdb.api_key (TMDB_API_KEY) is required")
+	}
+	return nil
+}
+
+func setString(target *string, name string) {
+	if value := os.Getenv(name); value != "" {
+		*target = value
+	}
+}
+
+// setList reads a comma separated list
+func setList(target *[]string, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*target = list
+}
+
+func setDuration(target *Duration, name string) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = Duration(d)
+	return nil
+}
+
+func setInt(target *int, name string) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = n
+	return nil
+}
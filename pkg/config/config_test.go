@@ -0,0 +1,22 @@
+package config
+
+import "testing"
+
+func TestLoadWithoutMongo(t *testing.T) {
+	t.Setenv("SHOWBOX_CONFIG", "")
+	t.Setenv("MONGO_URI", "")
+
+	// Commands that never open the database load without it
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := cfg.RequireMongo(); err == nil {
+		t.Error("RequireMongo accepted an empty mongo.uri")
+	}
+
+	cfg.Mongo.URI = "mongodb://localhost:27017"
+	if err := cfg.RequireMongo(); err != nil {
+		t.Errorf("RequireMongo: %v", err)
+	}
+}
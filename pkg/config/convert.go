@@ -0,0 +1,170 @@
+package config
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/pkg/cookiepool"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/hostlimit"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/httpclient"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/proxypool"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/refresher"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/tmdb"
+	"github.com/amankumarsingh77/go-showbox-api/scraper/febox"
+	"github.com/amankumarsingh77/go-showbox-api/scraper/showbox"
+)
+
+// HTTPClientConfig returns the outbound HTTP settings with the given timeout
+func (c *Config) HTTPClientConfig(timeout time.Duration) httpclient.Config {
+	return httpclient.Config{
+		Mode:        httpclient.Mode(c.HTTP.Mode),
+		FixturesDir: c.HTTP.FixturesDir,
+		Timeout:     timeout,
+	}
+}
+
+// HTTPClient creates an outbound HTTP client with the given timeout
+func (c *Config) HTTPClient(timeout time.Duration) (*http.Client, error) {
+	return httpclient.New(c.HTTPClientConfig(timeout))
+}
+
+// FeboxConfig returns the febbox scraper settings
+func (c *Config) FeboxConfig() *febox.Config {
+	return &febox.Config{
+		MaxConcurrency: c.Febbox.MaxConcurrency,
+		MaxRetries:     c.Febbox.MaxRetries,
+		RetryDelay:     c.Febbox.RetryDelay.Std(),
+		HTTPTimeout:    seconds(c.Febbox.Timeout),
+		DrainTimeout:   seconds(c.Febbox.DrainTimeout),
+		ShowboxBase:    c.Febbox.ShowboxBase,
+		FebboxBase:     c.Febbox.FebboxBase,
+		Proxies:        c.FebboxProxies(),
+		Cookie:         c.Febbox.Cookie,
+		Cookies:        c.CookiePool(),
+		Limiter:        c.FebboxLimiter(),
+		HTTP:           c.HTTPClientConfig(0),
+	}
+}
+
+// CookiePool creates the pool of febbox accounts: febbox.cookie
+// (FEBBOX_COOKIE) if set, followed by febbox.accounts
+func (c *Config) CookiePool() *cookiepool.Pool {
+	var accounts []cookiepool.Account
+	if c.Febbox.Cookie != "" {
+		accounts = append(accounts, cookiepool.Account{Name: "default", Cookie: c.Febbox.Cookie})
+	}
+	for _, account := range c.Febbox.Accounts {
+		accounts = append(accounts, cookiepool.Account{Name: account.Name, Cookie: account.Cookie})
+	}
+	return cookiepool.New(accounts, c.Febbox.QuotaCooldown.Std())
+}
+
+// FebboxLimiter creates the per-host rate limiter of febbox.rate_limit
+func (c *Config) FebboxLimiter() *hostlimit.Limiter {
+	return hostlimit.New(hostlimit.Options{
+		Initial:  c.Febbox.RateLimit.Initial,
+		Min:      c.Febbox.RateLimit.Min,
+		Max:      c.Febbox.RateLimit.Max,
+		Increase: c.Febbox.RateLimit.Increase,
+	})
+}
+
+// FebboxProxies creates the pool of proxies for requests to febbox and the
+// showbox share link API: proxies.servers plus febbox.proxy_url and
+// febbox.http_proxy (PROXY_URL)
+func (c *Config) FebboxProxies() *proxypool.Pool {
+	return c.proxyPool(proxypool.FromURLs(c.Febbox.ProxyURL, c.Febbox.HTTPProxy))
+}
+
+// ShowboxProxies creates the pool of proxies for the catalog crawler:
+// proxies.servers plus showbox.proxy_url
+func (c *Config) ShowboxProxies() *proxypool.Pool {
+	return c.proxyPool(proxypool.FromURLs(c.Showbox.ProxyURL, ""))
+}
+
+func (c *Config) proxyPool(extra []proxypool.Proxy) *proxypool.Pool {
+	var proxies []proxypool.Proxy
+	for _, server := range c.Proxies.Servers {
+		proxies = append(proxies, proxypool.Proxy{URL: server.URL, Prefix: server.Prefix})
+	}
+	return proxypool.New(append(proxies, extra...), proxypool.Options{
+		Selection:       proxypool.Selection(c.Proxies.Selection),
+		QuarantineAfter: c.Proxies.QuarantineAfter,
+		QuarantineFor:   c.Proxies.QuarantineFor.Std(),
+	})
+}
+
+// ShowboxConfig returns the showbox catalog crawler settings
+func (c *Config) ShowboxConfig() *showbox.Config {
+	return &showbox.Config{
+		DirectBaseURL: c.Showbox.DirectBaseURL,
+		BaseURL:       c.Showbox.BaseURL,
+		Proxies:       c.ShowboxProxies(),
+		StartPage:     c.Showbox.StartPage,
+		EndPage:       c.Showbox.EndPage,
+		UserAgent:     c.Showbox.UserAgent,
+		Timeout:       seconds(c.Showbox.Timeout),
+		Parallelism:   c.Showbox.Parallelism,
+		RandomDelay:   seconds(c.Showbox.RandomDelay),
+		StreamProxy:   c.Showbox.ProxyURL,
+		HTTP:          c.HTTPClientConfig(0),
+	}
+}
+
+// RefresherConfig returns the link refresher settings
+func (c *Config) RefresherConfig() refresher.Config {
+	return refresher.Config{
+		Interval:  c.Refresher.Interval.Std(),
+		Lead:      c.Refresher.Lead.Std(),
+		BatchSize: c.Refresher.BatchSize,
+	}
+}
+
+// TMDBClient creates a TMDB client, failing if no API key is configured. On
+// a dry run the response cache is only read, never written.
+func (c *Config) TMDBClient(dryRun bool) (*tmdb.Client, error) {
+	if err := c.RequireTMDB(); err != nil {
+		return nil, err
+	}
+	httpClient, err := c.HTTPClient(c.TMDB.Timeout.Std())
+	if err != nil {
+		return nil, err
+	}
+	client := tmdb.NewClientWith(c.TMDB.APIKey, c.TMDB.BaseURL, httpClient).
+		WithLimiter(tmdb.NewLimiter(c.TMDB.RequestsPerSecond, c.TMDB.Burst)).
+		WithRetries(c.TMDB.MaxRetries, tmdb.DefaultBackoff).
+		WithLanguages(c.TMDB.Languages)
+
+	switch {
+	case c.TMDB.Cache.Dir == "":
+	case dryRun:
+		// A refreshing cache serves nothing, and a dry run mustn't store
+		// anything, which leaves nothing to use it for
+		if !c.TMDB.Cache.Refresh {
+			client.WithCache(tmdb.NewReadOnlyCache(c.TMDB.Cache.Dir, c.TMDBCacheTTLs()))
+		}
+	default:
+		cache, err := tmdb.NewCache(c.TMDB.Cache.Dir, c.TMDBCacheTTLs(), c.TMDB.Cache.Refresh)
+		if err != nil {
+			return nil, err
+		}
+		client.WithCache(cache)
+	}
+	return client, nil
+}
+
+// TMDBCacheTTLs returns how long cached TMDB responses stay fresh
+func (c *Config) TMDBCacheTTLs() tmdb.CacheTTLs {
+	return tmdb.CacheTTLs{
+		Find:    c.TMDB.Cache.FindTTL.Std(),
+		Search:  c.TMDB.Cache.SearchTTL.Std(),
+		Movie:   c.TMDB.Cache.MovieTTL.Std(),
+		TV:      c.TMDB.Cache.TVTTL.Std(),
+		Season:  c.TMDB.Cache.SeasonTTL.Std(),
+		Episode: c.TMDB.Cache.EpisodeTTL.Std(),
+	}
+}
+
+func seconds(d Duration) int {
+	return int(d.Std() / time.Second)
+}
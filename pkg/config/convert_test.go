@@ -0,0 +1,15 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFeboxConfigKeepsSubSecondRetryDelay(t *testing.T) {
+	cfg := Default()
+	cfg.Febbox.RetryDelay = Duration(500 * time.Millisecond)
+
+	if got := cfg.FeboxConfig().RetryDelay; got != 500*time.Millisecond {
+		t.Errorf("got retry delay %s, want 500ms", got)
+	}
+}
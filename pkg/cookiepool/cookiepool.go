@@ -0,0 +1,204 @@
+// Package cookiepool hands out febbox session cookies from a pool of
+// accounts. Accounts febbox reports as logged out leave the rotation for
+// good, accounts over their quota for a cooldown, so one expired session no
+// longer means every file is stored without links.
+package cookiepool
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
+)
+
+// DefaultQuotaCooldown is how long an account over its quota is left out
+const DefaultQuotaCooldown = time.Hour
+
+var (
+	// ErrLoggedOut is reported for responses of an expired session
+	ErrLoggedOut = fmt.Errorf("febbox session logged out: %w", upstream.ErrAuth)
+	// ErrQuotaExceeded is reported for responses of an account over its quota
+	ErrQuotaExceeded = fmt.Errorf("febbox account quota exceeded: %w", upstream.ErrRateLimited)
+	// ErrExhausted is returned when no account is usable right now
+	ErrExhausted = fmt.Errorf("no usable febbox account: %w", upstream.ErrAuth)
+)
+
+// Account is a febbox account's session cookie. Name identifies it in logs
+// and usage reports, so the cookie itself never has to be printed.
+type Account struct {
+	Name   string
+	Cookie string
+}
+
+// State is the health of an account
+type State string
+
+const (
+	StateHealthy       State = "healthy"
+	StateLoggedOut     State = "logged_out"
+	StateQuotaExceeded State = "quota_exceeded"
+)
+
+// Usage is the report of one account
+type Usage struct {
+	Name     string
+	State    State
+	Requests int64     // requests made with the account's cookie
+	Failures int64     // of those, the ones that found it logged out or over quota
+	Until    time.Time // end of the quota cooldown
+}
+
+type account struct {
+	Account
+	state    State
+	until    time.Time
+	requests int64
+	failures int64
+}
+
+// usable reports whether the account can be handed out, ending its quota
+// cooldown once that has passed
+func (a *account) usable(now time.Time) bool {
+	if a.state == StateQuotaExceeded && !now.Before(a.until) {
+		a.state, a.until = StateHealthy, time.Time{}
+	}
+	return a.state == StateHealthy
+}
+
+// Pool rotates requests over the accounts round robin, skipping the ones
+// that are logged out or cooling down. It is safe for concurrent use.
+type Pool struct {
+	mu            sync.Mutex
+	accounts      []*account
+	next          int
+	quotaCooldown time.Duration
+	now           func() time.Time
+}
+
+// New creates a pool of the accounts with a cookie. A quotaCooldown of 0
+// uses DefaultQuotaCooldown.
+func New(accounts []Account, quotaCooldown time.Duration) *Pool {
+	if quotaCooldown <= 0 {
+		quotaCooldown = DefaultQuotaCooldown
+	}
+	p := &Pool{quotaCooldown: quotaCooldown, now: time.Now}
+	for i, acc := range accounts {
+		if acc.Cookie == "" {
+			continue
+		}
+		if acc.Name == "" {
+			acc.Name = fmt.Sprintf("account %d", i+1)
+		}
+		p.accounts = append(p.accounts, &account{Account: acc, state: StateHealthy})
+	}
+	return p
+}
+
+// Single creates a pool of one cookie, as used before pools existed
+func Single(cookie string) *Pool {
+	return New([]Account{{Name: "default", Cookie: cookie}}, 0)
+}
+
+// Acquire returns the next usable account and counts a request against it.
+// An empty pool hands out an account without a cookie, so requests still go
+// out anonymously like they did before.
+func (p *Pool) Acquire() (Account, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.accounts) == 0 {
+		return Account{}, nil
+	}
+	now := p.now()
+	for i := 0; i < len(p.accounts); i++ {
+		acc := p.accounts[(p.next+i)%len(p.accounts)]
+		if !acc.usable(now) {
+			continue
+		}
+		p.next = (p.next + i + 1) % len(p.accounts)
+		acc.requests++
+		return acc.Account, nil
+	}
+	return Account{}, ErrExhausted
+}
+
+// Report takes an account out of the rotation after a response showed it
+// logged out (ErrLoggedOut) or over its quota (ErrQuotaExceeded). Other
+// errors are ignored.
+func (p *Pool) Report(name string, err error) {
+	var state State
+	switch {
+	case errors.Is(err, ErrLoggedOut):
+		state = StateLoggedOut
+	case errors.Is(err, ErrQuotaExceeded):
+		state = StateQuotaExceeded
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, acc := range p.accounts {
+		if acc.Name != name {
+			continue
+		}
+		acc.failures++
+		acc.state = state
+		if state == StateQuotaExceeded {
+			acc.until = p.now().Add(p.quotaCooldown)
+		}
+	}
+}
+
+// Available returns ErrExhausted when every account is logged out or
+// cooling down. An empty pool is always available.
+func (p *Pool) Available() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.accounts) == 0 {
+		return nil
+	}
+	now := p.now()
+	for _, acc := range p.accounts {
+		if acc.usable(now) {
+			return nil
+		}
+	}
+	return ErrExhausted
+}
+
+// Size returns the number of accounts in the pool
+func (p *Pool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.accounts)
+}
+
+// Usage reports every account, in the order they were configured
+func (p *Pool) Usage() []Usage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	usage := make([]Usage, 0, len(p.accounts))
+	for _, acc := range p.accounts {
+		acc.usable(now)
+		usage = append(usage, Usage{
+			Name:     acc.Name,
+			State:    acc.state,
+			Requests: acc.requests,
+			Failures: acc.failures,
+			Until:    acc.until,
+		})
+	}
+	return usage
+}
+
+func (u Usage) String() string {
+	str := fmt.Sprintf("%s: %s, %d requests, %d failures", u.Name, u.State, u.Requests, u.Failures)
+	if !u.Until.IsZero() {
+		str += fmt.Sprintf(", back at %s", u.Until.Format(time.TimeOnly))
+	}
+	return str
+}
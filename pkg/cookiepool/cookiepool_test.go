@@ -0,0 +1,153 @@
+package cookiepool
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
+)
+
+// newTestPool creates a pool of accounts a, b and c with a clock the test
+// moves by hand
+func newTestPool() (*Pool, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pool := New([]Account{
+		{Name: "a", Cookie: "ui=a"},
+		{Name: "b", Cookie: "ui=b"},
+		{Name: "c", Cookie: "ui=c"},
+	}, time.Hour)
+	pool.now = func() time.Time { return now }
+	return pool, &now
+}
+
+// acquireNames acquires n accounts and returns their names
+func acquireNames(t *testing.T, pool *Pool, n int) []string {
+	t.Helper()
+	var names []string
+	for i := 0; i < n; i++ {
+		acc, err := pool.Acquire()
+		if err != nil {
+			t.Fatalf("Acquire %d: %v", i+1, err)
+		}
+		names = append(names, acc.Name)
+	}
+	return names
+}
+
+func TestPoolRotatesRoundRobin(t *testing.T) {
+	pool, _ := newTestPool()
+	if got := fmt.Sprint(acquireNames(t, pool, 5)); got != "[a b c a b]" {
+		t.Errorf("got %s, want [a b c a b]", got)
+	}
+}
+
+func TestPoolSkipsLoggedOutAccounts(t *testing.T) {
+	pool, now := newTestPool()
+	pool.Report("b", fmt.Errorf("video_quality_list: %w", ErrLoggedOut))
+
+	if got := fmt.Sprint(acquireNames(t, pool, 4)); got != "[a c a c]" {
+		t.Errorf("got %s, want [a c a c]", got)
+	}
+	// Logged out accounts never come back by themselves
+	*now = now.Add(24 * time.Hour)
+	if got := fmt.Sprint(acquireNames(t, pool, 2)); got != "[a c]" {
+		t.Errorf("got %s a day later, want [a c]", got)
+	}
+}
+
+func TestPoolQuotaCooldown(t *testing.T) {
+	pool, now := newTestPool()
+	pool.Report("a", ErrQuotaExceeded)
+
+	if got := fmt.Sprint(acquireNames(t, pool, 2)); got != "[b c]" {
+		t.Errorf("got %s during the cooldown, want [b c]", got)
+	}
+	*now = now.Add(time.Hour)
+	if got := fmt.Sprint(acquireNames(t, pool, 3)); got != "[a b c]" {
+		t.Errorf("got %s after the cooldown, want [a b c]", got)
+	}
+}
+
+func TestPoolExhausted(t *testing.T) {
+	pool, now := newTestPool()
+	pool.Report("a", ErrLoggedOut)
+	pool.Report("b", ErrQuotaExceeded)
+	pool.Report("c", ErrQuotaExceeded)
+
+	if _, err := pool.Acquire(); !errors.Is(err, ErrExhausted) {
+		t.Errorf("Acquire returned %v, want %v", err, ErrExhausted)
+	}
+	if err := pool.Available(); !errors.Is(err, ErrExhausted) || !errors.Is(err, upstream.ErrAuth) {
+		t.Errorf("Available returned %v, want %v", err, ErrExhausted)
+	}
+
+	*now = now.Add(time.Hour)
+	if err := pool.Available(); err != nil {
+		t.Errorf("Available after the cooldown returned %v", err)
+	}
+}
+
+func TestPoolReportIgnoresOtherErrors(t *testing.T) {
+	pool, _ := newTestPool()
+	pool.Report("a", &upstream.StatusError{StatusCode: 500})
+	pool.Report("a", errors.New("boom"))
+	pool.Report("unknown", ErrLoggedOut)
+
+	for _, usage := range pool.Usage() {
+		if usage.State != StateHealthy || usage.Failures != 0 {
+			t.Errorf("unexpected usage %+v", usage)
+		}
+	}
+}
+
+func TestPoolUsage(t *testing.T) {
+	pool, now := newTestPool()
+	acquireNames(t, pool, 4)
+	pool.Report("a", ErrQuotaExceeded)
+	pool.Report("b", ErrLoggedOut)
+
+	want := []Usage{
+		{Name: "a", State: StateQuotaExceeded, Requests: 2, Failures: 1, Until: now.Add(time.Hour)},
+		{Name: "b", State: StateLoggedOut, Requests: 1, Failures: 1},
+		{Name: "c", State: StateHealthy, Requests: 1},
+	}
+	got := pool.Usage()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got usage %v, want %v", got, want)
+	}
+}
+
+func TestPoolWithoutAccounts(t *testing.T) {
+	tests := []struct {
+		name string
+		pool *Pool
+	}{
+		{"empty", New(nil, 0)},
+		{"blank cookies only", New([]Account{{Name: "a"}}, 0)},
+		{"single without cookie", Single("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if size := tt.pool.Size(); size != 0 {
+				t.Errorf("got size %d, want 0", size)
+			}
+			// Requests go out anonymously, as before pools existed
+			acc, err := tt.pool.Acquire()
+			if err != nil || acc.Cookie != "" {
+				t.Errorf("Acquire = %+v, %v, want an account without cookie", acc, err)
+			}
+			if err := tt.pool.Available(); err != nil {
+				t.Errorf("Available returned %v", err)
+			}
+		})
+	}
+}
+
+func TestNewNamesUnnamedAccounts(t *testing.T) {
+	pool := New([]Account{{Cookie: "ui=1"}, {Cookie: "ui=2"}}, 0)
+	if got := fmt.Sprint(acquireNames(t, pool, 2)); got != "[account 1 account 2]" {
+		t.Errorf("got %s, want [account 1 account 2]", got)
+	}
+}
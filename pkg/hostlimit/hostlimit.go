@@ -0,0 +1,227 @@
+// Package hostlimit paces outbound requests per host and adapts the pace to
+// how the host responds, AIMD style: while responses are healthy the rate
+// climbs additively, a 429 or 5xx cuts it in half, and a Retry-After holds
+// back every request to the host until it has passed. Scrapers share one
+// Limiter, so nested fan-out (episodes, files, stream links) can't multiply
+// the load on a host.
+package hostlimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
+)
+
+// Defaults for Options left zero, in requests per second
+const (
+	DefaultInitial  = 2
+	DefaultMin      = 0.2
+	DefaultMax      = 10
+	DefaultIncrease = 0.2
+)
+
+// decrease is the factor a host's rate is cut by after a throttled response
+const decrease = 0.5
+
+// Options sets the rates every host starts at and moves between. Increase
+// is how much the rate rises per second's worth of healthy responses.
+type Options struct {
+	Initial  float64
+	Min      float64
+	Max      float64
+	Increase float64
+}
+
+// Rate is the current pace of one host
+type Rate struct {
+	Host        string
+	PerSecond   float64
+	Requests    int64
+	Throttled   int64     // 429 and 5xx responses
+	PausedUntil time.Time // end of a Retry-After pause, zero without one
+}
+
+func (r Rate) String() string {
+	str := fmt.Sprintf("%s: %.2f requests/s, %d requests, %d throttled", r.Host, r.PerSecond, r.Requests, r.Throttled)
+	if !r.PausedUntil.IsZero() {
+		str += fmt.Sprintf(", paused until %s", r.PausedUntil.Format(time.TimeOnly))
+	}
+	return str
+}
+
+type host struct {
+	rate        float64
+	next        time.Time // earliest start of the next request
+	pausedUntil time.Time
+	decreased   time.Time // last time the rate was cut
+	requests    int64
+	throttled   int64
+}
+
+// Limiter paces requests per host. It is safe for concurrent use; the zero
+// value is not, use New.
+type Limiter struct {
+	mu    sync.Mutex
+	hosts map[string]*host
+	opts  Options
+	now   func() time.Time
+}
+
+// New creates a limiter. Zero options use the defaults, and Initial is
+// clamped between Min and Max.
+func New(opts Options) *Limiter {
+	if opts.Min <= 0 {
+		opts.Min = DefaultMin
+	}
+	if opts.Max <= 0 {
+		opts.Max = DefaultMax
+	}
+	if opts.Initial <= 0 {
+		opts.Initial = DefaultInitial
+	}
+	if opts.Increase <= 0 {
+		opts.Increase = DefaultIncrease
+	}
+	opts.Max = max(opts.Max, opts.Min)
+	opts.Initial = min(max(opts.Initial, opts.Min), opts.Max)
+	return &Limiter{hosts: make(map[string]*host), opts: opts, now: time.Now}
+}
+
+// host returns the state of name, creating it at the initial rate. The
+// caller holds l.mu.
+func (l *Limiter) host(name string) *host {
+	h, ok := l.hosts[name]
+	if !ok {
+		h = &host{rate: l.opts.Initial}
+		l.hosts[name] = h
+	}
+	return h
+}
+
+// Wait blocks until a request to the named host may be sent or ctx is done
+func (l *Limiter) Wait(ctx context.Context, name string) error {
+	for {
+		delay := l.reserve(name)
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes the next slot of the named host and returns 0, or returns
+// how long to wait before trying again. Slots aren't booked ahead, so a
+// pause or a cut rate holds back requests that were already waiting.
+func (l *Limiter) reserve(name string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h := l.host(name)
+	now := l.now()
+	if now.Before(h.pausedUntil) {
+		return h.pausedUntil.Sub(now)
+	}
+	if now.Before(h.next) {
+		return h.next.Sub(now)
+	}
+	h.next = now.Add(time.Duration(float64(time.Second) / h.rate))
+	h.requests++
+	return 0
+}
+
+// Observe adapts the rate of the named host to the outcome of a request sent at sent.
+// A 429 or 5xx cuts the rate, once for all the requests that were already
+// in flight when it was last cut, and retryAfter pauses the host; any other
+// response raises the rate.
+func (l *Limiter) Observe(name string, sent time.Time, status int, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h := l.host(name)
+	now := l.now()
+
+	if status != http.StatusTooManyRequests && status < 500 {
+		if h.rate < l.opts.Max {
+			h.rate = min(h.rate+l.opts.Increase/h.rate, l.opts.Max)
+			if h.rate == l.opts.Max {
+				log.Printf("Rate for %s back at %.2f requests/s", name, h.rate)
+			}
+		}
+		return
+	}
+
+	h.throttled++
+	if retryAfter > 0 && now.Add(retryAfter).After(h.pausedUntil) {
+		h.pausedUntil = now.Add(retryAfter)
+		log.Printf("%s asked to wait %s, pausing requests to it", name, retryAfter)
+	}
+	if sent.Before(h.decreased) {
+		return
+	}
+	h.decreased = now
+	h.rate = max(h.rate*decrease, l.opts.Min)
+	if next := now.Add(time.Duration(float64(time.Second) / h.rate)); next.After(h.next) {
+		h.next = next
+	}
+	log.Printf("Rate for %s lowered to %.2f requests/s after status %d", name, h.rate, status)
+}
+
+// Rates reports every host the limiter has seen, sorted by name
+func (l *Limiter) Rates() []Rate {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	rates := make([]Rate, 0, len(l.hosts))
+	for name, h := range l.hosts {
+		rate := Rate{Host: name, PerSecond: h.rate, Requests: h.requests, Throttled: h.throttled}
+		if now.Before(h.pausedUntil) {
+			rate.PausedUntil = h.pausedUntil
+		}
+		rates = append(rates, rate)
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Host < rates[j].Host })
+	return rates
+}
+
+// Transport paces the requests sent through next by the host of their URL.
+// A nil limiter returns next unchanged.
+func (l *Limiter) Transport(next http.RoundTripper) http.RoundTripper {
+	if l == nil {
+		return next
+	}
+	return &transport{limiter: l, next: next}
+}
+
+type transport struct {
+	limiter *Limiter
+	next    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	if err := t.limiter.Wait(req.Context(), host); err != nil {
+		return nil, err
+	}
+	sent := t.limiter.now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.limiter.Observe(host, sent, resp.StatusCode, upstream.ParseRetryAfter(resp.Header.Get("Retry-After")))
+	return resp, nil
+}
@@ -0,0 +1,199 @@
+package hostlimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newTestLimiter creates a limiter with a clock the test moves by hand
+func newTestLimiter(opts Options) (*Limiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New(opts)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func rateOf(l *Limiter, name string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.host(name).rate
+}
+
+func near(got, want float64) bool {
+	return math.Abs(got-want) < 1e-9
+}
+
+func TestNewOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want Options
+	}{
+		{"defaults", Options{}, Options{Initial: DefaultInitial, Min: DefaultMin, Max: DefaultMax, Increase: DefaultIncrease}},
+		{"initial above max", Options{Initial: 20, Max: 5}, Options{Initial: 5, Min: DefaultMin, Max: 5, Increase: DefaultIncrease}},
+		{"initial below min", Options{Initial: 0.1, Min: 1}, Options{Initial: 1, Min: 1, Max: DefaultMax, Increase: DefaultIncrease}},
+		{"max below min", Options{Min: 3, Max: 1}, Options{Initial: 3, Min: 3, Max: 3, Increase: DefaultIncrease}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.opts).opts; got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReserveSpacesRequests(t *testing.T) {
+	limiter, now := newTestLimiter(Options{Initial: 2})
+
+	if delay := limiter.reserve("febbox.com"); delay != 0 {
+		t.Fatalf("first request waits %s", delay)
+	}
+	if delay := limiter.reserve("febbox.com"); delay != 500*time.Millisecond {
+		t.Errorf("second request waits %s, want 500ms at 2 requests/s", delay)
+	}
+	// Hosts are paced independently
+	if delay := limiter.reserve("showbox.media"); delay != 0 {
+		t.Errorf("other host waits %s", delay)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if delay := limiter.reserve("febbox.com"); delay != 0 {
+		t.Errorf("request after the interval waits %s", delay)
+	}
+}
+
+func TestAdditiveIncrease(t *testing.T) {
+	limiter, now := newTestLimiter(Options{Initial: 2, Max: 2.2, Increase: 0.2})
+
+	limiter.Observe("febbox.com", *now, http.StatusOK, 0)
+	if got := rateOf(limiter, "febbox.com"); !near(got, 2.1) {
+		t.Errorf("got rate %.3f after a success, want 2.1", got)
+	}
+	// Client errors aren't a sign of overload
+	limiter.Observe("febbox.com", *now, http.StatusNotFound, 0)
+	limiter.Observe("febbox.com", *now, http.StatusOK, 0)
+	if got := rateOf(limiter, "febbox.com"); got != 2.2 {
+		t.Errorf("got rate %.3f, want it capped at 2.2", got)
+	}
+}
+
+func TestMultiplicativeDecrease(t *testing.T) {
+	limiter, now := newTestLimiter(Options{Initial: 4, Min: 0.5})
+	sent := *now
+
+	*now = now.Add(time.Second)
+	limiter.Observe("febbox.com", sent, http.StatusTooManyRequests, 0)
+	if got := rateOf(limiter, "febbox.com"); got != 2 {
+		t.Fatalf("got rate %.2f after a 429, want 2", got)
+	}
+	// Requests in flight at the cut don't cut again
+	limiter.Observe("febbox.com", sent, http.StatusBadGateway, 0)
+	if got := rateOf(limiter, "febbox.com"); got != 2 {
+		t.Errorf("got rate %.2f after a second throttle of the same burst, want 2", got)
+	}
+
+	*now = now.Add(time.Second)
+	for i := 0; i < 5; i++ {
+		limiter.Observe("febbox.com", *now, http.StatusServiceUnavailable, 0)
+		*now = now.Add(time.Second)
+	}
+	if got := rateOf(limiter, "febbox.com"); got != 0.5 {
+		t.Errorf("got rate %.2f, want it floored at 0.5", got)
+	}
+	if rates := limiter.Rates(); rates[0].Throttled != 7 {
+		t.Errorf("got %d throttled, want 7", rates[0].Throttled)
+	}
+}
+
+func TestRetryAfterPausesHost(t *testing.T) {
+	limiter, now := newTestLimiter(Options{})
+
+	limiter.Observe("febbox.com", *now, http.StatusTooManyRequests, 30*time.Second)
+	// A shorter Retry-After doesn't end the pause early
+	limiter.Observe("febbox.com", *now, http.StatusTooManyRequests, 10*time.Second)
+
+	if delay := limiter.reserve("febbox.com"); delay != 30*time.Second {
+		t.Errorf("request waits %s, want the 30s asked for", delay)
+	}
+	if rates := limiter.Rates(); rates[0].PausedUntil != now.Add(30*time.Second) {
+		t.Errorf("unexpected rates %+v", rates)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx, "febbox.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait during the pause returned %v, want %v", err, context.Canceled)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if rates := limiter.Rates(); !rates[0].PausedUntil.IsZero() {
+		t.Errorf("pause still reported after it ended: %+v", rates[0])
+	}
+}
+
+func TestTransportObservesResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/busy" {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+	host := mustHost(t, server.URL)
+
+	limiter := New(Options{Initial: 1000, Max: 1000})
+	client := &http.Client{Transport: limiter.Transport(http.DefaultTransport)}
+
+	for _, path := range []string{"/", "/busy"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Get %s: %v", path, err)
+		}
+		resp.Body.Close()
+	}
+
+	rates := limiter.Rates()
+	if len(rates) != 1 || rates[0].Host != host {
+		t.Fatalf("unexpected rates %+v", rates)
+	}
+	if rates[0].Requests != 2 || rates[0].Throttled != 1 || rates[0].PerSecond != 500 {
+		t.Errorf("unexpected rate %+v", rates[0])
+	}
+	if until := time.Until(rates[0].PausedUntil); until < 110*time.Second {
+		t.Errorf("host paused for %s, want about 2m", until)
+	}
+
+	// The pause holds back the next request
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("request during the pause returned %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var limiter *Limiter
+	if limiter.Rates() != nil {
+		t.Error("nil limiter reported rates")
+	}
+	if got := limiter.Transport(http.DefaultTransport); got != http.DefaultTransport {
+		t.Errorf("nil limiter wrapped the transport: %T", got)
+	}
+}
+
+func mustHost(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,194 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrNoFixture is returned in replay mode for requests that were never recorded
+var ErrNoFixture = errors.New("no recorded response")
+
+// Query parameters holding credentials; they are dropped before a request is
+// matched or saved so fixtures can be committed
+var sensitiveParams = []string{"api_key", "token", "access_token", "pwd"}
+
+// Response headers worth keeping. Everything else, Set-Cookie in particular,
+// is left out of the fixture.
+var keptHeaders = []string{"Content-Type", "Location", "Retry-After"}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fixture is a single recorded exchange as stored on disk
+type fixture struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+type fixtureStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// path maps a request to its fixture file: one directory per host, named
+// after the URL path plus a hash of the full redacted URL
+func (s *fixtureStore) path(method string, u *url.URL) string {
+	redacted := redactURL(u)
+
+	// Requests routed through the prefix proxy are filed under the site
+	// they're really for
+	named := u
+	if destination := u.Query().Get("destination"); destination != "" {
+		if parsed, err := url.Parse(destination); err == nil && parsed.Host != "" {
+			named = parsed
+		}
+	}
+
+	sum := sha1.Sum([]byte(method + " " + redacted))
+	name := strings.Trim(unsafePathChars.ReplaceAllString(named.Path, "_"), "_")
+	if name == "" {
+		name = "index"
+	}
+	host := unsafePathChars.ReplaceAllString(named.Host, "_")
+	return filepath.Join(s.dir, host, fmt.Sprintf("%s-%s.json", name, hex.EncodeToString(sum[:])[:12]))
+}
+
+func (s *fixtureStore) load(req *http.Request) (*fixture, error) {
+	data, err := os.ReadFile(s.path(req.Method, req.URL))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoFixture, req.Method, redactURL(req.URL))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture: %w", err)
+	}
+	return &f, nil
+}
+
+func (s *fixtureStore) save(req *http.Request, f *fixture) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	path := s.path(req.Method, req.URL)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// recorder forwards requests to the network and saves every response
+type recorder struct {
+	next  http.RoundTripper
+	store fixtureStore
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	f := &fixture{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Status: resp.StatusCode,
+		Header: filterHeader(resp.Header),
+		Body:   string(body),
+	}
+	if err := r.store.save(req, f); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replayer serves recorded responses and fails requests it has none for
+type replayer struct {
+	store fixtureStore
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	f, err := r.store.load(req)
+	if err != nil {
+		return nil, err
+	}
+
+	header := f.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
+
+// redactURL drops credentials from a URL, including URLs nested in the
+// destination parameter of the prefix proxy
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+
+	query := redacted.Query()
+	for _, param := range sensitiveParams {
+		query.Del(param)
+	}
+	if destination := query.Get("destination"); destination != "" {
+		if parsed, err := url.Parse(destination); err == nil {
+			query.Set("destination", redactURL(parsed))
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+func filterHeader(header http.Header) http.Header {
+	filtered := make(http.Header)
+	for _, name := range keptHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			filtered[name] = values
+		}
+	}
+	return filtered
+}
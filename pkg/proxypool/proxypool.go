@@ -0,0 +1,300 @@
+// Package proxypool spreads outbound requests over a pool of proxies: HTTP,
+// HTTPS and SOCKS5 proxies as well as prefix proxies, which take the target
+// URL appended to their own (https://proxy.workers.dev?destination=...).
+// Every proxy keeps a health score, and proxies that keep failing with 429,
+// 5xx or network errors are quarantined for a while.
+package proxypool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Selection is how the next proxy is picked
+type Selection string
+
+const (
+	RoundRobin  Selection = "round_robin"  // take turns
+	LeastErrors Selection = "least_errors" // prefer the lowest recent error rate
+)
+
+// Defaults for Options left zero
+const (
+	DefaultQuarantineAfter = 3
+	DefaultQuarantineFor   = 5 * time.Minute
+)
+
+// errorRateWeight is how much the latest request moves a proxy's error rate
+const errorRateWeight = 0.2
+
+// Proxy is one entry of the pool. With Prefix set, URL is a prefix proxy and
+// requests go to URL followed by the escaped target URL; otherwise it is an
+// http, https or socks5 proxy.
+type Proxy struct {
+	URL    string
+	Prefix bool
+}
+
+// Validate checks that the proxy URL can be used
+func (p Proxy) Validate() error {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("proxy URL %q has no host", u.Redacted())
+	}
+	switch {
+	case p.Prefix && (u.Scheme == "http" || u.Scheme == "https"):
+	case !p.Prefix && (u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "socks5" || u.Scheme == "socks5h"):
+	case p.Prefix:
+		return fmt.Errorf("prefix proxy %q must be an http or https URL", u.Redacted())
+	default:
+		return fmt.Errorf("proxy %q must be an http, https or socks5 URL", u.Redacted())
+	}
+	return nil
+}
+
+// FromURLs returns a prefix proxy and an HTTP or SOCKS5 proxy as pool
+// entries, the way single proxies were configured before pools existed. Empty
+// URLs are left out.
+func FromURLs(prefix, proxy string) []Proxy {
+	var proxies []Proxy
+	if prefix != "" {
+		proxies = append(proxies, Proxy{URL: prefix, Prefix: true})
+	}
+	if proxy != "" {
+		proxies = append(proxies, Proxy{URL: proxy})
+	}
+	return proxies
+}
+
+// name is the proxy URL without credentials, for logs and stats
+func (p Proxy) name() string {
+	if u, err := url.Parse(p.URL); err == nil {
+		return u.Redacted()
+	}
+	return p.URL
+}
+
+// Options tunes selection and quarantine
+type Options struct {
+	Selection       Selection
+	QuarantineAfter int           // consecutive failures before a proxy is quarantined
+	QuarantineFor   time.Duration // how long it is left out
+}
+
+// Stats is the health of one proxy
+type Stats struct {
+	Proxy       string // URL without credentials
+	Requests    int64
+	Failures    int64
+	ErrorRate   float64   // recent share of failed requests, 0 to 1
+	Quarantined time.Time // end of the quarantine, zero when not quarantined
+}
+
+func (s Stats) String() string {
+	str := fmt.Sprintf("%s: %d requests, %d failures, error rate %.0f%%", s.Proxy, s.Requests, s.Failures, s.ErrorRate*100)
+	if !s.Quarantined.IsZero() {
+		str += fmt.Sprintf(", quarantined until %s", s.Quarantined.Format(time.TimeOnly))
+	}
+	return str
+}
+
+type entry struct {
+	Proxy
+	requests    int64
+	failures    int64
+	consecutive int
+	errorRate   float64
+	until       time.Time
+}
+
+// Pool picks a proxy for every request and tracks how each one fares. It is
+// safe for concurrent use.
+type Pool struct {
+	mu      sync.Mutex
+	entries []*entry
+	next    int
+	opts    Options
+	now     func() time.Time
+}
+
+// New creates a pool of the proxies. Invalid proxies are logged and left
+// out; config validation reports them before it gets that far.
+func New(proxies []Proxy, opts Options) *Pool {
+	if opts.Selection == "" {
+		opts.Selection = RoundRobin
+	}
+	if opts.QuarantineAfter <= 0 {
+		opts.QuarantineAfter = DefaultQuarantineAfter
+	}
+	if opts.QuarantineFor <= 0 {
+		opts.QuarantineFor = DefaultQuarantineFor
+	}
+	p := &Pool{opts: opts, now: time.Now}
+	for _, proxy := range proxies {
+		if err := proxy.Validate(); err != nil {
+			log.Printf("Skipping proxy: %v", err)
+			continue
+		}
+		p.entries = append(p.entries, &entry{Proxy: proxy})
+	}
+	return p
+}
+
+// Size returns the number of proxies in the pool
+func (p *Pool) Size() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// pick returns the index of the proxy for the next request. Quarantined
+// proxies are skipped; when all of them are, the one released first is used
+// so requests keep flowing.
+func (p *Pool) pick() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	best := -1
+	for i := 0; i < len(p.entries); i++ {
+		idx := (p.next + i) % len(p.entries)
+		e := p.entries[idx]
+		if now.Before(e.until) {
+			continue
+		}
+		if p.opts.Selection != LeastErrors {
+			best = idx
+			break
+		}
+		if best < 0 || e.errorRate < p.entries[best].errorRate {
+			best = idx
+		}
+	}
+	if best < 0 {
+		best = 0
+		for i, e := range p.entries {
+			if e.until.Before(p.entries[best].until) {
+				best = i
+			}
+		}
+	}
+	p.next = (best + 1) % len(p.entries)
+	p.entries[best].requests++
+	return best
+}
+
+// record scores the outcome of a request made through proxy idx
+func (p *Pool) record(idx int, resp *http.Response, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	failed := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.entries[idx]
+	outcome := 0.0
+	if failed {
+		outcome = 1
+		e.failures++
+		e.consecutive++
+	} else {
+		e.consecutive = 0
+	}
+	e.errorRate += errorRateWeight * (outcome - e.errorRate)
+
+	if e.consecutive >= p.opts.QuarantineAfter {
+		e.consecutive = 0
+		e.until = p.now().Add(p.opts.QuarantineFor)
+		log.Printf("Proxy %s failed %d times in a row, quarantined for %s", e.name(), p.opts.QuarantineAfter, p.opts.QuarantineFor)
+	}
+}
+
+// Stats reports every proxy, in the order they were configured
+func (p *Pool) Stats() []Stats {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	stats := make([]Stats, 0, len(p.entries))
+	for _, e := range p.entries {
+		s := Stats{
+			Proxy:     e.name(),
+			Requests:  e.requests,
+			Failures:  e.failures,
+			ErrorRate: e.errorRate,
+		}
+		if now.Before(e.until) {
+			s.Quarantined = e.until
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// Transport sends requests through the pool. Connection proxies get a clone
+// of base each; prefix proxies rewrite the request URL and use base itself.
+// An empty or nil pool returns base unchanged.
+func (p *Pool) Transport(base *http.Transport) http.RoundTripper {
+	if p.Size() == 0 {
+		return base
+	}
+	t := &transport{pool: p, direct: base, proxied: make([]http.RoundTripper, len(p.entries))}
+	for i, e := range p.entries {
+		if e.Prefix {
+			continue
+		}
+		proxyURL, _ := url.Parse(e.URL) // validated by New
+		proxied := base.Clone()
+		proxied.Proxy = http.ProxyURL(proxyURL)
+		t.proxied[i] = proxied
+	}
+	return t
+}
+
+type transport struct {
+	pool    *Pool
+	direct  http.RoundTripper
+	proxied []http.RoundTripper // per entry, nil for prefix proxies
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idx := t.pool.pick()
+	e := t.pool.entries[idx]
+
+	if !e.Prefix {
+		resp, err := t.proxied[idx].RoundTrip(req)
+		t.pool.record(idx, resp, err)
+		return resp, err
+	}
+
+	target, err := url.Parse(e.URL + url.QueryEscape(req.URL.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build prefix proxy URL: %w", err)
+	}
+	out := req.Clone(req.Context())
+	out.URL = target
+	out.Host = ""
+	resp, err := t.direct.RoundTrip(out)
+	t.pool.record(idx, resp, err)
+	if resp != nil {
+		// Callers see the response of the URL they asked for, so relative
+		// links and redirects resolve against the site, not the proxy
+		resp.Request = req
+	}
+	return resp, err
+}
@@ -0,0 +1,195 @@
+package refresher
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/db/utils"
+)
+
+// Config controls how often the refresher runs and how far ahead of expiry
+// it re-resolves links
+type Config struct {
+	Interval  time.Duration // time between two refresh passes
+	Lead      time.Duration // links expiring within this window are refreshed
+	BatchSize int64         // documents loaded from the database at a time
+}
+
+// DefaultConfig returns settings that keep links comfortably ahead of utils.LinkTTL
+func DefaultConfig() Config {
+	return Config{
+		Interval:  10 * time.Minute,
+		Lead:      30 * time.Minute,
+		BatchSize: 50,
+	}
+}
+
+// Stats summarises a single refresh pass
+type Stats struct {
+	Movies       int
+	TVShows      int
+	FilesUpdated int
+	Failures     int
+}
+
+// Refresher proactively re-resolves febbox links before they expire and
+// writes them back, so read paths can serve stored links as-is
+type Refresher struct {
+	repo     repository.Repository
+	streamer *utils.Streamer
+	config   Config
+}
+
+// NewRefresher creates a new link refresher
+func NewRefresher(repo repository.Repository, streamer *utils.Streamer, cfg Config) *Refresher {
+	return &Refresher{
+		repo:     repo,
+		streamer: streamer,
+		config:   cfg,
+	}
+}
+
+// Run refreshes links every Interval until the context is cancelled
+func (r *Refresher) Run(ctx context.Context) {
+	log.Printf("Link refresher started (interval: %s, lead: %s)", r.config.Interval, r.config.Lead)
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		stats := r.RefreshOnce(ctx)
+		if stats.FilesUpdated > 0 || stats.Failures > 0 {
+			log.Printf("Link refresh pass done: %d movies, %d TV shows, %d files updated, %d failures",
+				stats.Movies, stats.TVShows, stats.FilesUpdated, stats.Failures)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Link refresher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshOnce runs a single pass over every document with links expiring
+// within the lead window
+func (r *Refresher) RefreshOnce(ctx context.Context) Stats {
+	var stats Stats
+	before := time.Now().Add(r.config.Lead)
+
+	// Refreshed documents drop out of the result set, so only the ones that
+	// failed have to be skipped to reach the next batch. Documents already
+	// handled in this pass are skipped too, in case their new links still
+	// expire within the lead window
+	var skip int64
+	seen := make(map[string]bool)
+	for ctx.Err() == nil {
+		movies, err := r.repo.GetMoviesWithExpiringLinks(ctx, before, r.config.BatchSize, skip)
+		if err != nil {
+			log.Printf("Error loading movies with expiring links: %v", err)
+			break
+		}
+		if len(movies) == 0 {
+			break
+		}
+		for i := range movies {
+			if seen[movies[i].MovieID] {
+				skip++
+				continue
+			}
+			seen[movies[i].MovieID] = true
+			updated, ok := r.refreshMovie(ctx, &movies[i], before)
+			stats.Movies++
+			stats.FilesUpdated += updated
+			if !ok {
+				stats.Failures++
+				skip++
+			}
+		}
+	}
+
+	skip = 0
+	seen = make(map[string]bool)
+	for ctx.Err() == nil {
+		tvShows, err := r.repo.GetTVShowsWithExpiringLinks(ctx, before, r.config.BatchSize, skip)
+		if err != nil {
+			log.Printf("Error loading TV shows with expiring links: %v", err)
+			break
+		}
+		if len(tvShows) == 0 {
+			break
+		}
+		for i := range tvShows {
+			if seen[tvShows[i].TVID] {
+				skip++
+				continue
+			}
+			seen[tvShows[i].TVID] = true
+			updated, ok := r.refreshTV(ctx, &tvShows[i], before)
+			stats.TVShows++
+			stats.FilesUpdated += updated
+			if !ok {
+				stats.Failures++
+				skip++
+			}
+		}
+	}
+
+	return stats
+}
+
+// refreshMovie updates the expiring files of a movie and reports how many
+// files were updated and whether the movie is now fully refreshed
+func (r *Refresher) refreshMovie(ctx context.Context, movie *models.Movie, before time.Time) (int, bool) {
+	updated, ok := r.refreshFiles(ctx, movie.Files, before)
+	if updated > 0 {
+		if err := r.repo.UpdateMovie(ctx, movie); err != nil {
+			log.Printf("Error saving refreshed links for movie %s: %v", movie.MovieID, err)
+			return updated, false
+		}
+	}
+	return updated, ok
+}
+
+// refreshTV updates the expiring episode files of a TV show and reports how
+// many files were updated and whether the show is now fully refreshed
+func (r *Refresher) refreshTV(ctx context.Context, tv *models.TV, before time.Time) (int, bool) {
+	updated, ok := 0, true
+	for i := range tv.Seasons {
+		for j := range tv.Seasons[i].Episodes {
+			episode := &tv.Seasons[i].Episodes[j]
+			for k := range episode.Sources {
+				n, sourceOK := r.refreshFiles(ctx, episode.Sources[k].Files, before)
+				updated += n
+				ok = ok && sourceOK
+			}
+		}
+	}
+	if updated > 0 {
+		if err := r.repo.UpdateTV(ctx, tv); err != nil {
+			log.Printf("Error saving refreshed links for TV series %s: %v", tv.TVID, err)
+			return updated, false
+		}
+	}
+	return updated, ok
+}
+
+func (r *Refresher) refreshFiles(ctx context.Context, files []models.File, before time.Time) (int, bool) {
+	updated, ok := 0, true
+	for i := range files {
+		file := &files[i]
+		if len(file.Links) == 0 || !utils.LinksExpireBefore(file.Links, before) {
+			continue
+		}
+		if err := r.streamer.UpdateFileStream(ctx, file); err != nil {
+			log.Printf("Error refreshing links for fid %d: %v", file.FID, err)
+			ok = false
+			continue
+		}
+		updated++
+	}
+	return updated, ok
+}
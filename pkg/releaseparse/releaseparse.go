@@ -0,0 +1,445 @@
+// Package releaseparse extracts structured metadata from scene style release
+// names such as "Show.Name.S01E02.1080p.WEB-DL.DDP5.1.H.264-GROUP.mkv".
+package releaseparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Normalised values used for Source, Codec and HDR
+const (
+	SourceRemux  = "REMUX"
+	SourceBluRay = "BluRay"
+	SourceWebDL  = "WEB-DL"
+	SourceWebRip = "WEBRip"
+	SourceWeb    = "WEB"
+	SourceHDTV   = "HDTV"
+	SourceHDRip  = "HDRip"
+	SourceDVD    = "DVD"
+
+	CodecHEVC = "HEVC"
+	CodecH264 = "H.264"
+	CodecAV1  = "AV1"
+	CodecVP9  = "VP9"
+	CodecXviD = "XviD"
+
+	HDRDolbyVision = "DV"
+	HDR10Plus      = "HDR10+"
+	HDR10          = "HDR10"
+	HDRGeneric     = "HDR"
+	HDRHLG         = "HLG"
+)
+
+// Release is the metadata parsed from a file name. Fields that couldn't be
+// determined are left empty. A file is an episode when Episodes is non-empty,
+// in which case Season 0 means specials; anime releases without a season only
+// carry the Absolute episode number. Season packs spanning several seasons
+// list all of them in Seasons, with Season set to the first.
+type Release struct {
+	Title      string   `bson:"title,omitempty" json:"title,omitempty"`
+	Year       int      `bson:"year,omitempty" json:"year,omitempty"`
+	Season     int      `bson:"season,omitempty" json:"season,omitempty"`
+	Seasons    []int    `bson:"seasons,omitempty" json:"seasons,omitempty"`
+	Episodes   []int    `bson:"episodes,omitempty" json:"episodes,omitempty"`
+	Absolute   int      `bson:"absolute,omitempty" json:"absolute,omitempty"`
+	Date       string   `bson:"date,omitempty" json:"date,omitempty"` // air date of daily shows, YYYY-MM-DD
+	Resolution string   `bson:"resolution,omitempty" json:"resolution,omitempty"`
+	Source     string   `bson:"source,omitempty" json:"source,omitempty"`
+	Codec      string   `bson:"codec,omitempty" json:"codec,omitempty"`
+	HDR        []string `bson:"hdr,omitempty" json:"hdr,omitempty"`
+	Audio      string   `bson:"audio,omitempty" json:"audio,omitempty"`
+	Channels   string   `bson:"channels,omitempty" json:"channels,omitempty"`
+	Group      string   `bson:"group,omitempty" json:"group,omitempty"`
+	Edition    string   `bson:"edition,omitempty" json:"edition,omitempty"`
+}
+
+// IsEpisode reports whether the name carries a season and episode number
+func (r *Release) IsEpisode() bool {
+	return len(r.Episodes) > 0
+}
+
+// Episode returns the first episode number, or 0 if there is none
+func (r *Release) Episode() int {
+	if len(r.Episodes) == 0 {
+		return 0
+	}
+	return r.Episodes[0]
+}
+
+// maxEpisodeRange caps how many episodes a range like S01E01-E99 expands to
+const maxEpisodeRange = 50
+
+// labelledPattern maps a match to a normalised value. Patterns with a
+// capture group use label as a format for the captured text.
+type labelledPattern struct {
+	re    *regexp.Regexp
+	label string
+}
+
+var (
+	extensionPattern = regexp.MustCompile(`(?i)\.(?:mkv|mp4|m4v|avi|mov|wmv|webm|flv|mpe?g|ts|iso)$`)
+
+	leadingGroupPattern  = regexp.MustCompile(`^\s*\[([^\]]+)\]\s*`)
+	trailingGroupPattern = regexp.MustCompile(`-\s?(?:\[([^\]]+)\]|([A-Za-z0-9][A-Za-z0-9_]*))(?:\s?\[[^\]]*\])?\s*$`)
+
+	// S01E02, S01E02E03, S01E02-E05, S01E02-05
+	seasonEpisodePattern = regexp.MustCompile(`(?i)\bS(\d{1,3})[ .]?E(\d{1,4})((?:[ .-]?E\d{1,4})*)(?:-(\d{1,4})\b)?`)
+	additionalEpisode    = regexp.MustCompile(`(?i)([ .-]?)E(\d{1,4})`)
+	// 1x05, 1x05x06
+	crossPattern = regexp.MustCompile(`(?i)\b(\d{1,2})x(\d{2,3})((?:x\d{2,3})*)\b`)
+	// S01-S03, Season 1-3
+	seasonRangePattern = regexp.MustCompile(`(?i)\b(?:S(\d{1,2})[ .]?-[ .]?S|Season[ .]?(\d{1,2})[ .]?-[ .]?)(\d{1,2})\b`)
+	// S01, Season 1
+	seasonOnlyPattern = regexp.MustCompile(`(?i)\b(?:S|Season[ .]?)(\d{1,2})\b`)
+	// Daily shows: 2023.05.14
+	datePattern = regexp.MustCompile(`\b((?:19|20)\d{2})[.-](\d{2})[.-](\d{2})\b`)
+	// Anime: "[Group] Title - 1071 (1080p)"
+	absolutePattern = regexp.MustCompile(`\s-\s(\d{1,4})(?:v\d)?(?:\s|$|[\[(])`)
+	yearPattern     = regexp.MustCompile(`\b((?:19|20)\d{2})\b`)
+
+	resolutionPatterns = []labelledPattern{
+		{regexp.MustCompile(`(?i)\b\d{3,4}x(2160|1080|720|576|480)\b`), "%sp"},
+		{regexp.MustCompile(`(?i)\b(2160|1440|1080|720|576|480|360)[pi]\b`), "%sp"},
+		{regexp.MustCompile(`(?i)\b(?:4K|UHD)\b`), "2160p"},
+	}
+	sourcePatterns = []labelledPattern{
+		{regexp.MustCompile(`(?i)\b(?:BD)?REMUX\b`), SourceRemux},
+		{regexp.MustCompile(`(?i)\bWEB[ .-]?DL\b`), SourceWebDL},
+		{regexp.MustCompile(`(?i)\bWEB[ .-]?Rip\b`), SourceWebRip},
+		{regexp.MustCompile(`(?i)\b(?:Blu[ .-]?Ray|BDRip|BRRip|BD(?:25|50))\b`), SourceBluRay},
+		{regexp.MustCompile(`(?i)\bHDTV(?:Rip)?\b`), SourceHDTV},
+		{regexp.MustCompile(`(?i)\bHDRip\b`), SourceHDRip},
+		{regexp.MustCompile(`(?i)\bDVD(?:Rip|R|9|5)?\b`), SourceDVD},
+		{regexp.MustCompile(`(?i)\bWEB\b`), SourceWeb},
+	}
+	codecPatterns = []labelledPattern{
+		{regexp.MustCompile(`(?i)\b(?:[xh]\.?265|HEVC)\b`), CodecHEVC},
+		{regexp.MustCompile(`(?i)\b(?:[xh]\.?264|AVC)\b`), CodecH264},
+		{regexp.MustCompile(`(?i)\bAV1\b`), CodecAV1},
+		{regexp.MustCompile(`(?i)\bVP9\b`), CodecVP9},
+		{regexp.MustCompile(`(?i)\bXviD\b`), CodecXviD},
+	}
+	hdrPatterns = []labelledPattern{
+		{regexp.MustCompile(`(?i)\b(?:DV|DoVi|Dolby[ .]?Vision)\b`), HDRDolbyVision},
+		{regexp.MustCompile(`(?i)\bHDR10(?:\+|Plus\b)`), HDR10Plus},
+		{regexp.MustCompile(`(?i)\bHDR10\b`), HDR10},
+		{regexp.MustCompile(`(?i)\bHLG\b`), HDRHLG},
+		{regexp.MustCompile(`(?i)\bHDR\b`), HDRGeneric},
+	}
+	audioPatterns = []labelledPattern{
+		{regexp.MustCompile(`(?i)\bTrueHD`), "TrueHD"},
+		{regexp.MustCompile(`(?i)\bDTS[ .-]?HD[ .-]?MA`), "DTS-HD MA"},
+		{regexp.MustCompile(`(?i)\bDTS[ .:-]?X\b`), "DTS:X"},
+		{regexp.MustCompile(`(?i)\bDTS`), "DTS"},
+		{regexp.MustCompile(`(?i)\b(?:DDP|DD\+|E-?AC-?3)`), "DDP"},
+		{regexp.MustCompile(`(?i)\b(?:DD|AC-?3)(?:\d|\b)`), "DD"},
+		{regexp.MustCompile(`(?i)\bAAC`), "AAC"},
+		{regexp.MustCompile(`(?i)\bFLAC`), "FLAC"},
+		{regexp.MustCompile(`(?i)\bOpus\b`), "Opus"},
+		{regexp.MustCompile(`(?i)\bMP3\b`), "MP3"},
+	}
+	atmosPattern    = regexp.MustCompile(`(?i)\bAtmos\b`)
+	channelPatterns = []labelledPattern{
+		{regexp.MustCompile(`(?i)(?:DDP|DD\+|DD|E?AC-?3|AAC|DTS[ .:-]?X|DTS|MA|TrueHD|FLAC|Opus|Atmos)[ .]?([12567]\.[01])\b`), "%s"},
+		{regexp.MustCompile(`(?i)\b([2678])CH\b`), "%sch"},
+	}
+	editionPatterns = []labelledPattern{
+		{regexp.MustCompile(`(?i)\bDirector'?s[ .]Cut\b`), "Director's Cut"},
+		{regexp.MustCompile(`(?i)\bExtended(?:[ .](?:Cut|Edition))?\b`), "Extended"},
+		{regexp.MustCompile(`(?i)\bTheatrical(?:[ .]Cut)?\b`), "Theatrical"},
+		{regexp.MustCompile(`(?i)\bUnrated\b`), "Unrated"},
+		{regexp.MustCompile(`(?i)\bUncut\b`), "Uncut"},
+		{regexp.MustCompile(`(?i)\bUltimate[ .](?:Cut|Edition)\b`), "Ultimate"},
+		{regexp.MustCompile(`(?i)\bSpecial[ .]Edition\b`), "Special Edition"},
+		{regexp.MustCompile(`(?i)\bCriterion\b`), "Criterion"},
+		{regexp.MustCompile(`(?i)\bRemastered\b`), "Remastered"},
+		{regexp.MustCompile(`(?i)\bIMAX\b`), "IMAX"},
+	}
+
+	// Channel counts written as "6CH" and friends
+	channelsByCount = map[string]string{"2ch": "2.0", "6ch": "5.1", "7ch": "6.1", "8ch": "7.1"}
+)
+
+// parser keeps track of the parts of a name that were recognised as
+// metadata. The title is whatever precedes the first of them.
+type parser struct {
+	name  string
+	start int // where the title starts, after a leading [Group]
+	end   int // where the title ends
+	spans [][2]int
+}
+
+// Parse extracts the release metadata from a file name. It never fails; an
+// unrecognised name yields a Release with only the Title set.
+func Parse(name string) *Release {
+	name = extensionPattern.ReplaceAllString(strings.TrimSpace(name), "")
+	p := &parser{
+		// Underscores are separators too; replacing them one for one keeps
+		// match offsets valid for the original string
+		name: strings.ReplaceAll(name, "_", " "),
+		end:  len(name),
+	}
+	r := &Release{}
+
+	if loc := leadingGroupPattern.FindStringSubmatchIndex(p.name); loc != nil {
+		r.Group = strings.TrimSpace(p.name[loc[2]:loc[3]])
+		p.start = loc[1]
+		p.spans = append(p.spans, [2]int{loc[0], loc[1]})
+	}
+
+	p.parseEpisode(r)
+
+	r.Resolution = p.label(resolutionPatterns)
+	r.Source = p.label(sourcePatterns)
+	r.Codec = p.label(codecPatterns)
+	for _, pattern := range hdrPatterns {
+		if loc := p.find(pattern.re); loc != nil {
+			// Plain HDR is implied by HDR10 and HDR10+, but not by Dolby
+			// Vision or HLG, which are often released with an HDR fallback
+			if pattern.label == HDRGeneric && (contains(r.HDR, HDR10) || contains(r.HDR, HDR10Plus)) {
+				continue
+			}
+			r.HDR = append(r.HDR, pattern.label)
+		}
+	}
+	r.Audio = p.label(audioPatterns)
+	if p.find(atmosPattern) != nil {
+		r.Audio = strings.TrimSpace(r.Audio + " Atmos")
+	}
+	r.Channels = p.channels()
+	r.Edition = p.label(editionPatterns)
+
+	r.Year = p.parseYear()
+
+	// A trailing -GROUP only counts when the name had recognisable metadata,
+	// otherwise "Spider-Man" would be released by "Man"
+	if r.Group == "" && p.end < len(p.name) {
+		if loc := trailingGroupPattern.FindStringSubmatchIndex(p.name); loc != nil && loc[0] >= p.end && !p.overlaps(loc[0], loc[1]) {
+			if loc[2] >= 0 {
+				r.Group = p.name[loc[2]:loc[3]]
+			} else {
+				r.Group = p.name[loc[4]:loc[5]]
+			}
+		}
+	}
+
+	r.Title = cleanTitle(p.name[p.start:p.end])
+	return r
+}
+
+// parseEpisode looks for the season/episode notations in order of how
+// unambiguous they are
+func (p *parser) parseEpisode(r *Release) {
+	if loc := p.find(seasonEpisodePattern); loc != nil {
+		r.Season = atoi(p.name[loc[2]:loc[3]])
+		r.Episodes = []int{atoi(p.name[loc[4]:loc[5]])}
+		for _, m := range additionalEpisode.FindAllStringSubmatch(p.name[loc[6]:loc[7]], -1) {
+			// S01E02-E05 is a range, S01E02E03 a list
+			if m[1] == "-" {
+				r.Episodes = expandRange(r.Episodes, atoi(m[2]))
+			} else {
+				r.Episodes = append(r.Episodes, atoi(m[2]))
+			}
+		}
+		if loc[8] >= 0 {
+			r.Episodes = expandRange(r.Episodes, atoi(p.name[loc[8]:loc[9]]))
+		}
+		return
+	}
+
+	if loc := p.find(datePattern); loc != nil {
+		date := p.name[loc[2]:loc[3]] + "-" + p.name[loc[4]:loc[5]] + "-" + p.name[loc[6]:loc[7]]
+		if _, err := time.Parse("2006-01-02", date); err == nil {
+			r.Date = date
+			return
+		}
+	}
+
+	if loc := p.find(crossPattern); loc != nil {
+		r.Season = atoi(p.name[loc[2]:loc[3]])
+		r.Episodes = []int{atoi(p.name[loc[4]:loc[5]])}
+		for _, part := range strings.Split(p.name[loc[6]:loc[7]], "x") {
+			if part != "" {
+				r.Episodes = append(r.Episodes, atoi(part))
+			}
+		}
+		return
+	}
+
+	if loc := absolutePattern.FindStringSubmatchIndex(p.name[p.start:]); loc != nil {
+		number := atoi(p.name[p.start+loc[2] : p.start+loc[3]])
+		// Without a fansub [Group] a four digit number is more likely a year
+		if number > 0 && (r.Group != "" || !isYear(number)) {
+			p.mark(p.start+loc[0], p.start+loc[1])
+			r.Absolute = number
+			return
+		}
+	}
+
+	if loc := p.find(seasonRangePattern); loc != nil {
+		first := loc[2:4]
+		if first[0] < 0 {
+			first = loc[4:6]
+		}
+		r.Season = atoi(p.name[first[0]:first[1]])
+		r.Seasons = expandRange([]int{r.Season}, atoi(p.name[loc[6]:loc[7]]))
+		return
+	}
+
+	if loc := p.find(seasonOnlyPattern); loc != nil {
+		r.Season = atoi(p.name[loc[2]:loc[3]])
+	}
+}
+
+// parseYear picks the last plausible year before the other metadata, so
+// titles like "2001 A Space Odyssey 1968" keep their leading number
+func (p *parser) parseYear() int {
+	var year int
+	var yearLoc []int
+	for _, loc := range yearPattern.FindAllStringSubmatchIndex(p.name, -1) {
+		if loc[0] <= p.start || p.overlaps(loc[0], loc[1]) {
+			continue
+		}
+		if loc[0] > p.end && p.end < len(p.name) {
+			break
+		}
+		if n := atoi(p.name[loc[2]:loc[3]]); isYear(n) {
+			year, yearLoc = n, loc
+		}
+	}
+	if yearLoc != nil {
+		p.mark(yearLoc[0], yearLoc[1])
+	}
+	return year
+}
+
+// label returns the value of the first pattern that matches
+func (p *parser) label(patterns []labelledPattern) string {
+	for _, pattern := range patterns {
+		loc := p.find(pattern.re)
+		if loc == nil {
+			continue
+		}
+		if pattern.re.NumSubexp() == 0 {
+			return pattern.label
+		}
+		return fmt.Sprintf(pattern.label, strings.ToLower(p.name[loc[2]:loc[3]]))
+	}
+	return ""
+}
+
+// channels looks up the channel layout. Unlike the other fields it may
+// overlap the audio codec, since the two are usually glued together ("DDP5.1")
+func (p *parser) channels() string {
+	for _, pattern := range channelPatterns {
+		loc := pattern.re.FindStringSubmatchIndex(p.name)
+		if loc == nil || loc[0] < p.start {
+			continue
+		}
+		p.mark(loc[0], loc[1])
+		value := fmt.Sprintf(pattern.label, strings.ToLower(p.name[loc[2]:loc[3]]))
+		if count, ok := channelsByCount[value]; ok {
+			return count
+		}
+		return value
+	}
+	return ""
+}
+
+// find returns the submatch indexes of the first match of re that isn't part
+// of metadata recognised earlier, and marks it as metadata
+func (p *parser) find(re *regexp.Regexp) []int {
+	for _, loc := range re.FindAllStringSubmatchIndex(p.name, -1) {
+		if loc[0] < p.start || p.overlaps(loc[0], loc[1]) {
+			continue
+		}
+		p.mark(loc[0], loc[1])
+		return loc
+	}
+	return nil
+}
+
+func (p *parser) mark(start, end int) {
+	p.spans = append(p.spans, [2]int{start, end})
+	if start >= p.start && start < p.end {
+		p.end = start
+	}
+}
+
+func (p *parser) overlaps(start, end int) bool {
+	for _, span := range p.spans {
+		if start < span[1] && span[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+// expandRange turns S01E02-05 into episodes 2 through 5, and S01-S03 into
+// seasons 1 through 3
+func expandRange(episodes []int, last int) []int {
+	first := episodes[len(episodes)-1]
+	if last <= first || last-first > maxEpisodeRange {
+		return episodes
+	}
+	for n := first + 1; n <= last; n++ {
+		episodes = append(episodes, n)
+	}
+	return episodes
+}
+
+func cleanTitle(title string) string {
+	title = strings.NewReplacer(".", " ", "_", " ").Replace(title)
+	title = strings.Join(strings.Fields(title), " ")
+	return strings.Trim(title, " -[](){}")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isYear(n int) bool {
+	return n >= 1900 && n <= time.Now().Year()+1
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// String formats the release the way it would appear in a scene name,
+// mostly useful in logs
+func (r *Release) String() string {
+	parts := []string{r.Title}
+	if r.Year != 0 {
+		parts = append(parts, strconv.Itoa(r.Year))
+	}
+	if r.IsEpisode() {
+		episode := fmt.Sprintf("S%02d", r.Season)
+		for _, n := range r.Episodes {
+			episode += fmt.Sprintf("E%02d", n)
+		}
+		parts = append(parts, episode)
+	} else if r.Absolute != 0 {
+		parts = append(parts, strconv.Itoa(r.Absolute))
+	}
+	for _, field := range []string{r.Date, r.Resolution, r.Source, r.Codec, strings.Join(r.HDR, " "), r.Audio, r.Channels, r.Edition} {
+		if field != "" {
+			parts = append(parts, field)
+		}
+	}
+	s := strings.Join(parts, " ")
+	if r.Group != "" {
+		s += "-" + r.Group
+	}
+	return s
+}
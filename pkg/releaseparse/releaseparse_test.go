@@ -0,0 +1,214 @@
+package releaseparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		want Release
+	}{
+		// Multi-episode files
+		{"Show.Name.S01E01E02.1080p.WEB-DL.DDP5.1.H.264-NTb.mkv", Release{
+			Title: "Show Name", Season: 1, Episodes: []int{1, 2},
+			Resolution: "1080p", Source: SourceWebDL, Codec: CodecH264, Audio: "DDP", Channels: "5.1", Group: "NTb",
+		}},
+		{"Show.Name.S01E01.E02.E03.720p.HDTV.x264-GRP.mkv", Release{
+			Title: "Show Name", Season: 1, Episodes: []int{1, 2, 3},
+			Resolution: "720p", Source: SourceHDTV, Codec: CodecH264, Group: "GRP",
+		}},
+		{"Show.Name.S02E03-E05.720p.HDTV.x264-KILLERS.mkv", Release{
+			Title: "Show Name", Season: 2, Episodes: []int{3, 4, 5},
+			Resolution: "720p", Source: SourceHDTV, Codec: CodecH264, Group: "KILLERS",
+		}},
+		{"Show.Name.S02E03-05.720p.HDTV.x264-KILLERS.mkv", Release{
+			Title: "Show Name", Season: 2, Episodes: []int{3, 4, 5},
+			Resolution: "720p", Source: SourceHDTV, Codec: CodecH264, Group: "KILLERS",
+		}},
+		{"Show_Name_S01E02_1080p_WEB-DL_AAC2.0_H.264-GRP.mkv", Release{
+			Title: "Show Name", Season: 1, Episodes: []int{2},
+			Resolution: "1080p", Source: SourceWebDL, Codec: CodecH264, Audio: "AAC", Channels: "2.0", Group: "GRP",
+		}},
+		{"Show Name S00E04 Behind the Scenes 1080p.mkv", Release{
+			Title: "Show Name", Season: 0, Episodes: []int{4}, Resolution: "1080p",
+		}},
+
+		// NxNN notation
+		{"show.name.1x05.hdtv.xvid-lol.avi", Release{
+			Title: "show name", Season: 1, Episodes: []int{5}, Source: SourceHDTV, Codec: CodecXviD, Group: "lol",
+		}},
+		{"Show Name 3x10x11 720p.mkv", Release{
+			Title: "Show Name", Season: 3, Episodes: []int{10, 11}, Resolution: "720p",
+		}},
+
+		// Anime absolute numbering
+		{"[SubsPlease] One Piece - 1071 (1080p) [ABCDEF12].mkv", Release{
+			Title: "One Piece", Absolute: 1071, Resolution: "1080p", Group: "SubsPlease",
+		}},
+		{"[Erai-raws] Jujutsu Kaisen - 24v2 [1080p][Multiple Subtitle].mkv", Release{
+			Title: "Jujutsu Kaisen", Absolute: 24, Resolution: "1080p", Group: "Erai-raws",
+		}},
+		{"Naruto Shippuden - 500 [720p].mkv", Release{
+			Title: "Naruto Shippuden", Absolute: 500, Resolution: "720p",
+		}},
+
+		// Dated episodes of daily shows
+		{"The.Daily.Show.2023.05.14.720p.WEB.h264-BAE.mkv", Release{
+			Title: "The Daily Show", Date: "2023-05-14", Resolution: "720p", Source: SourceWeb, Codec: CodecH264, Group: "BAE",
+		}},
+		{"Late Night 2024-02-29 1080p WEB-DL.mkv", Release{
+			Title: "Late Night", Date: "2024-02-29", Resolution: "1080p", Source: SourceWebDL,
+		}},
+
+		// Season packs and season ranges
+		{"Breaking.Bad.S03.1080p.BluRay.x264-DEMAND", Release{
+			Title: "Breaking Bad", Season: 3, Resolution: "1080p", Source: SourceBluRay, Codec: CodecH264, Group: "DEMAND",
+		}},
+		{"The.Office.US.S01-S09.1080p.BluRay.x265-RARBG", Release{
+			Title: "The Office US", Season: 1, Seasons: []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			Resolution: "1080p", Source: SourceBluRay, Codec: CodecHEVC, Group: "RARBG",
+		}},
+		{"Friends.Season.1-10.Complete.720p.BluRay.x264", Release{
+			Title: "Friends", Season: 1, Seasons: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			Resolution: "720p", Source: SourceBluRay, Codec: CodecH264,
+		}},
+
+		// REMUX, HDR and Dolby Vision
+		{"Dune.Part.Two.2024.2160p.UHD.BluRay.REMUX.DV.HDR.HEVC.TrueHD.Atmos.7.1-FGT.mkv", Release{
+			Title: "Dune Part Two", Year: 2024, Resolution: "2160p", Source: SourceRemux, Codec: CodecHEVC,
+			HDR: []string{HDRDolbyVision, HDRGeneric}, Audio: "TrueHD Atmos", Channels: "7.1", Group: "FGT",
+		}},
+		{"Movie.2019.2160p.WEB-DL.DDP5.1.Atmos.DV.HDR10.HEVC-GRP.mkv", Release{
+			Title: "Movie", Year: 2019, Resolution: "2160p", Source: SourceWebDL, Codec: CodecHEVC,
+			HDR: []string{HDRDolbyVision, HDR10}, Audio: "DDP Atmos", Channels: "5.1", Group: "GRP",
+		}},
+		{"Movie.2020.2160p.WEB-DL.HDR10+.HEVC-GRP.mkv", Release{
+			Title: "Movie", Year: 2020, Resolution: "2160p", Source: SourceWebDL, Codec: CodecHEVC,
+			HDR: []string{HDR10Plus}, Group: "GRP",
+		}},
+		{"Movie.2020.2160p.BluRay.HDR.x265-GRP.mkv", Release{
+			Title: "Movie", Year: 2020, Resolution: "2160p", Source: SourceBluRay, Codec: CodecHEVC,
+			HDR: []string{HDRGeneric}, Group: "GRP",
+		}},
+		{"Movie.2021.2160p.WEB.HLG.HEVC-GRP.mkv", Release{
+			Title: "Movie", Year: 2021, Resolution: "2160p", Source: SourceWeb, Codec: CodecHEVC,
+			HDR: []string{HDRHLG}, Group: "GRP",
+		}},
+		{"Movie.2022.1080p.BDREMUX.AVC.DTS-HD.MA.5.1-GRP.mkv", Release{
+			Title: "Movie", Year: 2022, Resolution: "1080p", Source: SourceRemux, Codec: CodecH264,
+			Audio: "DTS-HD MA", Channels: "5.1", Group: "GRP",
+		}},
+
+		// Audio codecs and channels
+		{"Movie.2018.1080p.BluRay.DTS-HD.MA.5.1.x264-GRP.mkv", Release{
+			Title: "Movie", Year: 2018, Resolution: "1080p", Source: SourceBluRay, Codec: CodecH264,
+			Audio: "DTS-HD MA", Channels: "5.1", Group: "GRP",
+		}},
+		{"Movie.2018.1080p.BluRay.AAC2.0.x264-GRP.mkv", Release{
+			Title: "Movie", Year: 2018, Resolution: "1080p", Source: SourceBluRay, Codec: CodecH264,
+			Audio: "AAC", Channels: "2.0", Group: "GRP",
+		}},
+		{"Movie.2018.720p.WEBRip.6CH.x265-PSA.mkv", Release{
+			Title: "Movie", Year: 2018, Resolution: "720p", Source: SourceWebRip, Codec: CodecHEVC,
+			Channels: "5.1", Group: "PSA",
+		}},
+		{"Movie.2018.1080p.WEB.DD5.1.H264-GRP.mkv", Release{
+			Title: "Movie", Year: 2018, Resolution: "1080p", Source: SourceWeb, Codec: CodecH264,
+			Audio: "DD", Channels: "5.1", Group: "GRP",
+		}},
+		{"Movie.2018.1080p.BluRay.FLAC.2.0.x264-GRP.mkv", Release{
+			Title: "Movie", Year: 2018, Resolution: "1080p", Source: SourceBluRay, Codec: CodecH264,
+			Audio: "FLAC", Channels: "2.0", Group: "GRP",
+		}},
+		{"Movie.2017.2160p.UHD.BluRay.x265.DTS-X.7.1-GRP.mkv", Release{
+			Title: "Movie", Year: 2017, Resolution: "2160p", Source: SourceBluRay, Codec: CodecHEVC,
+			Audio: "DTS:X", Channels: "7.1", Group: "GRP",
+		}},
+
+		// Release groups
+		{"Movie.2019.1080p.AMZN.WEB-DL.DDP5.1.H.264-[GRP].mkv", Release{
+			Title: "Movie", Year: 2019, Resolution: "1080p", Source: SourceWebDL, Codec: CodecH264,
+			Audio: "DDP", Channels: "5.1", Group: "GRP",
+		}},
+		{"Blade Runner 2049 (2017) 1080p BluRay x265 10bit-Tigole.mkv", Release{
+			Title: "Blade Runner 2049", Year: 2017, Resolution: "1080p", Source: SourceBluRay, Codec: CodecHEVC, Group: "Tigole",
+		}},
+		{"Spider-Man.2002.1080p.mkv", Release{
+			Title: "Spider-Man", Year: 2002, Resolution: "1080p",
+		}},
+		{"Spider-Man.No.Way.Home.2021.1080p.WEBRip.x264.AAC5.1-YTS.mkv", Release{
+			Title: "Spider-Man No Way Home", Year: 2021, Resolution: "1080p", Source: SourceWebRip, Codec: CodecH264,
+			Audio: "AAC", Channels: "5.1", Group: "YTS",
+		}},
+
+		// Editions
+		{"Blade.Runner.1982.Directors.Cut.1080p.BluRay.x264-GRP.mkv", Release{
+			Title: "Blade Runner", Year: 1982, Resolution: "1080p", Source: SourceBluRay, Codec: CodecH264,
+			Group: "GRP", Edition: "Director's Cut",
+		}},
+		{"The.Lord.of.the.Rings.2001.Extended.Edition.1080p.BluRay.x264-GRP.mkv", Release{
+			Title: "The Lord of the Rings", Year: 2001, Resolution: "1080p", Source: SourceBluRay, Codec: CodecH264,
+			Group: "GRP", Edition: "Extended",
+		}},
+		{"Aliens.1986.Theatrical.Cut.720p.BluRay.x264.mkv", Release{
+			Title: "Aliens", Year: 1986, Resolution: "720p", Source: SourceBluRay, Codec: CodecH264, Edition: "Theatrical",
+		}},
+		{"Movie.2019.Criterion.1080p.BluRay.x264-GRP", Release{
+			Title: "Movie", Year: 2019, Resolution: "1080p", Source: SourceBluRay, Codec: CodecH264,
+			Group: "GRP", Edition: "Criterion",
+		}},
+
+		// Titles with numbers in them, and names without any metadata
+		{"2001.A.Space.Odyssey.1968.1080p.BluRay.x264-GRP.mkv", Release{
+			Title: "2001 A Space Odyssey", Year: 1968, Resolution: "1080p", Source: SourceBluRay, Codec: CodecH264, Group: "GRP",
+		}},
+		{"just a home video.mp4", Release{Title: "just a home video"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.name)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Parse(%q)\n got %+v\nwant %+v", tt.name, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseEpisode(t *testing.T) {
+	tests := []struct {
+		name        string
+		wantEpisode bool
+		want        int
+	}{
+		{"Show.S01E05.mkv", true, 5},
+		{"Show.S01E05E06.mkv", true, 5},
+		{"Show.S01.1080p.mkv", false, 0},
+		{"[Group] Show - 12 [1080p].mkv", false, 0},
+		{"Movie.2019.1080p.mkv", false, 0},
+	}
+	for _, tt := range tests {
+		release := Parse(tt.name)
+		if release.IsEpisode() != tt.wantEpisode || release.Episode() != tt.want {
+			t.Errorf("Parse(%q): IsEpisode() = %v, Episode() = %d, want %v, %d",
+				tt.name, release.IsEpisode(), release.Episode(), tt.wantEpisode, tt.want)
+		}
+	}
+}
+
+func TestReleaseString(t *testing.T) {
+	tests := map[string]string{
+		"Show.Name.S01E01E02.1080p.WEB-DL.DDP5.1.H.264-NTb.mkv":        "Show Name S01E01E02 1080p WEB-DL H.264 DDP 5.1-NTb",
+		"Dune.Part.Two.2024.2160p.UHD.BluRay.REMUX.DV.HDR.HEVC-FGT":    "Dune Part Two 2024 2160p REMUX HEVC DV HDR-FGT",
+		"[SubsPlease] One Piece - 1071 (1080p) [ABCDEF12].mkv":         "One Piece 1071 1080p-SubsPlease",
+		"The.Daily.Show.2023.05.14.720p.WEB.h264-BAE.mkv":              "The Daily Show 2023-05-14 720p WEB H.264-BAE",
+		"The.Lord.of.the.Rings.2001.Extended.Edition.1080p.BluRay.mkv": "The Lord of the Rings 2001 1080p BluRay Extended",
+	}
+	for name, want := range tests {
+		if got := Parse(name).String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", name, got, want)
+		}
+	}
+}
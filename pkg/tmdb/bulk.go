@@ -0,0 +1,320 @@
+package tmdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultWorkers is how many titles a bulk sync processes at once. The client's
+// rate limiter, not the worker count, bounds the request rate.
+const DefaultWorkers = 4
+
+// SyncSummary counts the outcomes of a bulk sync
+type SyncSummary struct {
+	Matched     int64 // newly matched to a TMDB entry
+	NeedsReview int64 // newly matched, but with low confidence or ambiguously
+	Updated     int64 // already matched, details refreshed
+	Unmatched   int64 // TMDB had no good match
+	Errored     int64
+	Skipped     int64 // unchanged since the last sync, not processed
+}
+
+// Total is the number of titles processed
+func (s SyncSummary) Total() int64 {
+	return s.Matched + s.NeedsReview + s.Updated + s.Unmatched + s.Errored
+}
+
+func (s SyncSummary) String() string {
+	str := fmt.Sprintf("%d processed: %d matched, %d need review, %d updated, %d unmatched, %d errored",
+		s.Total(), s.Matched, s.NeedsReview, s.Updated, s.Unmatched, s.Errored)
+	if s.Skipped > 0 {
+		str += fmt.Sprintf(", %d unchanged skipped", s.Skipped)
+	}
+	return str
+}
+
+// Names of the sync states holding the watermarks of the bulk syncs
+const (
+	movieSyncState = "tmdb_movies"
+	tvSyncState    = "tmdb_tv"
+)
+
+// WithWorkers sets how many titles the bulk syncs process concurrently
+func (s *SyncService) WithWorkers(workers int) *SyncService {
+	if workers < 1 {
+		workers = 1
+	}
+	s.workers = workers
+	return s
+}
+
+// SyncAllMovies streams every movie in the database through the worker pool.
+// When every movie synced, it records the start of the run as the watermark
+// SyncChangedMovies continues from.
+func (s *SyncService) SyncAllMovies(ctx context.Context) (SyncSummary, error) {
+	start := time.Now()
+	summary, err := runSync(ctx, s.workers, "movie", s.repo.IterateMovies, s.syncMovieOutcome)
+	return summary, s.advanceWatermark(ctx, movieSyncState, start, summary, err)
+}
+
+// SyncAllTV streams every TV show in the database through the worker pool and
+// records the watermark like SyncAllMovies
+func (s *SyncService) SyncAllTV(ctx context.Context) (SyncSummary, error) {
+	start := time.Now()
+	summary, err := runSync(ctx, s.workers, "TV show", s.repo.IterateTVShows, s.syncTVOutcome)
+	return summary, s.advanceWatermark(ctx, tvSyncState, start, summary, err)
+}
+
+// SyncChangedMovies syncs only the movies TMDB lists as changed since the
+// watermark, plus the movies that were never synced. Without a watermark it
+// falls back to SyncAllMovies.
+func (s *SyncService) SyncChangedMovies(ctx context.Context) (SyncSummary, error) {
+	start := time.Now()
+	since, ok, err := s.watermark(ctx, movieSyncState)
+	if err != nil {
+		return SyncSummary{}, err
+	}
+	if !ok {
+		log.Println("No movie sync watermark yet, syncing all movies")
+		return s.SyncAllMovies(ctx)
+	}
+
+	changed, err := s.tmdbClient.ChangedMovieIDs(ctx, since, start)
+	if err != nil {
+		return SyncSummary{}, err
+	}
+	log.Printf("%d movies changed on TMDB since %s", len(changed), since.Format(time.RFC3339))
+
+	var skipped int64
+	iterate := func(ctx context.Context, fn func(*models.Movie) error) error {
+		return s.repo.IterateMovies(ctx, func(movie *models.Movie) error {
+			if !needsSync(movie.TMDBID, movie.Match, changed) {
+				skipped++
+				return nil
+			}
+			return fn(movie)
+		})
+	}
+	summary, err := runSync(ctx, s.workers, "movie", iterate, s.syncMovieOutcome)
+	summary.Skipped = skipped
+	return summary, s.advanceWatermark(ctx, movieSyncState, start, summary, err)
+}
+
+// SyncChangedTV syncs only the TV shows TMDB lists as changed since the
+// watermark, plus the shows that were never synced, like SyncChangedMovies
+func (s *SyncService) SyncChangedTV(ctx context.Context) (SyncSummary, error) {
+	start := time.Now()
+	since, ok, err := s.watermark(ctx, tvSyncState)
+	if err != nil {
+		return SyncSummary{}, err
+	}
+	if !ok {
+		log.Println("No TV sync watermark yet, syncing all TV shows")
+		return s.SyncAllTV(ctx)
+	}
+
+	changed, err := s.tmdbClient.ChangedTVIDs(ctx, since, start)
+	if err != nil {
+		return SyncSummary{}, err
+	}
+	log.Printf("%d TV shows changed on TMDB since %s", len(changed), since.Format(time.RFC3339))
+
+	var skipped int64
+	iterate := func(ctx context.Context, fn func(*models.TV) error) error {
+		return s.repo.IterateTVShows(ctx, func(tv *models.TV) error {
+			if !needsSync(tv.TMDBID, tv.Match, changed) {
+				skipped++
+				return nil
+			}
+			return fn(tv)
+		})
+	}
+	summary, err := runSync(ctx, s.workers, "TV show", iterate, s.syncTVOutcome)
+	summary.Skipped = skipped
+	return summary, s.advanceWatermark(ctx, tvSyncState, start, summary, err)
+}
+
+// needsSync picks the titles an incremental sync processes: those never
+// synced, which have neither a TMDB ID nor a match record, and those whose
+// TMDB entry changed
+func needsSync(tmdbID int, match *models.Match, changed map[int]bool) bool {
+	if tmdbID == 0 {
+		return match == nil
+	}
+	return changed[tmdbID]
+}
+
+// watermark returns when the last successful sync under name started
+func (s *SyncService) watermark(ctx context.Context, name string) (time.Time, bool, error) {
+	state, err := s.repo.GetSyncState(ctx, name)
+	if errors.Is(err, repository.ErrNotFound) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to read sync watermark: %w", err)
+	}
+	return state.Watermark.Time(), true, nil
+}
+
+// advanceWatermark moves the watermark under name to start, unless the run
+// stopped early or some titles failed: those have to be picked up again by
+// the next incremental sync. It passes runErr on.
+func (s *SyncService) advanceWatermark(ctx context.Context, name string, start time.Time, summary SyncSummary, runErr error) error {
+	if runErr != nil {
+		return runErr
+	}
+	if summary.Errored > 0 {
+		log.Printf("Keeping the %s sync watermark, %d titles failed", name, summary.Errored)
+		return nil
+	}
+	state := &models.SyncState{Name: name, Watermark: primitive.NewDateTimeFromTime(start)}
+	if err := s.repo.SaveSyncState(ctx, state); err != nil {
+		return fmt.Errorf("failed to save sync watermark: %w", err)
+	}
+	return nil
+}
+
+// SyncMovies syncs the given movies through the worker pool
+func (s *SyncService) SyncMovies(ctx context.Context, movies []models.Movie) (SyncSummary, error) {
+	iterate := func(ctx context.Context, fn func(*models.Movie) error) error {
+		for i := range movies {
+			if err := fn(&movies[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return runSync(ctx, s.workers, "movie", iterate, s.syncMovieOutcome)
+}
+
+// SyncTVShows syncs the given TV shows through the worker pool. The shows
+// must be full documents, since each is saved back as a whole.
+func (s *SyncService) SyncTVShows(ctx context.Context, tvShows []models.TV) (SyncSummary, error) {
+	iterate := func(ctx context.Context, fn func(*models.TV) error) error {
+		for i := range tvShows {
+			if err := fn(&tvShows[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return runSync(ctx, s.workers, "TV show", iterate, s.syncTVOutcome)
+}
+
+type outcome int
+
+const (
+	outcomeMatched outcome = iota
+	outcomeNeedsReview
+	outcomeUpdated
+	outcomeUnmatched
+	outcomeErrored
+)
+
+func (s *SyncService) syncMovieOutcome(ctx context.Context, movie *models.Movie) outcome {
+	previousID := movie.TMDBID
+	err := s.SyncMovie(ctx, movie)
+	if err != nil {
+		log.Printf("Error syncing movie '%s': %v", movie.Title, err)
+	}
+	return classify(err, previousID, movie.TMDBID, movie.Match)
+}
+
+func (s *SyncService) syncTVOutcome(ctx context.Context, tv *models.TV) outcome {
+	previousID := tv.TMDBID
+	err := s.SyncTV(ctx, tv)
+	if err != nil {
+		log.Printf("Error syncing TV show '%s': %v", tv.Title, err)
+	}
+	return classify(err, previousID, tv.TMDBID, tv.Match)
+}
+
+func classify(err error, previousID, tmdbID int, match *models.Match) outcome {
+	switch {
+	case errors.Is(err, ErrNoMatch):
+		return outcomeUnmatched
+	case err != nil:
+		return outcomeErrored
+	case previousID != 0 && previousID == tmdbID:
+		return outcomeUpdated
+	case match != nil && match.Status == models.MatchNeedsReview:
+		return outcomeNeedsReview
+	default:
+		return outcomeMatched
+	}
+}
+
+// runSync feeds the titles iterate yields to a pool of workers and counts the
+// outcomes. The channel between them is small, so the iteration only reads
+// ahead as fast as the workers keep up. A cancelled ctx stops the iteration;
+// titles already queued are skipped.
+func runSync[T any](
+	ctx context.Context,
+	workers int,
+	kind string,
+	iterate func(context.Context, func(*T) error) error,
+	syncOne func(context.Context, *T) outcome,
+) (SyncSummary, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		summary SyncSummary
+		queued  int64
+		wg      sync.WaitGroup
+	)
+	counters := [...]*int64{
+		outcomeMatched:     &summary.Matched,
+		outcomeNeedsReview: &summary.NeedsReview,
+		outcomeUpdated:     &summary.Updated,
+		outcomeUnmatched:   &summary.Unmatched,
+		outcomeErrored:     &summary.Errored,
+	}
+
+	jobs := make(chan *T, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				atomic.AddInt64(counters[syncOne(ctx, item)], 1)
+			}
+		}()
+	}
+
+	err := iterate(ctx, func(item *T) error {
+		n := atomic.AddInt64(&queued, 1)
+		if n%100 == 0 {
+			log.Printf("Queued %d %ss for sync", n, kind)
+		}
+		select {
+		case jobs <- item:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(jobs)
+	wg.Wait()
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return summary, fmt.Errorf("%s sync stopped after %d: %w", kind, summary.Total(), err)
+	}
+	return summary, nil
+}
@@ -0,0 +1,163 @@
+package tmdb
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CacheTTLs says how long cached responses stay fresh, per resource type. A
+// TTL of 0 disables caching for that type.
+type CacheTTLs struct {
+	Find    time.Duration // external ID lookups
+	Search  time.Duration
+	Movie   time.Duration
+	TV      time.Duration
+	Season  time.Duration
+	Episode time.Duration
+}
+
+// DefaultCacheTTLs keeps TV data, which changes while a series airs, for a
+// day, external ID lookups, which hardly ever change, for a month and
+// everything else for a week
+var DefaultCacheTTLs = CacheTTLs{
+	Find:    30 * 24 * time.Hour,
+	Search:  7 * 24 * time.Hour,
+	Movie:   7 * 24 * time.Hour,
+	TV:      24 * time.Hour,
+	Season:  24 * time.Hour,
+	Episode: 7 * 24 * time.Hour,
+}
+
+// forEndpoint maps the endpoint names used in Stats to their TTL
+func (t CacheTTLs) forEndpoint(name string) time.Duration {
+	switch name {
+	case "find":
+		return t.Find
+	case "search/movie", "search/tv":
+		return t.Search
+	case "movie":
+		return t.Movie
+	case "tv", "tv/episode_groups", "tv/episode_group":
+		return t.TV
+	case "tv/season":
+		return t.Season
+	case "tv/episode":
+		return t.Episode
+	}
+	return 0
+}
+
+// Cache stores TMDB responses on disk, one JSON file per request under a
+// directory per endpoint, so re-syncs of unchanged titles don't hit TMDB.
+// Entries expire by the TTL in effect when they're read, so lowering a TTL
+// applies to responses already cached.
+type Cache struct {
+	dir      string
+	ttls     CacheTTLs
+	refresh  bool
+	readOnly bool
+}
+
+// cacheEntry is a cached response as stored on disk
+type cacheEntry struct {
+	URL       string          `json:"url"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// NewCache creates a cache in dir. With refresh set, cached responses are
+// never served but fresh ones are still stored, which rebuilds the cache.
+func NewCache(dir string, ttls CacheTTLs, refresh bool) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create TMDB cache directory: %w", err)
+	}
+	return &Cache{dir: dir, ttls: ttls, refresh: refresh}, nil
+}
+
+// NewReadOnlyCache serves the responses cached in dir but never stores new
+// ones, for dry runs. The directory doesn't have to exist.
+func NewReadOnlyCache(dir string, ttls CacheTTLs) *Cache {
+	return &Cache{dir: dir, ttls: ttls, readOnly: true}
+}
+
+// get returns the cached body for rawURL if it's still fresh
+func (c *Cache) get(name, rawURL string) ([]byte, bool) {
+	ttl := c.ttls.forEndpoint(name)
+	if c.refresh || ttl <= 0 {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(name, rawURL))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	if time.Since(entry.FetchedAt) > ttl {
+		return nil, false
+	}
+	return entry.Body, true
+}
+
+// put stores body for rawURL. The file is written under a temporary name and
+// renamed, so concurrent workers never read half an entry.
+func (c *Cache) put(name, rawURL string, body []byte) error {
+	if c.readOnly || c.ttls.forEndpoint(name) <= 0 {
+		return nil
+	}
+
+	path := c.path(name, rawURL)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cacheEntry{
+		URL:       redactAPIKey(rawURL),
+		FetchedAt: time.Now(),
+		Body:      body,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// path keys an entry by endpoint and the request URL without the API key, so
+// changing keys doesn't invalidate the cache
+func (c *Cache) path(name, rawURL string) string {
+	sum := sha1.Sum([]byte(redactAPIKey(rawURL)))
+	return filepath.Join(c.dir, strings.ReplaceAll(name, "/", "_"), hex.EncodeToString(sum[:])+".json")
+}
+
+func redactAPIKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	query.Del("api_key")
+	u.RawQuery = query.Encode()
+	return u.String()
+}
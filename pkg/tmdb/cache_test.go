@@ -0,0 +1,179 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const cachedURL = "https://api.themoviedb.org/3/movie/603?api_key=secret&language=en-US"
+
+// backdate moves the fetch time of a cached entry into the past
+func backdate(t *testing.T, cache *Cache, name, rawURL string, age time.Duration) {
+	t.Helper()
+	path := cache.path(name, rawURL)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.FetchedAt = time.Now().Add(-age)
+	if data, err = json.Marshal(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	ttls := CacheTTLs{Movie: time.Hour}
+	tests := []struct {
+		name  string
+		entry string
+		age   time.Duration
+		fresh bool
+	}{
+		{"fresh", "movie", time.Minute, true},
+		{"expired", "movie", 2 * time.Hour, false},
+		{"zero TTL isn't cached", "tv", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := NewCache(t.TempDir(), ttls, false)
+			if err != nil {
+				t.Fatalf("NewCache: %v", err)
+			}
+			if err := cache.put(tt.entry, cachedURL, []byte(`{"id":603}`)); err != nil {
+				t.Fatalf("put: %v", err)
+			}
+			if tt.age > 0 {
+				backdate(t, cache, tt.entry, cachedURL, tt.age)
+			}
+
+			body, ok := cache.get(tt.entry, cachedURL)
+			if ok != tt.fresh {
+				t.Fatalf("got hit %v, want %v", ok, tt.fresh)
+			}
+			if ok && string(body) != `{"id":603}` {
+				t.Errorf("got body %s", body)
+			}
+		})
+	}
+}
+
+func TestCacheLowerTTLAppliesToCachedEntries(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(dir, CacheTTLs{Movie: 24 * time.Hour}, false)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	if err := cache.put("movie", cachedURL, []byte(`{}`)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	backdate(t, cache, "movie", cachedURL, 2*time.Hour)
+
+	shorter, err := NewCache(dir, CacheTTLs{Movie: time.Hour}, false)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	if _, ok := shorter.get("movie", cachedURL); ok {
+		t.Error("entry older than the new TTL was served")
+	}
+}
+
+func TestCacheIgnoresAPIKey(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), DefaultCacheTTLs, false)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	if err := cache.put("movie", cachedURL, []byte(`{}`)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	if _, ok := cache.get("movie", strings.Replace(cachedURL, "secret", "other", 1)); !ok {
+		t.Error("changing the API key invalidated the entry")
+	}
+	data, err := os.ReadFile(cache.path("movie", cachedURL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("API key stored in the cache: %s", data)
+	}
+}
+
+func TestCacheRefreshWritesButNeverReads(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), DefaultCacheTTLs, true)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	if err := cache.put("movie", cachedURL, []byte(`{}`)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, ok := cache.get("movie", cachedURL); ok {
+		t.Error("refreshing cache served an entry")
+	}
+	if _, err := os.Stat(cache.path("movie", cachedURL)); err != nil {
+		t.Errorf("refreshing cache didn't store the entry: %v", err)
+	}
+}
+
+func TestReadOnlyCache(t *testing.T) {
+	dir := t.TempDir()
+	writable, err := NewCache(dir, DefaultCacheTTLs, false)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	if err := writable.put("movie", cachedURL, []byte(`{}`)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	cache := NewReadOnlyCache(dir, DefaultCacheTTLs)
+	if _, ok := cache.get("movie", cachedURL); !ok {
+		t.Error("read-only cache didn't serve the stored entry")
+	}
+	if err := cache.put("tv", cachedURL, []byte(`{}`)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tv")); !os.IsNotExist(err) {
+		t.Errorf("read-only cache wrote an entry (stat error %v)", err)
+	}
+
+	// Dry runs may point at a cache that was never created
+	missing := filepath.Join(dir, "missing")
+	if err := NewReadOnlyCache(missing, DefaultCacheTTLs).put("movie", cachedURL, []byte(`{}`)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("read-only cache created its directory (stat error %v)", err)
+	}
+}
+
+func TestClientServesCachedResponses(t *testing.T) {
+	client, requests := newTestClient(t, nil, nil)
+	cache, err := NewCache(t.TempDir(), DefaultCacheTTLs, false)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	client.WithCache(cache)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.SearchMovie(context.Background(), "The Matrix"); err != nil {
+			t.Fatalf("SearchMovie: %v", err)
+		}
+	}
+	if *requests != 1 {
+		t.Errorf("got %d requests, want 1", *requests)
+	}
+	if stats := client.Stats()["search/movie"]; stats.CacheHits != 1 || stats.Requests != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,49 @@
+package tmdb
+
+import (
+	"context"
+	"time"
+)
+
+// maxChangesWindow is the longest period TMDB returns changes for in one
+// request
+const maxChangesWindow = 14 * 24 * time.Hour
+
+// ChangedMovieIDs collects the TMDB IDs of every movie changed between since
+// and until. Longer periods are split into 14 day windows.
+func (c *Client) ChangedMovieIDs(ctx context.Context, since, until time.Time) (map[int]bool, error) {
+	return collectChanges(ctx, c.GetMovieChanges, since, until)
+}
+
+// ChangedTVIDs collects the TMDB IDs of every TV show changed between since
+// and until, like ChangedMovieIDs
+func (c *Client) ChangedTVIDs(ctx context.Context, since, until time.Time) (map[int]bool, error) {
+	return collectChanges(ctx, c.GetTVChanges, since, until)
+}
+
+func collectChanges(
+	ctx context.Context,
+	fetch func(ctx context.Context, start, end time.Time, page int) (*ChangesResponse, error),
+	since, until time.Time,
+) (map[int]bool, error) {
+	changed := make(map[int]bool)
+	for start := since; start.Before(until); start = start.Add(maxChangesWindow) {
+		end := start.Add(maxChangesWindow)
+		if end.After(until) {
+			end = until
+		}
+		for page := 1; ; page++ {
+			resp, err := fetch(ctx, start, end, page)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range resp.Results {
+				changed[entry.ID] = true
+			}
+			if page >= resp.TotalPages {
+				break
+			}
+		}
+	}
+	return changed, nil
+}
@@ -1,20 +1,58 @@
 package tmdb
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
 )
 
-// Client represents a TMDB API client
+// Client represents a TMDB API client. It is safe for concurrent use; all
+// requests share one rate limiter.
 type Client struct {
 	apiKey     string
 	baseURL    string
 	httpClient *http.Client
+	limiter    *Limiter
+	cache      *Cache
+	languages  []string
+
+	maxRetries int
+	backoff    time.Duration // delay before the first retry, doubled on every further one
+	statsMu    sync.Mutex
+	stats      map[string]*EndpointStats
+}
+
+// DefaultBaseURL is the TMDB v3 API root
+const DefaultBaseURL = "https://api.themoviedb.org/3"
+
+// Retry defaults for 429 and 5xx responses
+const (
+	DefaultMaxRetries = 5
+	DefaultBackoff    = 1 * time.Second
+	maxBackoff        = 30 * time.Second
+)
+
+// EndpointStats counts the requests made to one endpoint
+type EndpointStats struct {
+	Requests    int64 // every attempt, retries included
+	Retries     int64
+	RateLimited int64 // 429 responses
+	Failures    int64 // calls that gave up with an error
+	CacheHits   int64 // calls served from the cache, not counted in Requests
 }
 
 // NewClient creates a new TMDB API client
@@ -24,153 +62,365 @@ func NewClient() (*Client, error) {
 		return nil, fmt.Errorf("TMDB_API_KEY environment variable is not set")
 	}
 
+	return NewClientWith(apiKey, DefaultBaseURL, &http.Client{
+		Timeout: 10 * time.Second,
+	}), nil
+}
+
+// NewClientWith creates a TMDB API client with an explicit base URL and HTTP
+// client, e.g. to record responses or talk to a test server
+func NewClientWith(apiKey, baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
 	return &Client{
-		apiKey:  apiKey,
-		baseURL: "https://api.themoviedb.org/3",
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-	}, nil
+		apiKey:     apiKey,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+		limiter:    NewLimiter(DefaultRequestsPerSecond, DefaultBurst),
+		maxRetries: DefaultMaxRetries,
+		backoff:    DefaultBackoff,
+		stats:      make(map[string]*EndpointStats),
+	}
 }
 
-// SearchMovie searches for a movie by title
-func (c *Client) SearchMovie(title string) (*SearchMovieResponse, error) {
-	endpoint := fmt.Sprintf("%s/search/movie?api_key=%s&query=%s", c.baseURL, c.apiKey, url.QueryEscape(title))
+// WithLimiter replaces the default rate limiter, e.g. to share one between
+// clients
+func (c *Client) WithLimiter(limiter *Limiter) *Client {
+	c.limiter = limiter
+	return c
+}
 
-	resp, err := c.httpClient.Get(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search movie: %w", err)
+// WithCache serves responses from cache while they're fresh and stores the
+// ones fetched from TMDB. A nil cache disables caching.
+func (c *Client) WithCache(cache *Cache) *Client {
+	c.cache = cache
+	return c
+}
+
+// WithLanguages makes detail requests include translations, which the sync
+// stores for languages besides English. Codes are ISO 639-1, optionally with
+// a region, e.g. "de" or "pt-BR"; English is skipped since it's the default.
+func (c *Client) WithLanguages(languages []string) *Client {
+	c.languages = nil
+	for _, language := range languages {
+		if models.BaseLanguage(language) != "en" {
+			c.languages = append(c.languages, language)
+		}
 	}
-	defer resp.Body.Close()
+	return c
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("TMDB API error: %s, status code: %d", string(body), resp.StatusCode)
+// Languages returns the languages set with WithLanguages, English excluded
+func (c *Client) Languages() []string {
+	return c.languages
+}
+
+// WithRetries sets how often a 429 or 5xx response is retried and the delay
+// before the first retry when TMDB sends no Retry-After
+func (c *Client) WithRetries(maxRetries int, backoff time.Duration) *Client {
+	c.maxRetries = maxRetries
+	c.backoff = backoff
+	return c
+}
+
+// Stats returns a snapshot of the request counters per endpoint
+func (c *Client) Stats() map[string]EndpointStats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	snapshot := make(map[string]EndpointStats, len(c.stats))
+	for endpoint, stats := range c.stats {
+		snapshot[endpoint] = *stats
 	}
+	return snapshot
+}
 
-	var result SearchMovieResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// LogStats writes the request counters to the log, one line per endpoint
+func (c *Client) LogStats() {
+	stats := c.Stats()
+	endpoints := make([]string, 0, len(stats))
+	for endpoint := range stats {
+		endpoints = append(endpoints, endpoint)
 	}
+	sort.Strings(endpoints)
 
-	return &result, nil
+	for _, endpoint := range endpoints {
+		s := stats[endpoint]
+		log.Printf("TMDB %s: %d requests, %d retries, %d rate limited, %d failures, %d cache hits",
+			endpoint, s.Requests, s.Retries, s.RateLimited, s.Failures, s.CacheHits)
+	}
 }
 
-// SearchTV searches for a TV show by title
-func (c *Client) SearchTV(title string) (*SearchTVResponse, error) {
-	endpoint := fmt.Sprintf("%s/search/tv?api_key=%s&query=%s", c.baseURL, c.apiKey, url.QueryEscape(title))
+func (c *Client) count(endpoint string, update func(*EndpointStats)) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
 
-	resp, err := c.httpClient.Get(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search TV show: %w", err)
+	stats, ok := c.stats[endpoint]
+	if !ok {
+		stats = &EndpointStats{}
+		c.stats[endpoint] = stats
 	}
-	defer resp.Body.Close()
+	update(stats)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("TMDB API error: %s, status code: %d", string(body), resp.StatusCode)
+// SearchMovie searches for a movie by title
+func (c *Client) SearchMovie(ctx context.Context, title string) (*SearchMovieResponse, error) {
+	endpoint := fmt.Sprintf("%s/search/movie?api_key=%s&query=%s", c.baseURL, c.apiKey, url.QueryEscape(title))
+
+	var result SearchMovieResponse
+	if err := c.get(ctx, "search/movie", endpoint, &result); err != nil {
+		return nil, fmt.Errorf("failed to search movie: %w", err)
 	}
+	return &result, nil
+}
+
+// SearchTV searches for a TV show by title
+func (c *Client) SearchTV(ctx context.Context, title string) (*SearchTVResponse, error) {
+	endpoint := fmt.Sprintf("%s/search/tv?api_key=%s&query=%s", c.baseURL, c.apiKey, url.QueryEscape(title))
 
 	var result SearchTVResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.get(ctx, "search/tv", endpoint, &result); err != nil {
+		return nil, fmt.Errorf("failed to search TV show: %w", err)
 	}
-
 	return &result, nil
 }
 
-// GetMovieDetails gets detailed information about a movie by its TMDB ID
-func (c *Client) GetMovieDetails(tmdbID int) (*MovieDetails, error) {
-	endpoint := fmt.Sprintf("%s/movie/%d?api_key=%s&append_to_response=credits,images,videos",
-		c.baseURL, tmdbID, c.apiKey)
+// External ID sources accepted by FindByExternalID
+const (
+	ExternalSourceIMDb = "imdb_id"
+	ExternalSourceTVDB = "tvdb_id"
+)
 
-	resp, err := c.httpClient.Get(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get movie details: %w", err)
-	}
-	defer resp.Body.Close()
+// FindByExternalID looks up movies and TV shows by an ID from another
+// database, e.g. an IMDb ID with ExternalSourceIMDb
+func (c *Client) FindByExternalID(ctx context.Context, externalID, source string) (*FindResponse, error) {
+	endpoint := fmt.Sprintf("%s/find/%s?api_key=%s&external_source=%s",
+		c.baseURL, url.PathEscape(externalID), c.apiKey, url.QueryEscape(source))
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("TMDB API error: %s, status code: %d", string(body), resp.StatusCode)
+	var result FindResponse
+	if err := c.get(ctx, "find", endpoint, &result); err != nil {
+		return nil, fmt.Errorf("failed to find %s %s: %w", source, externalID, err)
 	}
+	return &result, nil
+}
+
+// GetMovieDetails gets detailed information about a movie by its TMDB ID
+func (c *Client) GetMovieDetails(ctx context.Context, tmdbID int) (*MovieDetails, error) {
+	endpoint := fmt.Sprintf("%s/movie/%d?api_key=%s&append_to_response=credits,images,videos%s",
+		c.baseURL, tmdbID, c.apiKey, c.appendTranslations())
 
 	var result MovieDetails
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.get(ctx, "movie", endpoint, &result); err != nil {
+		return nil, fmt.Errorf("failed to get movie details: %w", err)
 	}
-
 	return &result, nil
 }
 
 // GetTVDetails gets detailed information about a TV show by its TMDB ID
-func (c *Client) GetTVDetails(tmdbID int) (*TVDetails, error) {
-	endpoint := fmt.Sprintf("%s/tv/%d?api_key=%s&append_to_response=credits,images,videos",
-		c.baseURL, tmdbID, c.apiKey)
+func (c *Client) GetTVDetails(ctx context.Context, tmdbID int) (*TVDetails, error) {
+	endpoint := fmt.Sprintf("%s/tv/%d?api_key=%s&append_to_response=credits,images,videos,external_ids%s",
+		c.baseURL, tmdbID, c.apiKey, c.appendTranslations())
 
-	resp, err := c.httpClient.Get(endpoint)
-	if err != nil {
+	var result TVDetails
+	if err := c.get(ctx, "tv", endpoint, &result); err != nil {
 		return nil, fmt.Errorf("failed to get TV details: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("TMDB API error: %s, status code: %d", string(body), resp.StatusCode)
-	}
+	return &result, nil
+}
 
-	var result TVDetails
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// appendTranslations adds translations to append_to_response when other
+// languages than English are wanted
+func (c *Client) appendTranslations() string {
+	if len(c.languages) == 0 {
+		return ""
 	}
-
-	return &result, nil
+	return ",translations"
 }
 
 // GetTVSeasonDetails gets detailed information about a TV season
-func (c *Client) GetTVSeasonDetails(tmdbID, seasonNumber int) (*SeasonDetails, error) {
+func (c *Client) GetTVSeasonDetails(ctx context.Context, tmdbID, seasonNumber int) (*SeasonDetails, error) {
+	return c.GetLocalizedTVSeasonDetails(ctx, tmdbID, seasonNumber, "")
+}
+
+// GetLocalizedTVSeasonDetails gets a TV season with its name and the names and
+// overviews of its episodes in language, or in English when language is empty
+func (c *Client) GetLocalizedTVSeasonDetails(ctx context.Context, tmdbID, seasonNumber int, language string) (*SeasonDetails, error) {
 	endpoint := fmt.Sprintf("%s/tv/%d/season/%d?api_key=%s",
 		c.baseURL, tmdbID, seasonNumber, c.apiKey)
+	if language != "" {
+		endpoint += "&language=" + url.QueryEscape(language)
+	}
 
-	resp, err := c.httpClient.Get(endpoint)
-	if err != nil {
+	var result SeasonDetails
+	if err := c.get(ctx, "tv/season", endpoint, &result); err != nil {
 		return nil, fmt.Errorf("failed to get season details: %w", err)
 	}
-	defer resp.Body.Close()
+	return &result, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("TMDB API error: %s, status code: %d", string(body), resp.StatusCode)
-	}
+// GetTVEpisodeGroups lists the alternative episode orders of a TV show
+func (c *Client) GetTVEpisodeGroups(ctx context.Context, tmdbID int) (*EpisodeGroupsResponse, error) {
+	endpoint := fmt.Sprintf("%s/tv/%d/episode_groups?api_key=%s", c.baseURL, tmdbID, c.apiKey)
 
-	var result SeasonDetails
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	var result EpisodeGroupsResponse
+	if err := c.get(ctx, "tv/episode_groups", endpoint, &result); err != nil {
+		return nil, fmt.Errorf("failed to get episode groups: %w", err)
 	}
+	return &result, nil
+}
 
+// GetEpisodeGroupDetails gets an episode group with all its episodes
+func (c *Client) GetEpisodeGroupDetails(ctx context.Context, groupID string) (*EpisodeGroupDetails, error) {
+	endpoint := fmt.Sprintf("%s/tv/episode_group/%s?api_key=%s", c.baseURL, url.PathEscape(groupID), c.apiKey)
+
+	var result EpisodeGroupDetails
+	if err := c.get(ctx, "tv/episode_group", endpoint, &result); err != nil {
+		return nil, fmt.Errorf("failed to get episode group %s: %w", groupID, err)
+	}
 	return &result, nil
 }
 
 // GetTVEpisodeDetails gets detailed information about a TV episode
-func (c *Client) GetTVEpisodeDetails(tmdbID, seasonNumber, episodeNumber int) (*EpisodeDetails, error) {
+func (c *Client) GetTVEpisodeDetails(ctx context.Context, tmdbID, seasonNumber, episodeNumber int) (*EpisodeDetails, error) {
 	endpoint := fmt.Sprintf("%s/tv/%d/season/%d/episode/%d?api_key=%s",
 		c.baseURL, tmdbID, seasonNumber, episodeNumber, c.apiKey)
 
-	resp, err := c.httpClient.Get(endpoint)
-	if err != nil {
+	var result EpisodeDetails
+	if err := c.get(ctx, "tv/episode", endpoint, &result); err != nil {
 		return nil, fmt.Errorf("failed to get episode details: %w", err)
 	}
+	return &result, nil
+}
+
+// GetMovieChanges gets one page of the IDs of movies changed between start and
+// end, which TMDB allows to be at most 14 days apart
+func (c *Client) GetMovieChanges(ctx context.Context, start, end time.Time, page int) (*ChangesResponse, error) {
+	return c.getChanges(ctx, "movie", start, end, page)
+}
+
+// GetTVChanges gets one page of the IDs of TV shows changed between start and
+// end, including shows whose seasons or episodes changed
+func (c *Client) GetTVChanges(ctx context.Context, start, end time.Time, page int) (*ChangesResponse, error) {
+	return c.getChanges(ctx, "tv", start, end, page)
+}
+
+func (c *Client) getChanges(ctx context.Context, kind string, start, end time.Time, page int) (*ChangesResponse, error) {
+	endpoint := fmt.Sprintf("%s/%s/changes?api_key=%s&start_date=%s&end_date=%s&page=%d",
+		c.baseURL, kind, c.apiKey, start.UTC().Format(time.DateOnly), end.UTC().Format(time.DateOnly), page)
+
+	var result ChangesResponse
+	if err := c.get(ctx, kind+"/changes", endpoint, &result); err != nil {
+		return nil, fmt.Errorf("failed to get %s changes: %w", kind, err)
+	}
+	return &result, nil
+}
+
+// get fetches rawURL into out, from the cache if it holds a fresh response.
+// Otherwise it waits for the rate limiter before every attempt and retries
+// 429 and 5xx responses as well as network errors. name identifies the
+// endpoint in Stats and picks the cache TTL.
+func (c *Client) get(ctx context.Context, name, rawURL string, out interface{}) error {
+	if c.cache != nil {
+		if body, ok := c.cache.get(name, rawURL); ok {
+			if err := json.Unmarshal(body, out); err == nil {
+				c.count(name, func(s *EndpointStats) { s.CacheHits++ })
+				return nil
+			}
+			// A corrupt entry is simply fetched again and overwritten
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		c.count(name, func(s *EndpointStats) { s.Requests++ })
+
+		body, err := c.do(ctx, name, rawURL, out)
+		if err == nil {
+			if c.cache != nil {
+				if err := c.cache.put(name, rawURL, body); err != nil {
+					log.Printf("Error caching TMDB %s response: %v", name, err)
+				}
+			}
+			return nil
+		}
+
+		if !upstream.Retryable(err) || attempt >= c.maxRetries || ctx.Err() != nil {
+			c.count(name, func(s *EndpointStats) { s.Failures++ })
+			return err
+		}
+
+		delay := upstream.RetryAfter(err)
+		if delay <= 0 {
+			delay = c.backoff << attempt
+			if delay > maxBackoff || delay <= 0 {
+				delay = maxBackoff
+			}
+			delay = jitter(delay)
+		} else {
+			// TMDB asked every client to back off, not just this request
+			c.limiter.PauseUntil(time.Now().Add(delay))
+		}
+
+		log.Printf("TMDB %s: %v, retrying in %s (attempt %d/%d)", name, err, delay.Round(time.Millisecond), attempt+1, c.maxRetries)
+		c.count(name, func(s *EndpointStats) { s.Retries++ })
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			c.count(name, func(s *EndpointStats) { s.Failures++ })
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// do makes a single attempt and returns the raw body it decoded into out.
+// Failures are upstream errors, so get can tell which are worth retrying.
+func (c *Client) do(ctx context.Context, name, rawURL string, out interface{}) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("TMDB API error: %s, status code: %d", string(body), resp.StatusCode)
+		err := upstream.NewStatusError("tmdb", resp)
+		if errors.Is(err, upstream.ErrRateLimited) {
+			c.count(name, func(s *EndpointStats) { s.RateLimited++ })
+		}
+		return nil, err
 	}
 
-	var result EpisodeDetails
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, &upstream.ParseError{Service: "tmdb", What: name + " response", Err: err}
 	}
+	return body, nil
+}
 
-	return &result, nil
+// jitter spreads retries of concurrent callers over [d/2, d)
+func jitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
 }
@@ -0,0 +1,117 @@
+package tmdb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
+)
+
+// newTestClient points a client at a server answering with the statuses in
+// order, then 200 for every further request
+func newTestClient(t *testing.T, statuses []int, header http.Header) (*Client, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1))
+		if n <= len(statuses) {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte(`{"page":1,"results":[{"id":603,"title":"The Matrix"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClientWith("key", server.URL, server.Client()).
+		WithLimiter(NewLimiter(0, 0)).
+		WithRetries(3, time.Millisecond)
+	return client, &requests
+}
+
+func TestClientRetriesServerErrors(t *testing.T) {
+	client, requests := newTestClient(t, []int{http.StatusBadGateway, http.StatusServiceUnavailable}, nil)
+
+	result, err := client.SearchMovie(context.Background(), "The Matrix")
+	if err != nil {
+		t.Fatalf("SearchMovie: %v", err)
+	}
+	if len(result.Results) != 1 || result.Results[0].ID != 603 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if *requests != 3 {
+		t.Errorf("got %d requests, want 3", *requests)
+	}
+	stats := client.Stats()["search/movie"]
+	if stats.Requests != 3 || stats.Retries != 2 || stats.Failures != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestClientHonorsRetryAfter(t *testing.T) {
+	client, requests := newTestClient(t, []int{http.StatusTooManyRequests}, http.Header{"Retry-After": {"1"}})
+
+	start := time.Now()
+	if _, err := client.SearchMovie(context.Background(), "The Matrix"); err != nil {
+		t.Fatalf("SearchMovie: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the 1s TMDB asked for", elapsed)
+	}
+	if *requests != 2 {
+		t.Errorf("got %d requests, want 2", *requests)
+	}
+	if stats := client.Stats()["search/movie"]; stats.RateLimited != 1 || stats.Retries != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	// The pause applies to the whole limiter, not just the retried request
+	if delay := client.limiter.reserve(); delay != 0 {
+		t.Errorf("limiter still paused for %s after Retry-After passed", delay)
+	}
+}
+
+func TestClientGivesUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int32
+		kind     error
+	}{
+		{"after max retries", []int{500, 500, 500, 500, 500}, 4, upstream.ErrServer},
+		{"on not found", []int{404}, 1, upstream.ErrNotFound},
+		{"on bad API key", []int{401}, 1, upstream.ErrAuth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := newTestClient(t, tt.statuses, nil)
+
+			_, err := client.SearchMovie(context.Background(), "The Matrix")
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("got error %v, want %v", err, tt.kind)
+			}
+			if *requests != tt.requests {
+				t.Errorf("got %d requests, want %d", *requests, tt.requests)
+			}
+			if stats := client.Stats()["search/movie"]; stats.Failures != 1 {
+				t.Errorf("got %d failures, want 1", stats.Failures)
+			}
+		})
+	}
+}
+
+func TestClientStopsRetryingWhenCancelled(t *testing.T) {
+	client, _ := newTestClient(t, []int{http.StatusTooManyRequests}, http.Header{"Retry-After": {"60"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.SearchMovie(ctx, "The Matrix"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,358 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/releaseparse"
+)
+
+// minSpecialNameScore is the share of a special's name words that have to
+// appear in a file name for the file to be that special
+const minSpecialNameScore = 0.75
+
+// episodeGroupPreference is the order in which episode groups are tried for
+// seasons that don't fit TMDB's regular seasons. Absolute orders are left out,
+// the absolute strategy covers them.
+var episodeGroupPreference = []int{
+	EpisodeGroupDVD,
+	EpisodeGroupDigital,
+	EpisodeGroupTV,
+	EpisodeGroupProduction,
+	EpisodeGroupOriginalAirDate,
+	EpisodeGroupStoryArc,
+}
+
+var (
+	wordPattern     = regexp.MustCompile(`[\p{L}\p{N}]+`)
+	specialsPattern = regexp.MustCompile(`(?i)\bspecials?\b`)
+)
+
+// episodeCatalog loads the TMDB seasons of one show on demand, so shows
+// numbered like TMDB cost no more requests than a season each
+type episodeCatalog struct {
+	client  *Client
+	tmdbID  int
+	numbers []int // season numbers TMDB lists, specials included
+	seasons map[int]*SeasonDetails
+
+	// Built on first use from every season
+	byAirDate map[string]*Episode
+	absolute  []*Episode // the regular seasons' episodes in order
+}
+
+func newEpisodeCatalog(client *Client, tmdbID int, seasons []Season) *episodeCatalog {
+	numbers := make([]int, 0, len(seasons))
+	for _, season := range seasons {
+		numbers = append(numbers, season.SeasonNumber)
+	}
+	sort.Ints(numbers)
+	return &episodeCatalog{
+		client:  client,
+		tmdbID:  tmdbID,
+		numbers: numbers,
+		seasons: make(map[int]*SeasonDetails),
+	}
+}
+
+// season returns the details of season number, or nil if TMDB has no such
+// season
+func (c *episodeCatalog) season(ctx context.Context, number int) (*SeasonDetails, error) {
+	if details, ok := c.seasons[number]; ok {
+		return details, nil
+	}
+	if i := sort.SearchInts(c.numbers, number); i == len(c.numbers) || c.numbers[i] != number {
+		return nil, nil
+	}
+	details, err := c.client.GetTVSeasonDetails(ctx, c.tmdbID, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get season details: %w", err)
+	}
+	c.seasons[number] = details
+	return details, nil
+}
+
+// episode looks an episode up by its TMDB season and episode number
+func (c *episodeCatalog) episode(ctx context.Context, season, number int) (*Episode, error) {
+	details, err := c.season(ctx, season)
+	if err != nil || details == nil {
+		return nil, err
+	}
+	for i := range details.Episodes {
+		if details.Episodes[i].EpisodeNumber == number {
+			return &details.Episodes[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// episodeCount returns how many episodes TMDB lists in season, 0 if it has
+// no such season
+func (c *episodeCatalog) episodeCount(ctx context.Context, season int) (int, error) {
+	details, err := c.season(ctx, season)
+	if err != nil || details == nil {
+		return 0, err
+	}
+	return len(details.Episodes), nil
+}
+
+// index loads every season and indexes the episodes by air date and by
+// absolute number
+func (c *episodeCatalog) index(ctx context.Context) error {
+	if c.byAirDate != nil {
+		return nil
+	}
+	byAirDate := make(map[string]*Episode)
+	var absolute []*Episode
+	for _, number := range c.numbers {
+		details, err := c.season(ctx, number)
+		if err != nil {
+			return err
+		}
+		for i := range details.Episodes {
+			episode := &details.Episodes[i]
+			if episode.AirDate != "" {
+				if _, taken := byAirDate[episode.AirDate]; !taken {
+					byAirDate[episode.AirDate] = episode
+				}
+			}
+			if number > 0 {
+				absolute = append(absolute, episode)
+			}
+		}
+	}
+	c.byAirDate, c.absolute = byAirDate, absolute
+	return nil
+}
+
+func (c *episodeCatalog) episodeByAirDate(ctx context.Context, date string) (*Episode, error) {
+	if err := c.index(ctx); err != nil {
+		return nil, err
+	}
+	return c.byAirDate[date], nil
+}
+
+func (c *episodeCatalog) episodeByAbsolute(ctx context.Context, number int) (*Episode, error) {
+	if err := c.index(ctx); err != nil {
+		return nil, err
+	}
+	if number < 1 || number > len(c.absolute) {
+		return nil, nil
+	}
+	return c.absolute[number-1], nil
+}
+
+// specialByName finds the TMDB special whose name appears in one of the file
+// names, for specials numbered differently than on TMDB
+func (c *episodeCatalog) specialByName(ctx context.Context, fileNames []string) (*Episode, error) {
+	details, err := c.season(ctx, 0)
+	if err != nil || details == nil {
+		return nil, err
+	}
+
+	var best *Episode
+	bestScore := 0.0
+	for _, fileName := range fileNames {
+		words := make(map[string]bool)
+		for _, word := range wordPattern.FindAllString(strings.ToLower(fileName), -1) {
+			words[word] = true
+		}
+		for i := range details.Episodes {
+			special := &details.Episodes[i]
+			if score := nameScore(special.Name, words); score > bestScore {
+				best, bestScore = special, score
+			}
+		}
+	}
+	if bestScore < minSpecialNameScore {
+		return nil, nil
+	}
+	return best, nil
+}
+
+// nameScore is the share of the words of name, ignoring short ones, found in
+// words
+func nameScore(name string, words map[string]bool) float64 {
+	total, found := 0, 0
+	for _, word := range wordPattern.FindAllString(strings.ToLower(name), -1) {
+		if len([]rune(word)) < 3 {
+			continue
+		}
+		total++
+		if words[word] {
+			found++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(found) / float64(total)
+}
+
+// episodeGroupIndex maps our season and episode numbers onto the episodes of
+// an episode group: the group at position n is season n, or season 0 when
+// it's named Specials, and its episodes are numbered in order from 1
+type episodeGroupIndex map[int][]*Episode
+
+func (g episodeGroupIndex) episode(season, number int) *Episode {
+	episodes := g[season]
+	if number < 1 || number > len(episodes) {
+		return nil
+	}
+	return episodes[number-1]
+}
+
+func newEpisodeGroupIndex(details *EpisodeGroupDetails) episodeGroupIndex {
+	groups := append([]EpisodeGroupSeason(nil), details.Groups...)
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Order < groups[j].Order })
+
+	index := make(episodeGroupIndex)
+	season := 1
+	for _, group := range groups {
+		number := season
+		if specialsPattern.MatchString(group.Name) {
+			number = 0
+		} else {
+			season++
+		}
+
+		episodes := append([]GroupEpisode(nil), group.Episodes...)
+		sort.SliceStable(episodes, func(i, j int) bool { return episodes[i].Order < episodes[j].Order })
+		for i := range episodes {
+			index[number] = append(index[number], &episodes[i].Episode)
+		}
+	}
+	return index
+}
+
+// findEpisodeGroup returns the first episode group, in order of preference,
+// with enough episodes in each of the given seasons, which maps season
+// numbers to the highest episode number we have. It returns nil if none fits.
+func (s *SyncService) findEpisodeGroup(ctx context.Context, tmdbID int, needed map[int]int) (episodeGroupIndex, error) {
+	groups, err := s.tmdbClient.GetTVEpisodeGroups(ctx, tmdbID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, groupType := range episodeGroupPreference {
+		for _, group := range groups.Results {
+			if group.Type != groupType {
+				continue
+			}
+			details, err := s.tmdbClient.GetEpisodeGroupDetails(ctx, group.ID)
+			if err != nil {
+				return nil, err
+			}
+			index := newEpisodeGroupIndex(details)
+			if index.fits(needed) {
+				log.Printf("Numbering episodes of TMDB show %d by episode group %q", tmdbID, group.Name)
+				return index, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (g episodeGroupIndex) fits(needed map[int]int) bool {
+	for season, maxEpisode := range needed {
+		if len(g[season]) < maxEpisode {
+			return false
+		}
+	}
+	return true
+}
+
+// episodeMatcher pairs our episodes with TMDB episodes, trying in turn:
+//
+//   - the air date of daily shows, when a file name carries one
+//   - the episode group numbering, for seasons split differently than on TMDB
+//   - the same season and episode number, specials included
+//   - a special whose name is in the file name, for season 0 and episode 0
+//   - the episode number as an absolute number across the regular seasons,
+//     when that doesn't point into an earlier season
+type episodeMatcher struct {
+	catalog *episodeCatalog
+	group   episodeGroupIndex
+	misfits map[int]bool // seasons numbered differently than on TMDB
+}
+
+func (m *episodeMatcher) match(ctx context.Context, seasonNumber int, episode *models.Episode) (*Episode, string, error) {
+	releases, fileNames := episodeFiles(episode)
+
+	for _, release := range releases {
+		if release.Date == "" {
+			continue
+		}
+		found, err := m.catalog.episodeByAirDate(ctx, release.Date)
+		if err != nil || found != nil {
+			return found, models.EpisodeMatchAirDate, err
+		}
+	}
+
+	if m.misfits[seasonNumber] && m.group != nil {
+		if found := m.group.episode(seasonNumber, episode.EpisodeNo); found != nil {
+			return found, models.EpisodeMatchEpisodeGroup, nil
+		}
+	}
+
+	if seasonNumber == 0 || episode.EpisodeNo == 0 {
+		found, err := m.catalog.specialByName(ctx, fileNames)
+		if err != nil || found != nil {
+			return found, models.EpisodeMatchSpecial, err
+		}
+	}
+
+	found, err := m.catalog.episode(ctx, seasonNumber, episode.EpisodeNo)
+	if err != nil || found != nil {
+		return found, models.EpisodeMatchNumber, err
+	}
+
+	if seasonNumber > 0 {
+		absolute := episode.EpisodeNo
+		for _, release := range releases {
+			if release.Absolute > 0 {
+				absolute = release.Absolute
+				break
+			}
+		}
+		// An absolute number pointing into an earlier season is more likely
+		// an episode TMDB doesn't list yet
+		found, err := m.catalog.episodeByAbsolute(ctx, absolute)
+		if err != nil || (found != nil && found.SeasonNumber >= seasonNumber) {
+			return found, models.EpisodeMatchAbsolute, err
+		}
+	}
+	return nil, "", nil
+}
+
+// episodeFiles returns the parsed releases and the names of the files of an
+// episode
+func episodeFiles(episode *models.Episode) ([]*releaseparse.Release, []string) {
+	var releases []*releaseparse.Release
+	var fileNames []string
+	for _, source := range episode.Sources {
+		for _, file := range source.Files {
+			release := file.Release
+			if release == nil {
+				release = releaseparse.Parse(file.FileName)
+			}
+			releases = append(releases, release)
+			fileNames = append(fileNames, file.FileName)
+		}
+	}
+	return releases, fileNames
+}
+
+// maxEpisodeNo is the highest episode number of a season
+func maxEpisodeNo(season *models.Season) int {
+	highest := 0
+	for _, episode := range season.Episodes {
+		highest = max(highest, episode.EpisodeNo)
+	}
+	return highest
+}
@@ -0,0 +1,77 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// matchMovieByIMDbID looks the movie up by its IMDb ID and applies the TMDB
+// details. It reports false, without error, when TMDB doesn't know the ID,
+// so the caller can fall back to the title search.
+func (s *SyncService) matchMovieByIMDbID(ctx context.Context, movie *models.Movie) (bool, error) {
+	imdbID := movie.IMDbID
+	found, err := s.tmdbClient.FindByExternalID(ctx, imdbID, ExternalSourceIMDb)
+	if err != nil {
+		return false, err
+	}
+	if len(found.MovieResults) == 0 {
+		log.Printf("No TMDB movie with IMDb ID %s, falling back to title search", imdbID)
+		return false, nil
+	}
+
+	result := found.MovieResults[0]
+	details, err := s.tmdbClient.GetMovieDetails(ctx, result.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get movie details: %w", err)
+	}
+	s.updateMovieFromTMDB(movie, details)
+	movie.Match = externalMatch(imdbID, result.ID, result.Title, result.ReleaseDate)
+	log.Printf("Matched movie %s by IMDb ID %s to TMDB ID %d", movie.Title, imdbID, result.ID)
+	return true, nil
+}
+
+// matchTVByIMDbID is matchMovieByIMDbID for TV shows, seasons and episodes
+// included
+func (s *SyncService) matchTVByIMDbID(ctx context.Context, tv *models.TV) (bool, error) {
+	imdbID := tv.IMDbID
+	found, err := s.tmdbClient.FindByExternalID(ctx, imdbID, ExternalSourceIMDb)
+	if err != nil {
+		return false, err
+	}
+	if len(found.TVResults) == 0 {
+		log.Printf("No TMDB TV show with IMDb ID %s, falling back to title search", imdbID)
+		return false, nil
+	}
+
+	result := found.TVResults[0]
+	details, err := s.tmdbClient.GetTVDetails(ctx, result.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get TV details: %w", err)
+	}
+	s.updateTVFromTMDB(tv, details)
+	tv.Match = externalMatch(imdbID, result.ID, result.Name, result.FirstAirDate)
+	if err := s.syncTVSeasons(ctx, tv, details); err != nil {
+		log.Printf("Warning: error syncing seasons for TV %s: %v", tv.Title, err)
+	}
+	log.Printf("Matched TV show %s by IMDb ID %s to TMDB ID %d", tv.Title, imdbID, result.ID)
+	return true, nil
+}
+
+// externalMatch records a match made by IMDb ID. It's exact, so it gets the
+// full score and never needs review.
+func externalMatch(imdbID string, tmdbID int, title, date string) *models.Match {
+	return &models.Match{
+		Status: models.MatchMatched,
+		Score:  100,
+		Reason: fmt.Sprintf("found by IMDb ID %s", imdbID),
+		Candidates: []models.MatchCandidate{
+			{TMDBID: tmdbID, Title: title, Year: getYearFromDate(date), Score: 100},
+		},
+		MatchedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+}
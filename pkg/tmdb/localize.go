@@ -0,0 +1,122 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+)
+
+// localizations picks the client's languages out of the translations and
+// posters of a details response. It returns nil when no other languages
+// than English are configured, which drops localizations stored earlier.
+func (s *SyncService) localizations(translations []Translation, posters []Image) map[string]models.Localization {
+	var result map[string]models.Localization
+	for _, language := range s.tmdbClient.Languages() {
+		var loc models.Localization
+		if t, ok := findTranslation(translations, language); ok {
+			loc.Title = t.Data.Title
+			if loc.Title == "" {
+				loc.Title = t.Data.Name
+			}
+			loc.Overview = t.Data.Overview
+		}
+		loc.PosterPath = bestPoster(posters, models.BaseLanguage(language))
+		if loc == (models.Localization{}) {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]models.Localization)
+		}
+		result[language] = loc
+	}
+	return result
+}
+
+// findTranslation matches "pt-BR" to the Brazilian Portuguese translation
+// only. A code without region, like "de", prefers the language's home region
+// (de-DE) and takes any other region otherwise.
+func findTranslation(translations []Translation, language string) (Translation, bool) {
+	base, region := models.BaseLanguage(language), ""
+	if len(language) > len(base)+1 {
+		region = strings.ToUpper(language[len(base)+1:])
+	}
+
+	var fallback *Translation
+	for i, t := range translations {
+		if !strings.EqualFold(t.ISO6391, base) {
+			continue
+		}
+		switch {
+		case region != "" && strings.EqualFold(t.ISO31661, region):
+			return t, true
+		case region == "" && strings.EqualFold(t.ISO31661, base):
+			return t, true
+		case region == "" && fallback == nil:
+			fallback = &translations[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return Translation{}, false
+}
+
+// bestPoster returns the highest rated poster in language, if any
+func bestPoster(posters []Image, language string) string {
+	var best *Image
+	for i, p := range posters {
+		if strings.EqualFold(p.ISO6391, language) && (best == nil || p.VoteAverage > best.VoteAverage) {
+			best = &posters[i]
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.FilePath
+}
+
+// localizeEpisodes fetches the TMDB seasons the episodes were matched to once
+// per configured language and stores the episode names and overviews TMDB
+// has in it
+func (s *SyncService) localizeEpisodes(ctx context.Context, tv *models.TV) error {
+	// Our episodes by their TMDB season and episode number
+	matched := make(map[int]map[int][]*models.Episode)
+	for i := range tv.Seasons {
+		for j := range tv.Seasons[i].Episodes {
+			episode := &tv.Seasons[i].Episodes[j]
+			episode.Localizations = nil
+			if episode.TMDBMatch == nil {
+				continue
+			}
+			season := episode.TMDBMatch.Season
+			if matched[season] == nil {
+				matched[season] = make(map[int][]*models.Episode)
+			}
+			matched[season][episode.TMDBMatch.Episode] = append(matched[season][episode.TMDBMatch.Episode], episode)
+		}
+	}
+
+	for _, language := range s.tmdbClient.Languages() {
+		for seasonNumber, episodes := range matched {
+			details, err := s.tmdbClient.GetLocalizedTVSeasonDetails(ctx, tv.TMDBID, seasonNumber, language)
+			if err != nil {
+				return fmt.Errorf("failed to get %s season details: %w", language, err)
+			}
+			for _, tmdbEpisode := range details.Episodes {
+				loc := models.Localization{Title: tmdbEpisode.Name, Overview: tmdbEpisode.Overview}
+				if loc == (models.Localization{}) {
+					continue
+				}
+				for _, episode := range episodes[tmdbEpisode.EpisodeNumber] {
+					if episode.Localizations == nil {
+						episode.Localizations = make(map[string]models.Localization)
+					}
+					episode.Localizations[language] = loc
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package tmdb
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scores range from 0 to 100: up to 50 for the title, 30 for the year and 20
+// for popularity
+const (
+	minMatchScore   = 30 // below this the best result is rejected
+	confidentScore  = 50 // below this an accepted match needs review
+	ambiguityMargin = 5  // a runner-up this close to the best needs review
+	maxCandidates   = 5  // candidates kept on the title for reviewers
+)
+
+var titleYearRegex = regexp.MustCompile(`\((\d{4})\)`)
+
+// searchResult is a movie or TV search result reduced to what matching
+// looks at
+type searchResult struct {
+	id         int
+	title      string
+	date       string // release or first air date
+	popularity float64
+}
+
+func movieSearchResults(results []MovieResult) []searchResult {
+	reduced := make([]searchResult, len(results))
+	for i, r := range results {
+		reduced[i] = searchResult{id: r.ID, title: r.Title, date: r.ReleaseDate, popularity: r.Popularity}
+	}
+	return reduced
+}
+
+func tvSearchResults(results []TVResult) []searchResult {
+	reduced := make([]searchResult, len(results))
+	for i, r := range results {
+		reduced[i] = searchResult{id: r.ID, title: r.Name, date: r.FirstAirDate, popularity: r.Popularity}
+	}
+	return reduced
+}
+
+// matchTitle scores the search results for title and decides whether the
+// best one is a confident match, needs review, or is rejected. The returned
+// Match lists the best scored candidates, best first.
+func matchTitle(title string, results []searchResult, yearFromFile string) *models.Match {
+	match := &models.Match{MatchedAt: primitive.NewDateTimeFromTime(time.Now())}
+
+	candidates := scoreResults(title, results, yearFromFile)
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+	match.Candidates = candidates
+
+	if len(candidates) == 0 {
+		match.Status = models.MatchUnmatched
+		match.Reason = "no TMDB results"
+		return match
+	}
+
+	best := candidates[0]
+	match.Score = best.Score
+	switch {
+	case best.Score < minMatchScore:
+		match.Status = models.MatchUnmatched
+		match.Reason = fmt.Sprintf("best score %.1f is below %d", best.Score, minMatchScore)
+	case best.Score < confidentScore:
+		match.Status = models.MatchNeedsReview
+		match.Reason = fmt.Sprintf("low confidence: score %.1f is below %d", best.Score, confidentScore)
+	case len(candidates) > 1 && best.Score-candidates[1].Score < ambiguityMargin:
+		match.Status = models.MatchNeedsReview
+		match.Reason = fmt.Sprintf("ambiguous: %q (%s) scored %.1f, within %d of the best",
+			candidates[1].Title, candidates[1].Year, candidates[1].Score, ambiguityMargin)
+	default:
+		match.Status = models.MatchMatched
+		match.Reason = fmt.Sprintf("score %.1f", best.Score)
+	}
+	return match
+}
+
+// scoreResults scores every result on title similarity, year and
+// popularity and sorts them best first
+func scoreResults(title string, results []searchResult, yearFromFile string) []models.MatchCandidate {
+	// Extract year from title if present (e.g., "Movie Title (2020)")
+	titleLower := strings.ToLower(title)
+	titleYear := ""
+	titleWithoutYear := titleLower
+	if yearMatches := titleYearRegex.FindStringSubmatch(titleLower); len(yearMatches) > 1 {
+		titleYear = yearMatches[1]
+		titleWithoutYear = strings.TrimSpace(strings.Replace(titleLower, yearMatches[0], "", 1))
+	}
+
+	// Use year from file name if available and no year in title
+	if titleYear == "" && yearFromFile != "" {
+		titleYear = yearFromFile
+	}
+
+	candidates := make([]models.MatchCandidate, 0, len(results))
+	for i, result := range results {
+		score := 0.0
+
+		// 1. Title similarity score (0-50 points)
+		resultTitle := strings.ToLower(result.title)
+		if resultTitle == titleWithoutYear {
+			score += 50 // Exact match is best
+		} else {
+			// Calculate string similarity using Levenshtein distance
+			similarity := calculateStringSimilarity(titleWithoutYear, resultTitle)
+			score += similarity * 40 // Up to 40 points for similar titles
+		}
+
+		// 2. Year match score (0-30 points)
+		resultYear := getYearFromDate(result.date)
+		if titleYear != "" && resultYear == titleYear {
+			score += 30 // Exact year match with high weight because we extracted it from filename
+		} else if titleYear != "" && resultYear != "" {
+			// Partial points for close years
+			yearDiff := math.Abs(float64(parseYear(resultYear) - parseYear(titleYear)))
+			if yearDiff <= 1 {
+				score += 20 // Only 1 year off
+			} else if yearDiff <= 2 {
+				score += 10 // 2 years off
+			}
+		}
+
+		// 3. Popularity boost (0-20 points)
+		// More popular titles get a boost, scaled by position in results
+		popScore := math.Min(20, result.popularity)
+		popBoost := popScore * math.Max(0, 1.0-float64(i)*0.1) // Decrease boost for later results
+		score += popBoost
+
+		candidates = append(candidates, models.MatchCandidate{
+			TMDBID: result.id,
+			Title:  result.title,
+			Year:   resultYear,
+			Score:  math.Round(score*10) / 10,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	// Debug logging for top matches
+	for i, c := range candidates {
+		if i == 3 {
+			break
+		}
+		log.Printf("Match %d: '%s' (%s) - Score: %.2f", i+1, c.Title, c.Year, c.Score)
+	}
+	return candidates
+}
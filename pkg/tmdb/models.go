@@ -33,6 +33,13 @@ type SearchTVResponse struct {
 	TotalPages   int        `json:"total_pages"`
 }
 
+// FindResponse represents the response from the TMDB find endpoint, which
+// looks titles up by an external ID
+type FindResponse struct {
+	MovieResults []MovieResult `json:"movie_results"`
+	TVResults    []TVResult    `json:"tv_results"`
+}
+
 // TVResult represents a TV show result from the TMDB search
 type TVResult struct {
 	ID               int     `json:"id"`
@@ -73,6 +80,7 @@ type MovieDetails struct {
 	Credits             Credits        `json:"credits,omitempty"`
 	Videos              VideosResponse `json:"videos,omitempty"`
 	Images              ImagesResponse `json:"images,omitempty"`
+	Translations        Translations   `json:"translations,omitempty"`
 }
 
 // TVDetails represents the detailed information about a TV show from TMDB
@@ -95,9 +103,17 @@ type TVDetails struct {
 	VoteCount        int            `json:"vote_count"`
 	Popularity       float64        `json:"popularity"`
 	Seasons          []Season       `json:"seasons"`
+	ExternalIDs      ExternalIDs    `json:"external_ids,omitempty"`
 	Credits          Credits        `json:"credits,omitempty"`
 	Videos           VideosResponse `json:"videos,omitempty"`
 	Images           ImagesResponse `json:"images,omitempty"`
+	Translations     Translations   `json:"translations,omitempty"`
+}
+
+// ExternalIDs holds the IDs of a TV show on other sites
+type ExternalIDs struct {
+	IMDbID string `json:"imdb_id"`
+	TVDBID int    `json:"tvdb_id"`
 }
 
 // SeasonDetails represents the detailed information about a TV season from TMDB
@@ -176,6 +192,52 @@ type Episode struct {
 	VoteCount     int     `json:"vote_count"`
 }
 
+// Episode group types. Groups order the episodes of a show differently from
+// its regular seasons, e.g. as released on DVD.
+const (
+	EpisodeGroupOriginalAirDate = 1
+	EpisodeGroupAbsolute        = 2
+	EpisodeGroupDVD             = 3
+	EpisodeGroupDigital         = 4
+	EpisodeGroupStoryArc        = 5
+	EpisodeGroupProduction      = 6
+	EpisodeGroupTV              = 7
+)
+
+// EpisodeGroupsResponse lists the episode groups of a TV show
+type EpisodeGroupsResponse struct {
+	ID      int            `json:"id"`
+	Results []EpisodeGroup `json:"results"`
+}
+
+type EpisodeGroup struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         int    `json:"type"`
+	GroupCount   int    `json:"group_count"`
+	EpisodeCount int    `json:"episode_count"`
+}
+
+// EpisodeGroupDetails represents an episode group with its groups, which
+// play the part of seasons
+type EpisodeGroupDetails struct {
+	EpisodeGroup
+	Groups []EpisodeGroupSeason `json:"groups"`
+}
+
+type EpisodeGroupSeason struct {
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	Order    int            `json:"order"`
+	Episodes []GroupEpisode `json:"episodes"`
+}
+
+// GroupEpisode is an episode with its position inside a group
+type GroupEpisode struct {
+	Episode
+	Order int `json:"order"`
+}
+
 type Credits struct {
 	Cast []Cast `json:"cast"`
 	Crew []Crew `json:"crew"`
@@ -213,6 +275,24 @@ type Video struct {
 	Official bool   `json:"official"`
 }
 
+type Translations struct {
+	Translations []Translation `json:"translations"`
+}
+
+// Translation holds a title's metadata in one language. Fields TMDB has no
+// translation for are empty.
+type Translation struct {
+	ISO31661 string          `json:"iso_3166_1"`
+	ISO6391  string          `json:"iso_639_1"`
+	Data     TranslationData `json:"data"`
+}
+
+type TranslationData struct {
+	Title    string `json:"title"` // movies
+	Name     string `json:"name"`  // TV shows
+	Overview string `json:"overview"`
+}
+
 type ImagesResponse struct {
 	Backdrops []Image `json:"backdrops"`
 	Posters   []Image `json:"posters"`
@@ -227,3 +307,16 @@ type Image struct {
 	VoteAverage float64 `json:"vote_average"`
 	VoteCount   int     `json:"vote_count"`
 }
+
+// ChangesResponse is one page of the movie/changes or tv/changes feed
+type ChangesResponse struct {
+	Page         int            `json:"page"`
+	Results      []ChangedEntry `json:"results"`
+	TotalPages   int            `json:"total_pages"`
+	TotalResults int            `json:"total_results"`
+}
+
+type ChangedEntry struct {
+	ID    int   `json:"id"`
+	Adult *bool `json:"adult"`
+}
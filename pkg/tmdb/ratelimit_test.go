@@ -0,0 +1,59 @@
+package tmdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterBurstThenRate(t *testing.T) {
+	limiter := NewLimiter(10, 3)
+
+	for i := 0; i < 3; i++ {
+		if delay := limiter.reserve(); delay != 0 {
+			t.Fatalf("request %d of the burst waits %s", i+1, delay)
+		}
+	}
+	// The bucket is empty, the next token comes in 1/10s
+	delay := limiter.reserve()
+	if delay <= 50*time.Millisecond || delay > 100*time.Millisecond {
+		t.Errorf("got delay %s after the burst, want about 100ms", delay)
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	limiter := NewLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		if delay := limiter.reserve(); delay != 0 {
+			t.Fatalf("request %d waits %s on a disabled limiter", i+1, delay)
+		}
+	}
+}
+
+func TestLimiterPauseUntil(t *testing.T) {
+	limiter := NewLimiter(1000, 10)
+	limiter.PauseUntil(time.Now().Add(time.Hour))
+	// An earlier pause doesn't shorten the current one
+	limiter.PauseUntil(time.Now().Add(time.Minute))
+
+	if delay := limiter.reserve(); delay < 59*time.Minute {
+		t.Errorf("got delay %s during the pause, want about an hour", delay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait during the pause returned %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestLimiterStartsEmptyAfterPause(t *testing.T) {
+	limiter := NewLimiter(10, 5)
+	limiter.PauseUntil(time.Now())
+
+	// No burst right after a pause, the bucket refills at the normal rate
+	if delay := limiter.reserve(); delay == 0 {
+		t.Error("request right after the pause went out without waiting")
+	}
+}
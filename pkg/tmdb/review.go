@@ -0,0 +1,50 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PinMovie matches movie to tmdbID by hand and syncs its details. Later syncs
+// only ever refresh a pinned movie from that ID, they never search again.
+func (s *SyncService) PinMovie(ctx context.Context, movie *models.Movie, tmdbID int) error {
+	details, err := s.tmdbClient.GetMovieDetails(ctx, tmdbID)
+	if err != nil {
+		return fmt.Errorf("failed to get TMDB movie %d: %w", tmdbID, err)
+	}
+	s.updateMovieFromTMDB(movie, details)
+	movie.Match = pinnedMatch(movie.Match, tmdbID)
+	return s.repo.UpdateMovie(ctx, movie)
+}
+
+// PinTV matches tv to tmdbID by hand and syncs its details, seasons and
+// episodes, like PinMovie
+func (s *SyncService) PinTV(ctx context.Context, tv *models.TV, tmdbID int) error {
+	details, err := s.tmdbClient.GetTVDetails(ctx, tmdbID)
+	if err != nil {
+		return fmt.Errorf("failed to get TMDB TV show %d: %w", tmdbID, err)
+	}
+	s.updateTVFromTMDB(tv, details)
+	tv.Match = pinnedMatch(tv.Match, tmdbID)
+	if err := s.syncTVSeasons(ctx, tv, details); err != nil {
+		return fmt.Errorf("failed to sync seasons of TMDB TV show %d: %w", tmdbID, err)
+	}
+	return s.repo.UpdateTV(ctx, tv)
+}
+
+// pinnedMatch keeps the candidates of the automatic match for reference
+func pinnedMatch(previous *models.Match, tmdbID int) *models.Match {
+	match := &models.Match{
+		Status:    models.MatchPinned,
+		Reason:    fmt.Sprintf("pinned to TMDB ID %d", tmdbID),
+		MatchedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+	if previous != nil {
+		match.Candidates = previous.Candidates
+	}
+	return match
+}
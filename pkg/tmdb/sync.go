@@ -2,37 +2,47 @@ package tmdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math"
-	"regexp"
-	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/amankumarsingh77/go-showbox-api/db/models"
 	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/releaseparse"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrNoMatch is wrapped by SyncMovie and SyncTV when TMDB has no result
+// close enough to the stored title
+var ErrNoMatch = errors.New("no TMDB match")
+
 // SyncService handles the synchronization between the local database and TMDB
 type SyncService struct {
 	tmdbClient *Client
-	repo       *repository.MongoRepo
+	repo       repository.Repository
+	workers    int
 }
 
 // NewSyncService creates a new sync service
-func NewSyncService(repo *repository.MongoRepo) (*SyncService, error) {
+func NewSyncService(repo repository.Repository) (*SyncService, error) {
 	tmdbClient, err := NewClient()
 	if err != nil {
 		return nil, err
 	}
 
+	return NewSyncServiceWithClient(repo, tmdbClient), nil
+}
+
+// NewSyncServiceWithClient creates a sync service that uses the given TMDB client
+func NewSyncServiceWithClient(repo repository.Repository, tmdbClient *Client) *SyncService {
 	return &SyncService{
 		tmdbClient: tmdbClient,
 		repo:       repo,
-	}, nil
+		workers:    DefaultWorkers,
+	}
 }
 
 // SyncMovie synchronizes a single movie with TMDB
@@ -42,19 +52,34 @@ func (s *SyncService) SyncMovie(ctx context.Context, movie *models.Movie) error
 	// First, try to find by TMDB ID if it exists
 	if movie.TMDBID != 0 {
 		log.Printf("Movie already has TMDB ID: %d, fetching updated details", movie.TMDBID)
-		details, err := s.tmdbClient.GetMovieDetails(movie.TMDBID)
+		details, err := s.tmdbClient.GetMovieDetails(ctx, movie.TMDBID)
 		if err == nil {
 			s.updateMovieFromTMDB(movie, details)
 			return s.repo.UpdateMovie(ctx, movie)
 		}
+		if movie.Match.IsPinned() {
+			return fmt.Errorf("failed to refresh pinned TMDB ID %d: %w", movie.TMDBID, err)
+		}
 		log.Printf("Error fetching existing TMDB details: %v, will try searching by title", err)
 		// If error, continue to search by title
 	}
 
+	// An IMDb ID, from the showbox page or an earlier sync, identifies the
+	// movie exactly; the fuzzy title search is only the fallback
+	if movie.IMDbID != "" {
+		matched, err := s.matchMovieByIMDbID(ctx, movie)
+		if err != nil {
+			return err
+		}
+		if matched {
+			return s.repo.UpdateMovie(ctx, movie)
+		}
+	}
+
 	// Extract year from file name if available
 	yearFromFile := ""
 	if len(movie.Files) > 0 && movie.Files[0].FileName != "" {
-		yearFromFile = extractYearFromFileName(movie.Files[0].FileName)
+		yearFromFile = extractYearFromFile(movie.Files[0])
 		if yearFromFile != "" {
 			log.Printf("Extracted year from file name: %s", yearFromFile)
 		}
@@ -69,7 +94,7 @@ func (s *SyncService) SyncMovie(ctx context.Context, movie *models.Movie) error
 		log.Printf("Searching TMDB for movie: %s", movie.Title)
 	}
 
-	searchResp, err := s.tmdbClient.SearchMovie(searchQuery)
+	searchResp, err := s.tmdbClient.SearchMovie(ctx, searchQuery)
 	if err != nil {
 		return fmt.Errorf("failed to search for movie: %w", err)
 	}
@@ -80,39 +105,39 @@ func (s *SyncService) SyncMovie(ctx context.Context, movie *models.Movie) error
 		// If no results with year, try just the title
 		if yearFromFile != "" && searchQuery != movie.Title {
 			log.Printf("No results found with year. Trying with title only: %s", movie.Title)
-			searchResp, err = s.tmdbClient.SearchMovie(movie.Title)
+			searchResp, err = s.tmdbClient.SearchMovie(ctx, movie.Title)
 			if err != nil {
 				return fmt.Errorf("failed to search for movie: %w", err)
 			}
 
-			if len(searchResp.Results) == 0 {
-				return fmt.Errorf("no matches found for movie: %s", movie.Title)
-			}
 			log.Printf("Found %d potential matches using title only", len(searchResp.Results))
-		} else {
-			return fmt.Errorf("no matches found for movie: %s", movie.Title)
 		}
 	}
 
-	// Find the best match, passing the year from file name for better matching
-	bestMatch := findBestMovieMatch(movie.Title, searchResp.Results, yearFromFile)
-	if bestMatch == nil {
-		return fmt.Errorf("couldn't find a good match for movie: %s", movie.Title)
+	// Score the results, passing the year from file name for better matching
+	match := matchTitle(movie.Title, movieSearchResults(searchResp.Results), yearFromFile)
+	if match.Status == models.MatchUnmatched {
+		// Keep the candidates so a reviewer can pin one
+		movie.Match = match
+		if err := s.repo.UpdateMovie(ctx, movie); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w for movie %s: %s", ErrNoMatch, movie.Title, match.Reason)
 	}
 
-	log.Printf("Best match: %s (%s) - TMDB ID: %d",
-		bestMatch.Title,
-		getYearFromDate(bestMatch.ReleaseDate),
-		bestMatch.ID)
+	best := match.Candidates[0]
+	log.Printf("Best match: %s (%s) - TMDB ID: %d, %s", best.Title, best.Year, best.TMDBID, match.Status)
 
 	// Get detailed information
-	details, err := s.tmdbClient.GetMovieDetails(bestMatch.ID)
+	details, err := s.tmdbClient.GetMovieDetails(ctx, best.TMDBID)
 	if err != nil {
 		return fmt.Errorf("failed to get movie details: %w", err)
 	}
 
-	// Update the movie with TMDB data
+	// Update the movie with TMDB data. Matches that need review still get the
+	// best guess until someone pins the right ID.
 	s.updateMovieFromTMDB(movie, details)
+	movie.Match = match
 	log.Printf("Updated movie metadata from TMDB: %s", movie.Title)
 
 	// Save the updated movie
@@ -126,7 +151,7 @@ func (s *SyncService) SyncTV(ctx context.Context, tv *models.TV) error {
 	// First, try to find by TMDB ID if it exists
 	if tv.TMDBID != 0 {
 		log.Printf("TV show already has TMDB ID: %d, fetching updated details", tv.TMDBID)
-		details, err := s.tmdbClient.GetTVDetails(tv.TMDBID)
+		details, err := s.tmdbClient.GetTVDetails(ctx, tv.TMDBID)
 		if err == nil {
 			s.updateTVFromTMDB(tv, details)
 			if err := s.syncTVSeasons(ctx, tv, details); err != nil {
@@ -134,19 +159,33 @@ func (s *SyncService) SyncTV(ctx context.Context, tv *models.TV) error {
 			}
 			return s.repo.UpdateTV(ctx, tv)
 		}
+		if tv.Match.IsPinned() {
+			return fmt.Errorf("failed to refresh pinned TMDB ID %d: %w", tv.TMDBID, err)
+		}
 		log.Printf("Error fetching existing TMDB details: %v, will try searching by title", err)
 		// If error, continue to search by title
 	}
 
+	// Match by IMDb ID first, like SyncMovie
+	if tv.IMDbID != "" {
+		matched, err := s.matchTVByIMDbID(ctx, tv)
+		if err != nil {
+			return err
+		}
+		if matched {
+			return s.repo.UpdateTV(ctx, tv)
+		}
+	}
+
 	// Extract year from file names if available
 	yearFromFile := ""
 	if len(tv.Seasons) > 0 && len(tv.Seasons[0].Episodes) > 0 &&
 		len(tv.Seasons[0].Episodes[0].Sources) > 0 &&
 		len(tv.Seasons[0].Episodes[0].Sources[0].Files) > 0 {
 		// Try to get the year from the first episode file name
-		fileName := tv.Seasons[0].Episodes[0].Sources[0].Files[0].FileName
-		if fileName != "" {
-			yearFromFile = extractYearFromFileName(fileName)
+		file := tv.Seasons[0].Episodes[0].Sources[0].Files[0]
+		if file.FileName != "" {
+			yearFromFile = extractYearFromFile(file)
 			if yearFromFile != "" {
 				log.Printf("Extracted year from file name: %s", yearFromFile)
 			}
@@ -162,7 +201,7 @@ func (s *SyncService) SyncTV(ctx context.Context, tv *models.TV) error {
 		log.Printf("Searching TMDB for TV show: %s", tv.Title)
 	}
 
-	searchResp, err := s.tmdbClient.SearchTV(searchQuery)
+	searchResp, err := s.tmdbClient.SearchTV(ctx, searchQuery)
 	if err != nil {
 		return fmt.Errorf("failed to search for TV show: %w", err)
 	}
@@ -173,39 +212,39 @@ func (s *SyncService) SyncTV(ctx context.Context, tv *models.TV) error {
 		// If no results with year, try just the title
 		if yearFromFile != "" && searchQuery != tv.Title {
 			log.Printf("No results found with year. Trying with title only: %s", tv.Title)
-			searchResp, err = s.tmdbClient.SearchTV(tv.Title)
+			searchResp, err = s.tmdbClient.SearchTV(ctx, tv.Title)
 			if err != nil {
 				return fmt.Errorf("failed to search for TV show: %w", err)
 			}
 
-			if len(searchResp.Results) == 0 {
-				return fmt.Errorf("no matches found for TV show: %s", tv.Title)
-			}
 			log.Printf("Found %d potential matches using title only", len(searchResp.Results))
-		} else {
-			return fmt.Errorf("no matches found for TV show: %s", tv.Title)
 		}
 	}
 
-	// Find the best match, passing the year from file name for better matching
-	bestMatch := findBestTVMatch(tv.Title, searchResp.Results, yearFromFile)
-	if bestMatch == nil {
-		return fmt.Errorf("couldn't find a good match for TV show: %s", tv.Title)
+	// Score the results, passing the year from file name for better matching
+	match := matchTitle(tv.Title, tvSearchResults(searchResp.Results), yearFromFile)
+	if match.Status == models.MatchUnmatched {
+		// Keep the candidates so a reviewer can pin one
+		tv.Match = match
+		if err := s.repo.UpdateTV(ctx, tv); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w for TV show %s: %s", ErrNoMatch, tv.Title, match.Reason)
 	}
 
-	log.Printf("Best match: %s (%s) - TMDB ID: %d",
-		bestMatch.Name,
-		getYearFromDate(bestMatch.FirstAirDate),
-		bestMatch.ID)
+	best := match.Candidates[0]
+	log.Printf("Best match: %s (%s) - TMDB ID: %d, %s", best.Title, best.Year, best.TMDBID, match.Status)
 
 	// Get detailed information
-	details, err := s.tmdbClient.GetTVDetails(bestMatch.ID)
+	details, err := s.tmdbClient.GetTVDetails(ctx, best.TMDBID)
 	if err != nil {
 		return fmt.Errorf("failed to get TV details: %w", err)
 	}
 
-	// Update the TV show with TMDB data
+	// Update the TV show with TMDB data. Matches that need review still get
+	// the best guess until someone pins the right ID.
 	s.updateTVFromTMDB(tv, details)
+	tv.Match = match
 	log.Printf("Updated TV show metadata from TMDB: %s", tv.Title)
 
 	// Sync seasons and episodes
@@ -218,44 +257,6 @@ func (s *SyncService) SyncTV(ctx context.Context, tv *models.TV) error {
 	return s.repo.UpdateTV(ctx, tv)
 }
 
-// SyncAllMovies synchronizes all movies in the database with TMDB
-func (s *SyncService) SyncAllMovies(ctx context.Context) error {
-	movies, err := s.repo.GetAllMovies(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get all movies: %w", err)
-	}
-
-	for i, movie := range movies {
-		log.Printf("Syncing movie %d/%d: %s", i+1, len(movies), movie.Title)
-		if err := s.SyncMovie(ctx, &movie); err != nil {
-			log.Printf("Error syncing movie '%s': %v", movie.Title, err)
-		}
-		// Sleep to avoid rate limiting
-		time.Sleep(200 * time.Millisecond)
-	}
-
-	return nil
-}
-
-// SyncAllTV synchronizes all TV shows in the database with TMDB
-func (s *SyncService) SyncAllTV(ctx context.Context) error {
-	tvShows, err := s.repo.GetAllTVShows(ctx, 1000, 0)
-	if err != nil {
-		return fmt.Errorf("failed to get all TV shows: %w", err)
-	}
-
-	for i, tv := range tvShows {
-		log.Printf("Syncing TV show %d/%d: %s", i+1, len(tvShows), tv.Title)
-		if err := s.SyncTV(ctx, &tv); err != nil {
-			log.Printf("Error syncing TV show '%s': %v", tv.Title, err)
-		}
-		// Sleep to avoid rate limiting
-		time.Sleep(200 * time.Millisecond)
-	}
-
-	return nil
-}
-
 // Helper functions
 
 func (s *SyncService) updateMovieFromTMDB(movie *models.Movie, details *MovieDetails) {
@@ -330,12 +331,15 @@ func (s *SyncService) updateMovieFromTMDB(movie *models.Movie, details *MovieDet
 		}
 	}
 
+	movie.Localizations = s.localizations(details.Translations.Translations, details.Images.Posters)
+
 	// Update timestamp
 	movie.LastUpdated = primitive.NewDateTimeFromTime(time.Now())
 }
 
 func (s *SyncService) updateTVFromTMDB(tv *models.TV, details *TVDetails) {
 	tv.TMDBID = details.ID
+	tv.IMDbID = details.ExternalIDs.IMDbID
 	tv.Description = details.Overview
 	tv.PosterPath = details.PosterPath
 	tv.BackdropPath = details.BackdropPath
@@ -419,57 +423,79 @@ func (s *SyncService) updateTVFromTMDB(tv *models.TV, details *TVDetails) {
 		}
 	}
 
+	tv.Localizations = s.localizations(details.Translations.Translations, details.Images.Posters)
+
 	// Update timestamp
 	tv.LastUpdated = primitive.NewDateTimeFromTime(time.Now())
 }
 
+// syncTVSeasons copies season and episode metadata from TMDB. Episodes are
+// paired by the strategies of episodeMatcher, so files numbered absolutely,
+// split into seasons differently, named by air date or holding specials
+// still find their TMDB episode.
 func (s *SyncService) syncTVSeasons(ctx context.Context, tv *models.TV, details *TVDetails) error {
+	catalog := newEpisodeCatalog(s.tmdbClient, tv.TMDBID, details.Seasons)
+
 	// Create a map of our existing seasons for easier lookup
 	seasonMap := make(map[int]*models.Season)
 	for i := range tv.Seasons {
 		seasonMap[tv.Seasons[i].SeasonNumber] = &tv.Seasons[i]
 	}
 
-	// Process each season from TMDB
+	// Update the seasons TMDB knows with its data; seasons it doesn't have
+	// are skipped as we have no files for them
 	for _, tmdbSeason := range details.Seasons {
-		// Skip season 0 (usually specials)
-		if tmdbSeason.SeasonNumber == 0 {
-			continue
-		}
-
-		// Find or create the season in our data
 		season, exists := seasonMap[tmdbSeason.SeasonNumber]
 		if !exists {
-			// This is a new season that doesn't exist in our data yet
-			// We'll skip it as we don't have files for it
 			continue
 		}
-
-		// Update season with TMDB data
 		season.TMDBID = tmdbSeason.ID
 		season.SeasonName = tmdbSeason.Name
 		season.AirDate = tmdbSeason.AirDate
 		season.PosterPath = tmdbSeason.PosterPath
+	}
 
-		// Get detailed season information
-		seasonDetails, err := s.tmdbClient.GetTVSeasonDetails(tv.TMDBID, tmdbSeason.SeasonNumber)
+	// Seasons with more episodes than TMDB's season of the same number are
+	// split differently. An episode group may number them like we do, if it
+	// has room for the episodes of all our regular seasons.
+	misfits := make(map[int]bool)
+	needed := make(map[int]int)
+	for i := range tv.Seasons {
+		season := &tv.Seasons[i]
+		count, err := catalog.episodeCount(ctx, season.SeasonNumber)
 		if err != nil {
-			return fmt.Errorf("failed to get season details: %w", err)
+			return err
 		}
-
-		// Create a map of our existing episodes for easier lookup
-		episodeMap := make(map[int]*models.Episode)
-		for i := range season.Episodes {
-			episodeMap[season.Episodes[i].EpisodeNo] = &season.Episodes[i]
+		highest := maxEpisodeNo(season)
+		if highest > count {
+			misfits[season.SeasonNumber] = true
+		}
+		if season.SeasonNumber > 0 {
+			needed[season.SeasonNumber] = highest
+		}
+	}
+	matcher := &episodeMatcher{catalog: catalog, misfits: misfits}
+	if len(misfits) > 0 {
+		group, err := s.findEpisodeGroup(ctx, tv.TMDBID, needed)
+		if err != nil {
+			log.Printf("Warning: error looking up episode groups for TV %s: %v", tv.Title, err)
 		}
+		matcher.group = group
+	}
 
-		// Process each episode from TMDB
-		for _, tmdbEpisode := range seasonDetails.Episodes {
-			// Find the episode in our data
-			episode, exists := episodeMap[tmdbEpisode.EpisodeNumber]
-			if !exists {
-				// This episode doesn't exist in our data yet
-				// We'll skip it as we don't have files for it
+	unmatched := 0
+	for i := range tv.Seasons {
+		season := &tv.Seasons[i]
+		for j := range season.Episodes {
+			episode := &season.Episodes[j]
+			tmdbEpisode, strategy, err := matcher.match(ctx, season.SeasonNumber, episode)
+			if err != nil {
+				return err
+			}
+			episode.TMDBMatch = nil
+			if tmdbEpisode == nil {
+				// This episode isn't on TMDB (yet)
+				unmatched++
 				continue
 			}
 
@@ -481,200 +507,18 @@ func (s *SyncService) syncTVSeasons(ctx context.Context, tv *models.TV, details
 			episode.Overview = tmdbEpisode.Overview
 			episode.VoteAverage = tmdbEpisode.VoteAverage
 			episode.VoteCount = tmdbEpisode.VoteCount
-		}
-	}
-
-	return nil
-}
-
-// findBestMovieMatch finds the best match from TMDB results for a movie title
-func findBestMovieMatch(title string, results []MovieResult, yearFromFile string) *MovieResult {
-	if len(results) == 0 {
-		return nil
-	}
-
-	// Extract year from title if present (e.g., "Movie Title (2020)")
-	titleLower := strings.ToLower(title)
-	titleYear := ""
-	titleWithoutYear := titleLower
-
-	yearRegex := regexp.MustCompile(`\((\d{4})\)`)
-	yearMatches := yearRegex.FindStringSubmatch(titleLower)
-	if len(yearMatches) > 1 {
-		titleYear = yearMatches[1]
-		titleWithoutYear = strings.TrimSpace(strings.Replace(titleLower, yearMatches[0], "", 1))
-	}
-
-	// Use year from file name if available and no year in title
-	if titleYear == "" && yearFromFile != "" {
-		titleYear = yearFromFile
-	}
-
-	type scoredResult struct {
-		result *MovieResult
-		score  float64
-	}
-
-	// Score each result
-	var scoredResults []scoredResult
-	for i, result := range results {
-		resultCopy := result // Create a copy to avoid issues with loop variable capture
-		score := 0.0
-
-		// 1. Title similarity score (0-50 points)
-		resultTitle := strings.ToLower(result.Title)
-		if resultTitle == titleWithoutYear {
-			score += 50 // Exact match is best
-		} else {
-			// Calculate string similarity using Levenshtein distance
-			similarity := calculateStringSimilarity(titleWithoutYear, resultTitle)
-			score += similarity * 40 // Up to 40 points for similar titles
-		}
-
-		// 2. Year match score (0-30 points)
-		resultYear := ""
-		if len(result.ReleaseDate) >= 4 {
-			resultYear = result.ReleaseDate[:4]
-		}
-
-		if titleYear != "" && resultYear == titleYear {
-			score += 30 // Exact year match with high weight because we extracted it from filename
-		} else if titleYear != "" && resultYear != "" {
-			// Partial points for close years
-			yearDiff := math.Abs(float64(parseYear(resultYear) - parseYear(titleYear)))
-			if yearDiff <= 1 {
-				score += 20 // Only 1 year off
-			} else if yearDiff <= 2 {
-				score += 10 // 2 years off
-			}
-		}
-
-		// 3. Popularity boost (0-20 points)
-		// More popular movies get a boost, scaled by position in results
-		popScore := math.Min(20, result.Popularity)
-		popBoost := popScore * (1.0 - float64(i)*0.1) // Decrease boost for later results
-		score += popBoost
-
-		scoredResults = append(scoredResults, scoredResult{&resultCopy, score})
-	}
-
-	// Sort by score (highest first)
-	sort.Slice(scoredResults, func(i, j int) bool {
-		return scoredResults[i].score > scoredResults[j].score
-	})
-
-	// Debug logging for top matches
-	for i, sr := range scoredResults {
-		if i < 3 { // Log top 3 matches
-			log.Printf("Match %d: '%s' (%s) - Score: %.2f",
-				i+1,
-				sr.result.Title,
-				getYearFromDate(sr.result.ReleaseDate),
-				sr.score)
-		}
-	}
-
-	// If best match has a very low score, it might not be a good match at all
-	if len(scoredResults) > 0 && scoredResults[0].score >= 30 {
-		return scoredResults[0].result
-	}
-
-	return nil
-}
-
-// findBestTVMatch finds the best match from TMDB results for a TV show title
-func findBestTVMatch(title string, results []TVResult, yearFromFile string) *TVResult {
-	if len(results) == 0 {
-		return nil
-	}
-
-	titleLower := strings.ToLower(title)
-
-	// Extract year if present (e.g., "TV Show (2020)")
-	titleYear := ""
-	titleWithoutYear := titleLower
-
-	yearRegex := regexp.MustCompile(`\((\d{4})\)`)
-	yearMatches := yearRegex.FindStringSubmatch(titleLower)
-	if len(yearMatches) > 1 {
-		titleYear = yearMatches[1]
-		titleWithoutYear = strings.TrimSpace(strings.Replace(titleLower, yearMatches[0], "", 1))
-	}
-
-	// Use year from file name if available and no year in title
-	if titleYear == "" && yearFromFile != "" {
-		titleYear = yearFromFile
-	}
-
-	type scoredResult struct {
-		result *TVResult
-		score  float64
-	}
-
-	// Score each result
-	var scoredResults []scoredResult
-	for i, result := range results {
-		resultCopy := result // Create a copy to avoid issues with loop variable capture
-		score := 0.0
-
-		// 1. Title similarity score (0-50 points)
-		resultTitle := strings.ToLower(result.Name)
-		if resultTitle == titleWithoutYear {
-			score += 50 // Exact match is best
-		} else {
-			// Calculate string similarity
-			similarity := calculateStringSimilarity(titleWithoutYear, resultTitle)
-			score += similarity * 40 // Up to 40 points for similar titles
-		}
-
-		// 2. Year match score (0-30 points)
-		resultYear := ""
-		if len(result.FirstAirDate) >= 4 {
-			resultYear = result.FirstAirDate[:4]
-		}
-
-		if titleYear != "" && resultYear == titleYear {
-			score += 30 // Exact year match with higher weight because we extracted it from filename
-		} else if titleYear != "" && resultYear != "" {
-			// Partial points for close years
-			yearDiff := math.Abs(float64(parseYear(resultYear) - parseYear(titleYear)))
-			if yearDiff <= 1 {
-				score += 20 // Only 1 year off
-			} else if yearDiff <= 2 {
-				score += 10 // 2 years off
+			episode.TMDBMatch = &models.EpisodeMatch{
+				Season:   tmdbEpisode.SeasonNumber,
+				Episode:  tmdbEpisode.EpisodeNumber,
+				Strategy: strategy,
 			}
 		}
-
-		// 3. Popularity boost (0-20 points)
-		popScore := math.Min(20, result.Popularity)
-		popBoost := popScore * (1.0 - float64(i)*0.1) // Decrease boost for later results
-		score += popBoost
-
-		scoredResults = append(scoredResults, scoredResult{&resultCopy, score})
-	}
-
-	// Sort by score (highest first)
-	sort.Slice(scoredResults, func(i, j int) bool {
-		return scoredResults[i].score > scoredResults[j].score
-	})
-
-	// Debug logging for top matches
-	for i, sr := range scoredResults {
-		if i < 3 { // Log top 3 matches
-			log.Printf("Match %d: '%s' (%s) - Score: %.2f",
-				i+1,
-				sr.result.Name,
-				getYearFromDate(sr.result.FirstAirDate),
-				sr.score)
-		}
 	}
-
-	// If best match has a very low score, it might not be a good match at all
-	if len(scoredResults) > 0 && scoredResults[0].score >= 30 {
-		return scoredResults[0].result
+	if unmatched > 0 {
+		log.Printf("%d episodes of %s have no TMDB episode", unmatched, tv.Title)
 	}
 
-	return nil
+	return s.localizeEpisodes(ctx, tv)
 }
 
 // Helper functions for the matching algorithms
@@ -774,24 +618,16 @@ func min(a, b int) int {
 	return b
 }
 
-// extractYearFromFileName extracts the year from a movie file name
+// extractYearFromFile extracts the release year from a file, using the
+// metadata parsed at scrape time when it's there
 // e.g. "Tarzan.Goes.To.India.1962.1080p.BluRay.x264-[YTS.AM].mp4" -> "1962"
-func extractYearFromFileName(fileName string) string {
-	// Look for a 4-digit year in the file name
-	yearRegex := regexp.MustCompile(`\b(19\d{2}|20\d{2})\b`)
-	matches := yearRegex.FindStringSubmatch(fileName)
-
-	if len(matches) > 1 {
-		// Validate that it's a reasonable movie year (1900-current year)
-		year := matches[1]
-		yearNum, err := strconv.Atoi(year)
-		if err == nil {
-			currentYear := time.Now().Year()
-			if yearNum >= 1900 && yearNum <= currentYear {
-				return year
-			}
-		}
+func extractYearFromFile(file models.File) string {
+	release := file.Release
+	if release == nil {
+		release = releaseparse.Parse(file.FileName)
 	}
-
-	return ""
+	if release.Year == 0 {
+		return ""
+	}
+	return strconv.Itoa(release.Year)
 }
@@ -0,0 +1,194 @@
+// Package upstream defines the errors returned by the clients of the sites
+// this project talks to: showbox, febbox and TMDB. Callers decide whether a
+// failure is worth retrying, and how to report it, with errors.Is and
+// errors.As instead of matching on messages.
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Kinds of failures, matched with errors.Is against any of the error types
+// below
+var (
+	ErrRateLimited = errors.New("rate limited")
+	ErrAuth        = errors.New("not authorized") // expired cookie or invalid API key
+	ErrNotFound    = errors.New("not found")
+	ErrServer      = errors.New("server error")
+	ErrParse       = errors.New("unparsable response")
+)
+
+// maxBody is how much of an error response is kept for the message
+const maxBody = 256
+
+// StatusError is a response with an unexpected HTTP status
+type StatusError struct {
+	Service    string // "showbox", "febbox" or "tmdb"
+	StatusCode int
+	Body       string        // start of the response body
+	RetryAfter time.Duration // as asked for in Retry-After, 0 without one
+}
+
+// NewStatusError describes resp, reading the start of its body
+func NewStatusError(service string, resp *http.Response) *StatusError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	return &StatusError{
+		Service:    service,
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("%s: unexpected status %d", e.Service, e.StatusCode)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Is maps the status code to the kinds of failures
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrAuth:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// APIError is a response whose HTTP status says success but whose body
+// reports a failure by an API level code, like febbox's {"code": 0}
+type APIError struct {
+	Service string
+	Code    int
+	Msg     string
+	Kind    error // one of the kinds above, nil when the code doesn't tell
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error: %s (code: %d)", e.Service, e.Msg, e.Code)
+}
+
+func (e *APIError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// ParseError is a response that couldn't be decoded or lacks what the
+// caller needs from it
+type ParseError struct {
+	Service string
+	What    string // what was being parsed, e.g. "share link"
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: failed to parse %s", e.Service, e.What)
+	}
+	return fmt.Sprintf("%s: failed to parse %s: %v", e.Service, e.What, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+func (e *ParseError) Is(target error) bool {
+	return target == ErrParse
+}
+
+// Retryable reports whether another attempt may succeed. Rate limits, 5xx
+// responses, timeouts and dropped connections are; cancellation, API errors
+// and responses that don't parse aren't.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServer) {
+		return true
+	}
+	var statusErr *StatusError
+	var apiErr *APIError
+	if errors.As(err, &statusErr) || errors.As(err, &apiErr) || errors.Is(err, ErrParse) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// RetryAfter returns the delay the site asked for before the next attempt,
+// 0 if it didn't ask
+func RetryAfter(err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
+}
+
+// Kind names the kind of failure for reports: "rate_limited", "auth",
+// "not_found", "server", "parse", "api", "status", "network" or "other"
+func Kind(err error) string {
+	var statusErr *StatusError
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrAuth):
+		return "auth"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrServer):
+		return "server"
+	case errors.Is(err, ErrParse):
+		return "parse"
+	case errors.As(err, &apiErr):
+		return "api"
+	case errors.As(err, &statusErr):
+		return "status"
+	case Retryable(err):
+		return "network"
+	}
+	return "other"
+}
+
+// ParseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date. It returns 0 when the header is missing or invalid.
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
@@ -0,0 +1,118 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// timeoutError is a net.Error reporting a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+		kind      string
+	}{
+		{"nil", nil, false, ""},
+		{"429", &StatusError{StatusCode: 429}, true, "rate_limited"},
+		{"wrapped 429", fmt.Errorf("folder: %w", &StatusError{StatusCode: 429}), true, "rate_limited"},
+		{"500", &StatusError{StatusCode: 500}, true, "server"},
+		{"503", &StatusError{StatusCode: 503}, true, "server"},
+		{"401", &StatusError{StatusCode: 401}, false, "auth"},
+		{"403", &StatusError{StatusCode: 403}, false, "auth"},
+		{"404", &StatusError{StatusCode: 404}, false, "not_found"},
+		{"418", &StatusError{StatusCode: 418}, false, "status"},
+		{"API error with kind", &APIError{Code: 0, Kind: ErrAuth}, false, "auth"},
+		{"rate limited API error", &APIError{Code: 0, Kind: ErrRateLimited}, true, "rate_limited"},
+		{"API error without kind", &APIError{Code: 0}, false, "api"},
+		{"parse error", &ParseError{What: "share link", Err: io.ErrUnexpectedEOF}, false, "parse"},
+		{"timeout", timeoutError{}, true, "network"},
+		{"dial error", &net.OpError{Op: "dial", Err: errors.New("no route to host")}, true, "network"},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true, "network"},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true, "network"},
+		{"cancelled", context.Canceled, false, "other"},
+		{"cancelled mid request", fmt.Errorf("get: %w", context.Canceled), false, "other"},
+		{"anything else", errors.New("boom"), false, "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Retryable(tt.err); got != tt.retryable {
+				t.Errorf("Retryable = %v, want %v", got, tt.retryable)
+			}
+			if got := Kind(tt.err); got != tt.kind {
+				t.Errorf("Kind = %q, want %q", got, tt.kind)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"7", 7 * time.Second, 7 * time.Second},
+		{" 2 ", 2 * time.Second, 2 * time.Second},
+		{"0", 0, 0},
+		{"-3", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := ParseRetryAfter(tt.value); got < tt.min || got > tt.max {
+				t.Errorf("got %s, want between %s and %s", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestNewStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte("  slow down " + strings.Repeat("x", 1000)))
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	statusErr := NewStatusError("febbox", resp)
+	if statusErr.StatusCode != 429 || statusErr.Service != "febbox" {
+		t.Errorf("unexpected error %+v", statusErr)
+	}
+	if len(statusErr.Body) > maxBody || !strings.HasPrefix(statusErr.Body, "slow down") {
+		t.Errorf("got body %q, want the trimmed start of the response", statusErr.Body)
+	}
+	wrapped := fmt.Errorf("share link: %w", statusErr)
+	if got := RetryAfter(wrapped); got != 30*time.Second {
+		t.Errorf("RetryAfter = %s, want 30s", got)
+	}
+	if !errors.Is(wrapped, ErrRateLimited) {
+		t.Error("429 doesn't match ErrRateLimited")
+	}
+	if got := RetryAfter(errors.New("boom")); got != 0 {
+		t.Errorf("RetryAfter of another error = %s, want 0", got)
+	}
+}
@@ -1,17 +1,63 @@
 package febox
 
+import (
+	"os"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/pkg/cookiepool"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/hostlimit"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/httpclient"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/proxypool"
+)
+
 const (
-	ProxyURL    = "https://simple-proxy.ak7702401082.workers.dev?destination="
 	ShowboxBase = "https://showbox.media/"
 	FebboxBase  = "https://www.febbox.com"
 	UserAgent   = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"
 )
 
 type Config struct {
-	MaxConcurrency  int
-	RequestInterval int
-	MaxRetries      int
-	RetryDelay      int
-	HTTPTimeout     int
-	isMovie         bool
+	MaxConcurrency int
+	MaxRetries     int
+	RetryDelay     time.Duration // base of the exponential backoff between attempts
+	HTTPTimeout    int
+	DrainTimeout   int
+
+	// Where requests go. ShowboxBase and FebboxBase fall back to the
+	// production sites when empty. Every request goes through Proxies, or
+	// through ProxyURL and HTTPProxy when it is nil; without any proxy
+	// requests go directly.
+	ShowboxBase string
+	FebboxBase  string
+	ProxyURL    string           // prefix proxy, the target URL is appended to it
+	HTTPProxy   string           // HTTP or SOCKS5 proxy
+	Proxies     *proxypool.Pool  // proxies to spread requests over
+	Cookie      string           // febbox session cookie
+	Cookies     *cookiepool.Pool // accounts to rotate over, Cookie alone when nil
+
+	// Limiter paces requests per host, including the streamer's. Default
+	// rates are used when it is nil.
+	Limiter *hostlimit.Limiter
+
+	// HTTP selects whether responses come from the network or from recorded
+	// fixtures
+	HTTP httpclient.Config
+
+	isMovie bool
+}
+
+// DefaultConfig returns the settings used against the production sites, with
+// the proxy and cookie taken from PROXY_URL and FEBBOX_COOKIE
+func DefaultConfig() *Config {
+	return &Config{
+		MaxConcurrency: 5,
+		MaxRetries:     3,
+		RetryDelay:     2 * time.Second,
+		HTTPTimeout:    120,
+		ShowboxBase:    ShowboxBase,
+		FebboxBase:     FebboxBase,
+		HTTPProxy:      os.Getenv("PROXY_URL"),
+		Cookie:         os.Getenv("FEBBOX_COOKIE"),
+		HTTP:           httpclient.ConfigFromEnv(),
+	}
 }
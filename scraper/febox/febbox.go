@@ -4,13 +4,11 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"net/url"
-	"os"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,6 +17,9 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/releaseparse"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
 )
 
 // FebboxResponse represents the top level response structure
@@ -50,10 +51,10 @@ type FebboxFile struct {
 
 // Episode info from filename
 type EpisodeInfo struct {
-	Season  int
-	Episode int
-	Quality string
-	Codec   string
+	Season   int
+	Episodes []int // every episode in the file, e.g. 1 and 2 for S01E01E02
+	Quality  string
+	Codec    string
 }
 
 // ScrapeContentType defines the type of content being scraped
@@ -65,7 +66,7 @@ const (
 )
 
 // ScrapeContent is a general function that can scrape both movies and TV series
-func (s *Scraper) ScrapeContent(content interface{}, idx int) error {
+func (s *Scraper) ScrapeContent(ctx context.Context, content interface{}, idx int) error {
 	var contentID, contentTitle string
 	var contentType ContentType
 
@@ -82,12 +83,36 @@ func (s *Scraper) ScrapeContent(content interface{}, idx int) error {
 	default:
 		return fmt.Errorf("unsupported content type: %T", content)
 	}
-	encodedurl := url.QueryEscape(fmt.Sprintf("%s/index/share_link?id=%s&type=%d", ShowboxBase, contentID, contentType))
-	shoemediaUrl := fmt.Sprintf("%s%s", ProxyURL, encodedurl)
-	req, err := http.NewRequest("GET", shoemediaUrl, nil)
+	link, err := s.shareLink(ctx, contentID, contentType, idx)
+	if err != nil {
+		return err
+	}
+
+	if s.isVisited(link) {
+		log.Printf("Already visited: %s", link)
+		return nil
+	}
+
+	// Process the specific content type
+	switch contentType {
+	case MovieType:
+		log.Printf("Scraping movie: %s", contentTitle)
+		return s.scrapeMovieDetails(ctx, link, content.(*models.Movie), idx)
+	case TVType:
+		log.Printf("Scraping TV series: %s", contentTitle)
+		return s.scrapeSeriesDetails(ctx, link, content.(*models.TV))
+	}
+
+	return nil
+}
+
+// shareLink asks showbox for the febbox share link of a title
+func (s *Scraper) shareLink(ctx context.Context, contentID string, contentType ContentType, idx int) (string, error) {
+	shoemediaUrl := fmt.Sprintf("%s/index/share_link?id=%s&type=%d", s.config.ShowboxBase, contentID, contentType)
+	req, err := http.NewRequestWithContext(ctx, "GET", shoemediaUrl, nil)
 	if err != nil {
 		log.Printf("Error creating request for content %s: %v", contentID, err)
-		return fmt.Errorf("request creation failed: %w", err)
+		return "", fmt.Errorf("request creation failed: %w", err)
 	}
 
 	req.Header.Set("User-Agent", UserAgent)
@@ -95,17 +120,17 @@ func (s *Scraper) ScrapeContent(content interface{}, idx int) error {
 	res, err := s.client.Do(req)
 	if err != nil {
 		log.Printf("Error fetching data for content %s: %v", contentID, err)
-		return fmt.Errorf("request failed: %w", err)
+		return "", fmt.Errorf("request failed: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode == http.StatusTooManyRequests {
 		log.Printf("Rate limited while fetching content %s: %s, %d", contentID, res.Status, idx)
-		return fmt.Errorf("rate limited: status %d", res.StatusCode)
+		return "", upstream.NewStatusError("showbox", res)
 	}
 	if res.StatusCode != http.StatusOK {
 		log.Printf("Unexpected response for content %s: %s", contentID, res.Status)
-		return fmt.Errorf("unexpected status: %d", res.StatusCode)
+		return "", upstream.NewStatusError("showbox", res)
 	}
 
 	var output struct {
@@ -113,50 +138,39 @@ func (s *Scraper) ScrapeContent(content interface{}, idx int) error {
 			Link string `json:"link"`
 		} `json:"data"`
 	}
-	if err = json.NewDecoder(res.Body).Decode(&output); err != nil {
+	if err := json.NewDecoder(res.Body).Decode(&output); err != nil {
 		log.Printf("Error decoding response for content %s: %v", contentID, err)
-		return fmt.Errorf("response decoding failed: %w", err)
+		return "", &upstream.ParseError{Service: "showbox", What: "share link", Err: err}
 	}
-
-	if s.isVisited(output.Data.Link) {
-		log.Printf("Already visited: %s", output.Data.Link)
-		return nil
+	if output.Data.Link == "" {
+		return "", fmt.Errorf("content %s: %w", contentID, ErrShareNotFound)
 	}
 
-	// Process the specific content type
-	switch contentType {
-	case MovieType:
-		log.Printf("Scraping movie: %s", contentTitle)
-		s.scrapeMovieDetails(output.Data.Link, content.(*models.Movie), idx)
-	case TVType:
-		log.Printf("Scraping TV series: %s", contentTitle)
-		s.scrapeSeriesDetails(output.Data.Link, content.(*models.TV))
-	}
-
-	return nil
+	return output.Data.Link, nil
 }
 
 // scrapeMovie is kept for backward compatibility
-func (s *Scraper) scrapeMovie(movie *models.Movie, idx int) error {
-	return s.ScrapeContent(movie, idx)
+func (s *Scraper) scrapeMovie(ctx context.Context, movie *models.Movie, idx int) error {
+	return s.ScrapeContent(ctx, movie, idx)
 }
 
-func (s *Scraper) scrapeSeriesDetails(link string, tv *models.TV) error {
+func (s *Scraper) scrapeSeriesDetails(ctx context.Context, link string, tv *models.TV) error {
 	var err error
 	maxRetries := s.config.MaxRetries
-	baseDelay := time.Duration(s.config.RetryDelay) * time.Second
+	baseDelay := s.config.RetryDelay
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			// Calculate exponential backoff delay
-			delay := baseDelay * time.Duration(1<<(attempt-1))
+			delay := backoff(err, baseDelay, attempt)
 			log.Printf("Retry attempt %d/%d for TV series %s, waiting for %v",
 				attempt, maxRetries, tv.Title, delay)
-			time.Sleep(delay)
+			if err := sleepContext(ctx, delay); err != nil {
+				return err
+			}
 		}
 
 		// Attempt to scrape the series
-		err = s.doScrapeSeriesDetails(link, tv)
+		err = s.doScrapeSeriesDetails(ctx, link, tv)
 
 		// If successful or it's a non-retryable error, return
 		if err == nil {
@@ -166,9 +180,10 @@ func (s *Scraper) scrapeSeriesDetails(link string, tv *models.TV) error {
 			return nil
 		}
 
-		// If it's not a retryable error, don't retry
-		if !isRetryableError(err) {
-			log.Printf("Non-retryable error encountered for TV series %s: %v", tv.Title, err)
+		// If it's not a retryable error, don't retry. Rate limits are
+		// retried for the whole title by the caller.
+		if !isRetryableError(err) || isRateLimitError(err) {
+			log.Printf("Not retrying error encountered for TV series %s: %v", tv.Title, err)
 			return err
 		}
 
@@ -185,118 +200,42 @@ func (s *Scraper) scrapeSeriesDetails(link string, tv *models.TV) error {
 	return err
 }
 
-// Helper function to determine if an error is retryable
+// isRetryableError reports whether another attempt at a request may succeed
 func isRetryableError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	// Rate limiting is always retryable
-	if strings.Contains(err.Error(), "rate limited") ||
-		strings.Contains(err.Error(), "429") {
-		return true
-	}
-
-	// Network-related errors are usually retryable
-	if strings.Contains(err.Error(), "connection") ||
-		strings.Contains(err.Error(), "timeout") ||
-		strings.Contains(err.Error(), "reset by peer") ||
-		strings.Contains(err.Error(), "EOF") {
-		return true
-	}
-
-	// 5xx server errors are retryable
-	if strings.Contains(err.Error(), "5") &&
-		(strings.Contains(err.Error(), "status") || strings.Contains(err.Error(), "code")) {
-		return true
-	}
-
-	return false
+	return upstream.Retryable(err)
 }
 
 // Actual implementation of series details scraping
-func (s *Scraper) doScrapeSeriesDetails(link string, tv *models.TV) error {
-	proxyurl := os.Getenv("PROXY_URL")
-	contentID := strings.Split(link, "/")[len(strings.Split(link, "/"))-1]
-	proxy, err := url.Parse(proxyurl)
-	if err != nil {
-		log.Printf("Error parsing proxy URL: %v", err)
-		return fmt.Errorf("error parsing proxy URL: %w", err)
-	}
-	client := &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyURL(proxy),
-		},
-	}
-	encodedurl := url.QueryEscape(link)
-	shoemediaUrl := fmt.Sprintf("%s%s", ProxyURL, encodedurl)
-	log.Printf("Request URL: %s", shoemediaUrl)
-	req, err := http.NewRequest("GET", shoemediaUrl, nil)
-	if err != nil {
-		log.Printf("Error creating request for link %s: %v", link, err)
-		return fmt.Errorf("error creating request for link %s: %w", link, err)
+func (s *Scraper) doScrapeSeriesDetails(ctx context.Context, link string, tv *models.TV) error {
+	builder := newSeasonBuilder()
+	if err := s.scrapeSeasons(ctx, link, nil, builder); err != nil {
+		return err
 	}
-	req.Header.Set("Cookie", os.Getenv("FEBBOX_COOKIE"))
 
-	res, err := client.Do(req)
-	if err != nil {
-		log.Printf("Error fetching data for link %s: %v", link, err)
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode == http.StatusTooManyRequests {
-		log.Printf("Rate limited while fetching link %s: %s", link, res.Status)
-		return fmt.Errorf("rate limited: status %d", res.StatusCode)
+	seasons := builder.build()
+	if len(seasons) == 0 {
+		return fmt.Errorf("no valid seasons found for TV series %s", tv.Title)
 	}
-
-	doc, err := goquery.NewDocumentFromReader(res.Body)
-	if err != nil {
-		log.Printf("Error parsing HTML: %v", err)
-		return fmt.Errorf("error parsing HTML: %w", err)
+	for _, season := range seasons {
+		log.Printf("Found season %d with %d episodes for %s",
+			season.SeasonNumber, len(season.Episodes), tv.Title)
 	}
+	tv.Seasons = seasons
 
-	seasonFound := false
-	doc.Find(".f_list_scroll div[data-id]").Each(func(i int, s *goquery.Selection) {
-		parentID, exists := s.Attr("data-id")
-		seasonName := s.Find("p.file_name").Text()
-		seasonNumber := i + 1
-
-		if exists {
-			episodes, episodeErr := getSeasonsEpisodes(contentID, parentID)
-			if episodeErr != nil {
-				log.Printf("Error getting episodes for season %d: %v", seasonNumber, episodeErr)
-				return
-			}
-
-			if len(episodes) > 0 {
-				seasonFound = true
-				season := models.Season{
-					SeasonID:     fmt.Sprintf("season_%d", seasonNumber),
-					SeasonName:   seasonName,
-					SeasonNumber: seasonNumber,
-					Size:         calculateTotalEpisodesSize(episodes),
-					Episodes:     episodes,
-				}
-				log.Printf("Found season %d with %d episodes for %s",
-					seasonNumber, len(episodes), tv.Title)
-				tv.Seasons = append(tv.Seasons, season)
-			}
-		}
-	})
-
-	if !seasonFound {
-		return fmt.Errorf("no valid seasons found for TV series %s", tv.Title)
+	// Files resolved after every account was logged out or over its quota
+	// have no links, so the title fails and gets scraped again later
+	if err := s.config.Cookies.Available(); err != nil {
+		return err
 	}
 
 	// Save TV series to database
 	if s.dbRepo != nil && len(tv.Seasons) > 0 {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 		defer cancel()
 
 		// Check if TV series already exists
 		existingTV, err := s.dbRepo.GetTVById(ctx, tv.TVID)
-		if err != nil && !strings.Contains(err.Error(), "no TV series found") {
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
 			log.Printf("Error checking for existing TV series: %v", err)
 		}
 
@@ -329,6 +268,70 @@ func (s *Scraper) doScrapeSeriesDetails(link string, tv *models.TV) error {
 	return nil
 }
 
+// scrapeSeasons lists the season folders of a share link and adds their
+// episodes to builder. Files whose FID is in known are left out.
+func (s *Scraper) scrapeSeasons(ctx context.Context, link string, known map[int64]bool, builder *seasonBuilder) error {
+	contentID := strings.Split(link, "/")[len(strings.Split(link, "/"))-1]
+	log.Printf("Request URL: %s", link)
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		log.Printf("Error creating request for link %s: %v", link, err)
+		return fmt.Errorf("error creating request for link %s: %w", link, err)
+	}
+	account, err := s.config.Cookies.Acquire()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Cookie", account.Cookie)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		log.Printf("Error fetching data for link %s: %v", link, err)
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		log.Printf("Unexpected response for link %s: %s", link, res.Status)
+		return upstream.NewStatusError("febbox", res)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		log.Printf("Error parsing HTML: %v", err)
+		return fmt.Errorf("error parsing HTML: %w", err)
+	}
+
+	// Folders don't reliably map to seasons (specials, missing or out of
+	// order seasons), so episodes are regrouped by the season parsed from
+	// their file names, falling back to the folder name
+	var folderErr error
+	doc.Find(".f_list_scroll div[data-id]").EachWithBreak(func(i int, sel *goquery.Selection) bool {
+		parentID, exists := sel.Attr("data-id")
+		folderName := strings.TrimSpace(sel.Find("p.file_name").Text())
+		folderSeason := parseSeasonFolder(folderName)
+
+		if exists {
+			episodesBySeason, episodeErr := s.getSeasonsEpisodes(ctx, contentID, parentID, folderSeason, known, builder.lastEpisode(0))
+			if episodeErr != nil {
+				log.Printf("Error getting episodes for folder %q: %v", folderName, episodeErr)
+				// Saving the show without this folder would mark it done
+				// with seasons missing, so the title is retried or failed
+				// instead when another attempt could get the folder
+				if isRetryableError(episodeErr) || ctx.Err() != nil {
+					folderErr = fmt.Errorf("folder %q: %w", folderName, episodeErr)
+					return false
+				}
+				return true
+			}
+			builder.add(folderName, folderSeason, episodesBySeason)
+		}
+		return true
+	})
+
+	return folderErr
+}
+
 // Helper function to calculate total season size from episodes
 func calculateTotalEpisodesSize(episodes []models.Episode) int {
 	var totalSize int
@@ -338,23 +341,26 @@ func calculateTotalEpisodesSize(episodes []models.Episode) int {
 	return totalSize
 }
 
-func getSeasonsEpisodes(shareKey, parentID string) ([]models.Episode, error) {
-	url := fmt.Sprintf("%s/file/file_share_list?share_key=%s&pwd=&parent_id=%s&is_html=0", FebboxBase, shareKey, parentID)
+// getSeasonsEpisodes lists a share folder and returns its episodes grouped by season
+func (s *Scraper) getSeasonsEpisodes(ctx context.Context, shareKey, parentID string, folderSeason int, known map[int64]bool, lastSpecial int) (map[int][]models.Episode, error) {
+	url := fmt.Sprintf("%s/file/file_share_list?share_key=%s&pwd=&parent_id=%s&is_html=0", s.config.FebboxBase, shareKey, parentID)
 
 	maxRetries := 3
 	baseDelay := 2 * time.Second
-	var episodes []models.Episode
+	var episodes map[int][]models.Episode
 	var err error
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			delay := baseDelay * time.Duration(1<<(attempt-1))
+			delay := backoff(err, baseDelay, attempt)
 			log.Printf("Retry attempt %d/%d for getting episodes (parent_id: %s), waiting for %v",
 				attempt, maxRetries, parentID, delay)
-			time.Sleep(delay)
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
 		}
 
-		episodes, err = doGetSeasonsEpisodes(url)
+		episodes, err = s.doGetSeasonsEpisodes(ctx, url, folderSeason, known, lastSpecial)
 
 		// If successful, return the episodes
 		if err == nil {
@@ -365,9 +371,10 @@ func getSeasonsEpisodes(shareKey, parentID string) ([]models.Episode, error) {
 			return episodes, nil
 		}
 
-		// If it's not a retryable error, don't retry
-		if !isRetryableError(err) {
-			log.Printf("Non-retryable error retrieving episodes: %v", err)
+		// If it's not a retryable error, don't retry. Rate limits fail the
+		// title, which the caller retries as a whole.
+		if !isRetryableError(err) || isRateLimitError(err) {
+			log.Printf("Not retrying error retrieving episodes: %v", err)
 			return nil, err
 		}
 
@@ -383,104 +390,110 @@ func getSeasonsEpisodes(shareKey, parentID string) ([]models.Episode, error) {
 	return nil, err
 }
 
-func doGetSeasonsEpisodes(url string) ([]models.Episode, error) {
+func (s *Scraper) doGetSeasonsEpisodes(ctx context.Context, url string, folderSeason int, known map[int64]bool, lastSpecial int) (map[int][]models.Episode, error) {
 	log.Println("Fetching episodes from URL:", url)
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	resp, err := s.client.Do(req)
 	if err != nil {
 		log.Printf("Error getting file info: %v", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return nil, fmt.Errorf("rate limited: status %d", resp.StatusCode)
-	}
-
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, upstream.NewStatusError("febbox", resp)
 	}
 
 	var febboxResp FebboxResponse
 	if err = json.NewDecoder(resp.Body).Decode(&febboxResp); err != nil {
 		log.Printf("Error decoding file info: %v", err)
-		return nil, err
+		return nil, &upstream.ParseError{Service: "febbox", What: "file list", Err: err}
 	}
 
 	if febboxResp.Code != 1 {
-		return nil, fmt.Errorf("API error: %s (code: %d)", febboxResp.Msg, febboxResp.Code)
+		return nil, &upstream.APIError{Service: "febbox", Code: febboxResp.Code, Msg: febboxResp.Msg}
 	}
 
-	return processFileList(febboxResp.Data.FileList)
+	return s.processFileList(ctx, febboxResp.Data.FileList, folderSeason, known, lastSpecial)
 }
 
-func (s *Scraper) scrapeMovieDetails(link string, movie *models.Movie, idx int) {
-	proxyurl := os.Getenv("PROXY_URL")
-	proxy, err := url.Parse(proxyurl)
+func (s *Scraper) scrapeMovieDetails(ctx context.Context, link string, movie *models.Movie, idx int) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
 	if err != nil {
-		log.Printf("Error parsing proxy URL: %v", err)
-		return
-	}
-	client := &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyURL(proxy),
-		},
+		log.Printf("Error creating request for link %s: %v", link, err)
+		return fmt.Errorf("error creating request for link %s: %w", link, err)
 	}
-	req, err := http.NewRequest("GET", ProxyURL+link, nil)
+	account, err := s.config.Cookies.Acquire()
 	if err != nil {
-		log.Printf("Error creating request for link %s: %v", link, err)
-		return
+		return err
 	}
-	req.Header.Set("Cookie", os.Getenv("FEBBOX_COOKIE"))
+	req.Header.Set("Cookie", account.Cookie)
 
 	req.Header.Set("User-Agent", UserAgent)
-	res, err := client.Do(req)
+	res, err := s.client.Do(req)
 	if err != nil {
 		log.Printf("Error scraping link %s: %v %d", link, err, idx)
-		return
+		return fmt.Errorf("request failed: %w", err)
 	}
 	defer res.Body.Close()
-	if res.StatusCode == http.StatusTooManyRequests {
-		time.Sleep(time.Duration(2) * time.Second)
-		log.Printf("Rate limited while fetching movie %s: %s %s %d", movie.ID, res.Status, link, idx)
-		s.scrapeMovieDetails(link, movie, idx)
-		log.Printf("Retrying after 2 seconds : %s", link)
-		return
+	if res.StatusCode != http.StatusOK {
+		// Rate limited titles are retried by the caller, after the delay
+		// febbox asks for
+		log.Printf("Unexpected response for movie %s: %s %s %d", movie.ID, res.Status, link, idx)
+		return upstream.NewStatusError("febbox", res)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
 		log.Printf("Error parsing HTML: %v", err)
-		return
+		return fmt.Errorf("error parsing HTML: %w", err)
 	}
 
 	var files []models.File
-	doc.Find(".f_list_scroll div[data-id]").Each(func(i int, s *goquery.Selection) {
+	doc.Find(".f_list_scroll div[data-id]").Each(func(i int, sel *goquery.Selection) {
 		//log.Println("reached")
-		fileID, exists := s.Attr("data-id")
+		fileID, exists := sel.Attr("data-id")
 		if exists {
-			file, _ := getFileDetails(fileID)
+			file, _ := s.getFileDetails(ctx, fileID)
 			if file.FID != 0 {
 				files = append(files, file)
 			}
 		}
 	})
 
+	// Files resolved after every account was logged out or over its quota
+	// have no links, so the title fails and gets scraped again later
+	if err := s.config.Cookies.Available(); err != nil {
+		return err
+	}
+
 	movieModel := &models.Movie{
 		Title:       movie.Title,
 		Description: movie.Description,
-		MovieID:     movie.ID.String(),
+		MovieID:     movie.MovieID,
+		IMDbID:      movie.IMDbID,
 		Files:       files,
 	}
 
-	if err := s.dbRepo.CreateMovie(context.Background(), movieModel); err != nil && len(files) > 0 {
+	if s.dbRepo == nil {
+		log.Println("Database repository not initialized, skipping movie save")
+	} else if err := s.dbRepo.CreateMovie(ctx, movieModel); err != nil && len(files) > 0 {
 		log.Printf("Error saving movie to database: %v", err)
-		return
+		return fmt.Errorf("database save failed: %w", err)
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no files found for movie %s", movie.Title)
 	}
 
 	log.Printf("Successfully saved movie: %s", movieModel.Title)
+	return nil
 }
 
-func getFileDetails(fileid string) (models.File, error) {
+func (s *Scraper) getFileDetails(ctx context.Context, fileid string) (models.File, error) {
 	maxRetries := 3
 	baseDelay := 2 * time.Second
 	var detailedFile models.File
@@ -488,13 +501,15 @@ func getFileDetails(fileid string) (models.File, error) {
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			delay := baseDelay * time.Duration(1<<(attempt-1))
+			delay := backoff(err, baseDelay, attempt)
 			log.Printf("Retry attempt %d/%d for file details (fid: %s), waiting for %v",
 				attempt, maxRetries, fileid, delay)
-			time.Sleep(delay)
+			if err := sleepContext(ctx, delay); err != nil {
+				return models.File{}, err
+			}
 		}
 
-		detailedFile, err = doGetFileDetails(fileid)
+		detailedFile, err = s.doGetFileDetails(ctx, fileid)
 
 		// If successful, return the file details
 		if err == nil {
@@ -527,34 +542,34 @@ func getFileDetails(fileid string) (models.File, error) {
 	return models.File{}, err
 }
 
-func doGetFileDetails(fileid string) (models.File, error) {
-	url := fmt.Sprintf("%s/file/file_info?fid=%s", FebboxBase, fileid)
-	resp, err := http.Get(url)
+func (s *Scraper) doGetFileDetails(ctx context.Context, fileid string) (models.File, error) {
+	url := fmt.Sprintf("%s/file/file_info?fid=%s", s.config.FebboxBase, fileid)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return models.File{}, fmt.Errorf("request creation failed: %w", err)
+	}
+	resp, err := s.client.Do(req)
 	if err != nil {
 		log.Printf("Error getting file info: %v", err)
 		return models.File{}, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return models.File{}, fmt.Errorf("rate limited: status %d", resp.StatusCode)
-	}
-
 	if resp.StatusCode != http.StatusOK {
-		return models.File{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return models.File{}, upstream.NewStatusError("febbox", resp)
 	}
 
 	var data FileResponse
 	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
 		log.Printf("Error decoding file info: %v", err)
-		return models.File{}, err
+		return models.File{}, &upstream.ParseError{Service: "febbox", What: "file info", Err: err}
 	}
 
 	if data.Data.File.Fid == 0 {
-		return models.File{}, fmt.Errorf("invalid or empty file data returned")
+		return models.File{}, &upstream.ParseError{Service: "febbox", What: "file info", Err: errors.New("empty file data")}
 	}
 
-	links := GetQualities(fileid)
+	links := s.GetQualities(ctx, data.Data.File.Fid)
 
 	return models.File{
 		FID:      data.Data.File.Fid,
@@ -562,115 +577,167 @@ func doGetFileDetails(fileid string) (models.File, error) {
 		Size:     data.Data.File.Size,
 		ThumbURL: data.Data.File.Thumbnail,
 		Links:    links,
+		Release:  releaseparse.Parse(data.Data.File.Filename),
 	}, nil
 }
 
 // Helper function to extract season and episode info from filename
 func extractEpisodeInfo(filename string) (EpisodeInfo, error) {
-	// Common regex patterns for TV episode filenames
-	patterns := []string{
-		`[Ss](\d+)[Ee](\d+)`, // S03E05, s03e05
-		`\.(\d+)x(\d+)\.`,    // .3x05.
-	}
-
-	var season, episode int
-	var matched bool
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(filename)
-		if len(matches) == 3 {
-			season, _ = strconv.Atoi(matches[1])
-			episode, _ = strconv.Atoi(matches[2])
-			matched = true
-			break
-		}
-	}
-
-	if !matched {
+	release := releaseparse.Parse(filename)
+	if !release.IsEpisode() {
 		return EpisodeInfo{}, fmt.Errorf("could not extract episode info from: %s", filename)
 	}
 
-	// Extract quality and codec info
+	quality, codec := qualityAndCodec(release)
+
+	return EpisodeInfo{
+		Season:   release.Season,
+		Episodes: release.Episodes,
+		Quality:  quality,
+		Codec:    codec,
+	}, nil
+}
+
+// Helper function to extract quality and codec info from filename
+func extractQualityAndCodec(filename string) (string, string) {
+	return qualityAndCodec(releaseparse.Parse(filename))
+}
+
+// qualityAndCodec maps parsed release info to the labels stored as source
+// names, which existing documents already use
+func qualityAndCodec(release *releaseparse.Release) (string, string) {
 	quality := "Standard"
-	if strings.Contains(filename, "1080p") {
-		quality = "1080p"
-	} else if strings.Contains(filename, "720p") {
-		quality = "720p"
-	} else if strings.Contains(filename, "2160p") || strings.Contains(filename, "4K") {
+	switch release.Resolution {
+	case "1080p", "720p":
+		quality = release.Resolution
+	case "2160p":
 		quality = "4K"
 	}
 
 	codec := "Unknown"
-	if strings.Contains(filename, "x265") || strings.Contains(filename, "HEVC") {
+	switch release.Codec {
+	case releaseparse.CodecHEVC:
 		codec = "HEVC/x265"
-	} else if strings.Contains(filename, "x264") || strings.Contains(filename, "h264") {
+	case releaseparse.CodecH264:
 		codec = "H.264/x264"
-	} else if strings.Contains(filename, "AV1") {
+	case releaseparse.CodecAV1:
 		codec = "AV1"
 	}
 
-	return EpisodeInfo{
-		Season:  season,
-		Episode: episode,
-		Quality: quality,
-		Codec:   codec,
-	}, nil
+	return quality, codec
 }
 
-// Process the file list and convert it to Episodes
-func processFileList(files []FebboxFile) ([]models.Episode, error) {
+// Process the file list and convert it to Episodes grouped by season.
+// folderSeason is the season parsed from the share folder name, or noSeason;
+// it's only used for files whose names don't carry a season themselves.
+// Files whose FID is in known are skipped, and unnumbered specials are
+// numbered after lastSpecial, the highest special already stored or scraped,
+// so new specials never take the number of an existing one.
+func (s *Scraper) processFileList(ctx context.Context, files []FebboxFile, folderSeason int, known map[int64]bool, lastSpecial int) (map[int][]models.Episode, error) {
+	type episodeKey struct {
+		season, episode int
+	}
+
 	// Group files by episode
-	episodeMap := make(map[string][]FebboxFile)
+	episodeMap := make(map[episodeKey][]FebboxFile)
+	var unnumbered []FebboxFile
 
 	for _, file := range files {
+		if known[int64(file.Fid)] {
+			continue
+		}
 		info, err := extractEpisodeInfo(file.FileName)
 		if err != nil {
-			// Skip files where we can't extract episode info
-			continue
+			if folderSeason == noSeason {
+				// Skip files where we can't extract episode info
+				continue
+			}
+			episode, ok := extractEpisodeNumber(file.FileName)
+			if !ok {
+				if folderSeason == 0 {
+					unnumbered = append(unnumbered, file)
+				}
+				continue
+			}
+			info.Season, info.Episodes = folderSeason, []int{episode}
+		}
+
+		// Multi-episode files are a source of every episode they contain
+		for _, episode := range info.Episodes {
+			key := episodeKey{info.Season, episode}
+			episodeMap[key] = append(episodeMap[key], file)
 		}
+	}
 
-		// Create a key in format "S{season}E{episode}" (e.g., "S3E5")
-		key := fmt.Sprintf("S%dE%d", info.Season, info.Episode)
-		episodeMap[key] = append(episodeMap[key], file)
+	// Specials often have no episode number at all; number them by file
+	// name after the highest numbered special
+	if len(unnumbered) > 0 {
+		sort.Slice(unnumbered, func(i, j int) bool {
+			return unnumbered[i].FileName < unnumbered[j].FileName
+		})
+		next := lastSpecial + 1
+		for key := range episodeMap {
+			if key.season == 0 && key.episode >= next {
+				next = key.episode + 1
+			}
+		}
+		for _, file := range unnumbered {
+			episodeMap[episodeKey{0, next}] = []FebboxFile{file}
+			next++
+		}
+	}
+
+	// Multi-episode files belong to several episodes, but their details and
+	// links are fetched once and shared
+	var unique []FebboxFile
+	seen := make(map[int]bool)
+	for _, files := range episodeMap {
+		for _, file := range files {
+			if !seen[file.Fid] {
+				seen[file.Fid] = true
+				unique = append(unique, file)
+			}
+		}
+	}
+	resolved, err := s.resolveFiles(ctx, unique)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create episodes from grouped files
-	var episodes []models.Episode
+	episodes := make(map[int][]models.Episode)
 
 	for key, files := range episodeMap {
-		// Extract season and episode numbers from the key
-		var seasonNum, episodeNum int
-		fmt.Sscanf(key, "S%dE%d", &seasonNum, &episodeNum)
-
 		// Create a new episode
 		episode := models.Episode{
-			EpisodeID:   generateID(key),
-			EpisodeName: fmt.Sprintf("Episode %d", episodeNum),
-			EpisodeNo:   episodeNum,
+			EpisodeID:   generateID(fmt.Sprintf("S%dE%d", key.season, key.episode)),
+			EpisodeName: fmt.Sprintf("Episode %d", key.episode),
+			EpisodeNo:   key.episode,
 			Size:        calculateTotalSize(files),
-			Sources:     groupFilesBySource(files),
+			Sources:     groupFilesBySource(files, resolved),
 		}
 
-		episodes = append(episodes, episode)
+		episodes[key.season] = append(episodes[key.season], episode)
 	}
 
 	// Sort episodes by episode number
-	sort.Slice(episodes, func(i, j int) bool {
-		return episodes[i].EpisodeNo < episodes[j].EpisodeNo
-	})
+	for _, seasonEpisodes := range episodes {
+		sort.Slice(seasonEpisodes, func(i, j int) bool {
+			return seasonEpisodes[i].EpisodeNo < seasonEpisodes[j].EpisodeNo
+		})
+	}
 
 	return episodes, nil
 }
 
-// Group files by source, creating Source structs
-func groupFilesBySource(files []FebboxFile) []models.Source {
+// Group files by source, creating Source structs from the resolved files
+func groupFilesBySource(files []FebboxFile, resolved map[int64]models.File) []models.Source {
 	// Group files by source (using codec as the grouping factor)
 	sourceMap := make(map[string][]FebboxFile)
 
 	for _, file := range files {
-		info, _ := extractEpisodeInfo(file.FileName)
-		sourceMap[info.Codec] = append(sourceMap[info.Codec], file)
+		_, codec := extractQualityAndCodec(file.FileName)
+		sourceMap[codec] = append(sourceMap[codec], file)
 	}
 
 	// Create Source structs from grouped files
@@ -680,19 +747,28 @@ func groupFilesBySource(files []FebboxFile) []models.Source {
 		source := models.Source{
 			SourceID:   generateID(codec),
 			SourceName: codec,
-			Files:      createEpisodeFiles(files),
+			Files:      make([]models.File, 0, len(files)),
 		}
+		for _, file := range files {
+			source.Files = append(source.Files, resolved[int64(file.Fid)])
+		}
+		// Sort the files to ensure consistent ordering despite concurrent processing
+		sort.Slice(source.Files, func(i, j int) bool {
+			return source.Files[i].FID < source.Files[j].FID
+		})
 		sources = append(sources, source)
 	}
 
 	return sources
 }
 
-// Create File structs from FebboxFile
-func createEpisodeFiles(files []FebboxFile) []models.File {
-	var episodeFiles []models.File
+// resolveFiles fetches the details and links of each file, keyed by the FID
+// of the listing. It gives up with ctx's error once ctx is done.
+func (s *Scraper) resolveFiles(ctx context.Context, files []FebboxFile) (map[int64]models.File, error) {
+	resolved := make(map[int64]models.File, len(files))
 
-	// Use a channel with buffer to limit concurrent API calls
+	// Bound the goroutines per folder; how fast their requests go out is
+	// up to the host limiter shared by the whole scraper
 	sem := make(chan struct{}, 5)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -702,12 +778,14 @@ func createEpisodeFiles(files []FebboxFile) []models.File {
 		go func(file FebboxFile) {
 			defer wg.Done()
 
-			sem <- struct{}{}        // Acquire semaphore
-			defer func() { <-sem }() // Release semaphore
+			if !acquire(ctx, sem) {
+				return
+			}
+			defer func() { <-sem }()
 
 			// Try to get detailed file information
 			fileID := strconv.Itoa(file.Fid)
-			detailedFile, err := getFileDetails(fileID)
+			detailedFile, err := s.getFileDetails(ctx, fileID)
 
 			// If there was an error or if the FID is 0 (fallback empty file), create a basic file
 			if err != nil || detailedFile.FID == 0 {
@@ -717,6 +795,7 @@ func createEpisodeFiles(files []FebboxFile) []models.File {
 					FID:      int64(file.Fid),
 					Size:     file.FileSize,
 					ThumbURL: file.Thumb,
+					Release:  releaseparse.Parse(file.FileName),
 					// Note: Links will be empty here
 				}
 
@@ -727,7 +806,7 @@ func createEpisodeFiles(files []FebboxFile) []models.File {
 			}
 
 			mu.Lock()
-			episodeFiles = append(episodeFiles, detailedFile)
+			resolved[int64(file.Fid)] = detailedFile
 			mu.Unlock()
 
 		}(file)
@@ -735,12 +814,10 @@ func createEpisodeFiles(files []FebboxFile) []models.File {
 
 	wg.Wait()
 
-	// Sort the files to ensure consistent ordering despite concurrent processing
-	sort.Slice(episodeFiles, func(i, j int) bool {
-		return episodeFiles[i].FID < episodeFiles[j].FID
-	})
-
-	return episodeFiles
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return resolved, nil
 }
 
 // Helper function to generate a unique ID
@@ -760,74 +837,13 @@ func calculateTotalSize(files []FebboxFile) int {
 	return int(totalSize / (1024 * 1024))
 }
 
-func GetQualities(fileId string) []models.Link {
-	url := fmt.Sprintf("%s/console/video_quality_list?fid=%s?type=1", FebboxBase, fileId)
-	client := &http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return nil
-		},
-	}
-
-	req, err := http.NewRequest("GET", ProxyURL+url, nil)
-	if err != nil {
-		log.Printf("Error creating request: %v", err)
-		return nil
-	}
-
-	req.Header.Add("Cookie", os.Getenv("FEBBOX_COOKIE"))
-	resp, err := client.Do(req)
+// GetQualities resolves the stream links of a file, or nil if febbox doesn't
+// return any
+func (s *Scraper) GetQualities(ctx context.Context, fid int64) []models.Link {
+	links, err := s.streamer.FetchLinks(ctx, fid)
 	if err != nil {
 		log.Printf("Error getting qualities: %v", err)
 		return nil
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Error reading response body: %v", err)
-		return nil
-	}
-
-	var input map[string]interface{}
-	if err = json.Unmarshal(body, &input); err != nil {
-		log.Printf("Error unmarshaling response: %v", err)
-		return nil
-	}
-
-	html, ok := input["html"].(string)
-	if !ok {
-		log.Println("HTML field not found in response")
-		return nil
-	}
-
-	data := parseHtmlToJson(html)
-	var links []models.Link
-	for _, movie := range data {
-		link := models.Link{
-			Quality: movie.Quality,
-			URL:     movie.URL,
-			Size:    movie.Size,
-		}
-		links = append(links, link)
-	}
 	return links
 }
-
-func parseHtmlToJson(html string) []VideoQuality {
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
-	if err != nil {
-		log.Printf("Error parsing HTML: %v", err)
-		return nil
-	}
-
-	var videos []VideoQuality
-	doc.Find(".file_quality").Each(func(i int, s *goquery.Selection) {
-		video := VideoQuality{
-			Quality: s.AttrOr("data-quality", ""),
-			URL:     s.AttrOr("data-url", ""),
-			Size:    s.Find(".desc .size").Text(),
-		}
-		videos = append(videos, video)
-	})
-	return videos
-}
@@ -0,0 +1,234 @@
+package febox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/httpclient"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
+)
+
+// newReplayScraper creates a scraper that serves every request from the
+// responses recorded under testdata/fixtures
+func newReplayScraper(t *testing.T) (*Scraper, *repository.MemoryRepo) {
+	t.Helper()
+	repo := repository.NewMemoryRepo()
+	scraper, err := NewScraper(repo, &Config{
+		MaxConcurrency: 1,
+		MaxRetries:     1,
+		HTTPTimeout:    5,
+		Cookie:         "ui=fixture",
+		HTTP: httpclient.Config{
+			Mode:        httpclient.ModeReplay,
+			FixturesDir: "testdata/fixtures",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewScraper: %v", err)
+	}
+	return scraper, repo
+}
+
+func TestScrapeSeriesFromFixtures(t *testing.T) {
+	scraper, repo := newReplayScraper(t)
+	ctx := context.Background()
+
+	if err := scraper.ScrapeContent(ctx, &models.TV{TVID: "2001", Title: "Sample Show"}, 0); err != nil {
+		t.Fatalf("ScrapeContent: %v", err)
+	}
+	tv, err := repo.GetFullTVById(ctx, "2001")
+	if err != nil {
+		t.Fatalf("GetFullTVById: %v", err)
+	}
+
+	// season -> episode -> FIDs of every file of the episode. 103 is
+	// S01E02E03 and belongs to both episodes.
+	want := map[int]map[int][]int64{
+		1: {1: {101, 102}, 2: {103}, 3: {103}},
+		2: {1: {201}},
+	}
+	if len(tv.Seasons) != len(want) {
+		t.Fatalf("got %d seasons, want %d", len(tv.Seasons), len(want))
+	}
+	for _, season := range tv.Seasons {
+		wantEpisodes, ok := want[season.SeasonNumber]
+		if !ok {
+			t.Errorf("unexpected season %d", season.SeasonNumber)
+			continue
+		}
+		if len(season.Episodes) != len(wantEpisodes) {
+			t.Errorf("season %d: got %d episodes, want %d", season.SeasonNumber, len(season.Episodes), len(wantEpisodes))
+		}
+		for _, episode := range season.Episodes {
+			var fids []int64
+			for _, source := range episode.Sources {
+				for _, file := range source.Files {
+					fids = append(fids, file.FID)
+					if len(file.Links) != 2 {
+						t.Errorf("S%02dE%02d fid %d: got %d links, want 2", season.SeasonNumber, episode.EpisodeNo, file.FID, len(file.Links))
+					}
+					if file.Release == nil || file.Release.Season != season.SeasonNumber {
+						t.Errorf("S%02dE%02d fid %d: release %+v doesn't match the season", season.SeasonNumber, episode.EpisodeNo, file.FID, file.Release)
+					}
+				}
+			}
+			if !sameFIDs(fids, wantEpisodes[episode.EpisodeNo]) {
+				t.Errorf("S%02dE%02d: got files %v, want %v", season.SeasonNumber, episode.EpisodeNo, fids, wantEpisodes[episode.EpisodeNo])
+			}
+		}
+	}
+}
+
+// countingTransport counts the requests made per URL
+type countingTransport struct {
+	next http.RoundTripper
+	mu   sync.Mutex
+	hits map[string]int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.hits[req.URL.String()]++
+	c.mu.Unlock()
+	return c.next.RoundTrip(req)
+}
+
+func TestScrapeSeriesResolvesEachFileOnce(t *testing.T) {
+	scraper, _ := newReplayScraper(t)
+	counter := &countingTransport{next: scraper.client.Transport, hits: make(map[string]int)}
+	scraper.client.Transport = counter
+
+	if err := scraper.ScrapeContent(context.Background(), &models.TV{TVID: "2001", Title: "Sample Show"}, 0); err != nil {
+		t.Fatalf("ScrapeContent: %v", err)
+	}
+	// 103 holds two episodes but is looked up once
+	for url, hits := range counter.hits {
+		if (strings.Contains(url, "file_info") || strings.Contains(url, "video_quality_list")) && hits != 1 {
+			t.Errorf("%s requested %d times, want 1", url, hits)
+		}
+	}
+}
+
+func TestScrapeMovieFromFixtures(t *testing.T) {
+	scraper, repo := newReplayScraper(t)
+	ctx := context.Background()
+
+	if err := scraper.ScrapeContent(ctx, &models.Movie{MovieID: "1056", Title: "The Matrix"}, 0); err != nil {
+		t.Fatalf("ScrapeContent: %v", err)
+	}
+	movie, err := repo.GetMovieById(ctx, "1056")
+	if err != nil {
+		t.Fatalf("GetMovieById: %v", err)
+	}
+	if len(movie.Files) != 1 {
+		t.Fatalf("got %d files, want 1", len(movie.Files))
+	}
+
+	file := movie.Files[0]
+	if file.FID != 301 || file.FileName != "The.Matrix.1999.1080p.BluRay.x264-GRP.mkv" || file.Size != "2.1 GB" {
+		t.Errorf("unexpected file %+v", file)
+	}
+	var qualities []string
+	for _, link := range file.Links {
+		qualities = append(qualities, link.Quality)
+		if link.URL == "" || link.ExpiresAt == 0 {
+			t.Errorf("link %+v has no URL or expiry", link)
+		}
+	}
+	if len(qualities) != 3 || qualities[0] != "ORG" || qualities[1] != "1080P" || qualities[2] != "720P" {
+		t.Errorf("got qualities %v, want [ORG 1080P 720P]", qualities)
+	}
+	if file.Release == nil || file.Release.Year != 1999 || file.Release.Resolution != "1080p" {
+		t.Errorf("unexpected release %+v", file.Release)
+	}
+}
+
+func TestScrapeSeriesFailsOnRateLimitedFolder(t *testing.T) {
+	scraper, repo := newReplayScraper(t)
+	ctx := context.Background()
+
+	// The second season folder of 2002 was answered with a 429
+	err := scraper.ScrapeContent(ctx, &models.TV{TVID: "2002", Title: "Other Show"}, 0)
+	if !errors.Is(err, upstream.ErrRateLimited) {
+		t.Fatalf("got error %v, want %v", err, upstream.ErrRateLimited)
+	}
+	if _, err := repo.GetTVById(ctx, "2002"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("show with a missing season was saved (lookup error %v)", err)
+	}
+}
+
+func TestScrapeSeriesRetriesRateLimitsOncePerTitleAttempt(t *testing.T) {
+	scraper, _ := newReplayScraper(t)
+	scraper.config.MaxRetries = 2
+	counter := &countingTransport{next: scraper.client.Transport, hits: make(map[string]int)}
+	scraper.client.Transport = counter
+
+	scraper.ScrapeSeriesConcurrently(context.Background(), []models.TV{{TVID: "2002", Title: "Other Show"}})
+
+	// Only the title loop retries the rate limited folder, nothing below it
+	url := "https://www.febbox.com/file/file_share_list?share_key=tvShare02&pwd=&parent_id=9102&is_html=0"
+	if hits := counter.hits[url]; hits != 2 {
+		t.Errorf("rate limited folder requested %d times, want 2", hits)
+	}
+}
+
+func TestScrapeUnrecordedTitleFails(t *testing.T) {
+	scraper, _ := newReplayScraper(t)
+	err := scraper.ScrapeContent(context.Background(), &models.Movie{MovieID: "9999", Title: "Unknown"}, 0)
+	if err == nil {
+		t.Fatal("expected an error for a title without recorded responses")
+	}
+}
+
+func sameFIDs(got, want []int64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	seen := make(map[int64]int)
+	for _, fid := range got {
+		seen[fid]++
+	}
+	for _, fid := range want {
+		if seen[fid] == 0 {
+			return false
+		}
+		seen[fid]--
+	}
+	return true
+}
+
+func TestScrapeMovieWithoutRepository(t *testing.T) {
+	scraper, err := NewScraper(nil, &Config{
+		MaxConcurrency: 1,
+		MaxRetries:     1,
+		HTTPTimeout:    5,
+		Cookie:         "ui=fixture",
+		HTTP: httpclient.Config{
+			Mode:        httpclient.ModeReplay,
+			FixturesDir: "testdata/fixtures",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewScraper: %v", err)
+	}
+	if err := scraper.ScrapeContent(context.Background(), &models.Movie{MovieID: "1056", Title: "The Matrix"}, 0); err != nil {
+		t.Fatalf("ScrapeContent: %v", err)
+	}
+}
+
+func TestProcessFileListStopsWhenCancelled(t *testing.T) {
+	scraper, _ := newReplayScraper(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	files := []FebboxFile{{Fid: 101, FileName: "Sample.Show.S01E01.1080p.WEB-DL.DDP5.1.x265-GRP.mkv"}}
+	if _, err := scraper.processFileList(ctx, files, 1, nil, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
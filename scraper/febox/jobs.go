@@ -0,0 +1,238 @@
+package febox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
+)
+
+// JobStatus is the state of a single title in a scrape run
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+// RunMode selects which titles of a list are scraped when a job store is used
+type RunMode int
+
+const (
+	RunAll        RunMode = iota // scrape every title, even finished ones
+	RunResume                    // skip titles that are already done
+	RunFailedOnly                // only retry titles that failed before
+)
+
+// JobItem is the checkpoint record of a single movie or TV series
+type JobItem struct {
+	ID        string      `json:"id"`
+	Title     string      `json:"title"`
+	Type      ContentType `json:"type"`
+	Status    JobStatus   `json:"status"`
+	Attempts  int         `json:"attempts"`
+	LastError string      `json:"last_error,omitempty"`
+	ErrorKind string      `json:"error_kind,omitempty"` // see upstream.Kind
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// JobStore persists per-title scrape progress to a local JSON file so a run
+// can be resumed, retried or inspected after the fact
+type JobStore struct {
+	path  string
+	mu    sync.Mutex
+	items map[string]*JobItem
+}
+
+// OpenJobStore loads the checkpoint file at path, starting empty if it
+// doesn't exist yet
+func OpenJobStore(path string) (*JobStore, error) {
+	store := &JobStore{
+		path:  path,
+		items: make(map[string]*JobItem),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job store: %w", err)
+	}
+
+	var items []*JobItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse job store %s: %w", path, err)
+	}
+	for _, item := range items {
+		store.items[jobKey(item.Type, item.ID)] = item
+	}
+	return store, nil
+}
+
+// SelectMovies registers the movies in the store and returns the ones that
+// should be scraped in the given mode
+func (j *JobStore) SelectMovies(movies []models.Movie, mode RunMode) []models.Movie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var selected []models.Movie
+	for _, movie := range movies {
+		if j.register(MovieType, movie.MovieID, movie.Title, mode) {
+			selected = append(selected, movie)
+		}
+	}
+	return selected
+}
+
+// SelectSeries registers the series in the store and returns the ones that
+// should be scraped in the given mode
+func (j *JobStore) SelectSeries(series []models.TV, mode RunMode) []models.TV {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var selected []models.TV
+	for _, tv := range series {
+		if j.register(TVType, tv.TVID, tv.Title, mode) {
+			selected = append(selected, tv)
+		}
+	}
+	return selected
+}
+
+func (j *JobStore) register(contentType ContentType, id, title string, mode RunMode) bool {
+	key := jobKey(contentType, id)
+	item, exists := j.items[key]
+	if !exists {
+		j.items[key] = &JobItem{
+			ID:        id,
+			Title:     title,
+			Type:      contentType,
+			Status:    JobPending,
+			UpdatedAt: time.Now(),
+		}
+		return mode != RunFailedOnly
+	}
+
+	switch mode {
+	case RunResume:
+		return item.Status != JobDone
+	case RunFailedOnly:
+		return item.Status == JobFailed
+	default:
+		return true
+	}
+}
+
+// Record stores the outcome of one scrape attempt and writes the checkpoint
+// file, so a crash loses at most the titles in flight
+func (j *JobStore) Record(contentType ContentType, id, title string, scrapeErr error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key := jobKey(contentType, id)
+	item, exists := j.items[key]
+	if !exists {
+		item = &JobItem{ID: id, Title: title, Type: contentType}
+		j.items[key] = item
+	}
+
+	item.Attempts++
+	item.UpdatedAt = time.Now()
+	if scrapeErr != nil {
+		item.Status = JobFailed
+		item.LastError = scrapeErr.Error()
+		item.ErrorKind = upstream.Kind(scrapeErr)
+	} else {
+		item.Status = JobDone
+		item.LastError = ""
+		item.ErrorKind = ""
+	}
+
+	return j.save()
+}
+
+// Save writes the checkpoint file
+func (j *JobStore) Save() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.save()
+}
+
+func (j *JobStore) save() error {
+	items := j.sortedItems()
+
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode job store: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted write never
+	// corrupts the previous checkpoint
+	if dir := filepath.Dir(j.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create job store directory: %w", err)
+		}
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write job store: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("failed to replace job store: %w", err)
+	}
+	return nil
+}
+
+// Items returns the records with the given status, or every record if
+// status is empty
+func (j *JobStore) Items(status JobStatus) []JobItem {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var items []JobItem
+	for _, item := range j.sortedItems() {
+		if status == "" || item.Status == status {
+			items = append(items, *item)
+		}
+	}
+	return items
+}
+
+// Summary counts the records per status
+func (j *JobStore) Summary() map[JobStatus]int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	summary := make(map[JobStatus]int)
+	for _, item := range j.items {
+		summary[item.Status]++
+	}
+	return summary
+}
+
+func (j *JobStore) sortedItems() []*JobItem {
+	items := make([]*JobItem, 0, len(j.items))
+	for _, item := range j.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(a, b int) bool {
+		if items[a].Type != items[b].Type {
+			return items[a].Type < items[b].Type
+		}
+		return items[a].ID < items[b].ID
+	})
+	return items
+}
+
+func jobKey(contentType ContentType, id string) string {
+	return fmt.Sprintf("%d:%s", contentType, id)
+}
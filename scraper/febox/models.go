@@ -8,6 +8,7 @@ type Movie struct {
 	ID          string    `json:"id"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
+	IMDbID      string    `json:"imdb_id,omitempty"`
 	ScrapedAt   time.Time `json:"scraped_at"`
 }
 
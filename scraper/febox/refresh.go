@@ -0,0 +1,142 @@
+package febox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+)
+
+// RefreshSummary counts what RefreshSeriesConcurrently did
+type RefreshSummary struct {
+	Refreshed   int64 // shows whose share folder was checked
+	Updated     int64 // shows with new files, saved
+	NewEpisodes int64
+	NewFiles    int64
+	Errored     int64
+}
+
+func (s RefreshSummary) String() string {
+	return fmt.Sprintf("%d shows checked: %d updated with %d new episodes and %d new files, %d errored",
+		s.Refreshed, s.Updated, s.NewEpisodes, s.NewFiles, s.Errored)
+}
+
+// RefreshSeries scrapes the share folder of a stored show again and adds the
+// files that aren't stored yet, which is far cheaper than scraping it from
+// scratch: file details are only fetched for new files. Stored episodes keep
+// their TMDB metadata; new episodes get theirs on the next TMDB sync. The show
+// is only saved when something was added.
+func (s *Scraper) RefreshSeries(ctx context.Context, tv *models.TV) (newEpisodes, newFiles int, err error) {
+	link, err := s.shareLink(ctx, tv.TVID, TVType, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	known := fileIDs(tv.Seasons)
+	builder := newSeasonBuilderFrom(tv.Seasons)
+	if err := s.scrapeSeasons(ctx, link, known, builder); err != nil {
+		return 0, 0, err
+	}
+
+	seasons := builder.build()
+	newEpisodes = countEpisodes(seasons) - countEpisodes(tv.Seasons)
+	newFiles = len(fileIDs(seasons)) - len(known)
+	if newFiles == 0 {
+		log.Printf("No new files for TV series %s", tv.Title)
+		return 0, 0, nil
+	}
+	if err := s.config.Cookies.Available(); err != nil {
+		return 0, 0, err
+	}
+	tv.Seasons = seasons
+
+	if s.dbRepo == nil {
+		log.Println("Database repository not initialized, skipping TV series save")
+		return newEpisodes, newFiles, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := s.dbRepo.UpdateTV(ctx, tv); err != nil {
+		log.Printf("Error updating TV series %s in database: %v", tv.TVID, err)
+		return 0, 0, fmt.Errorf("database update failed: %w", err)
+	}
+	log.Printf("Added %d new episodes and %d new files to TV series %s (%s)",
+		newEpisodes, newFiles, tv.Title, tv.TVID)
+	return newEpisodes, newFiles, nil
+}
+
+// RefreshSeriesConcurrently refreshes stored shows with the same concurrency,
+// pacing and shutdown behavior as ScrapeSeriesConcurrently
+func (s *Scraper) RefreshSeriesConcurrently(ctx context.Context, series []models.TV) RefreshSummary {
+	workCtx, cancel := s.drainContext(ctx)
+	defer cancel()
+
+	var summary RefreshSummary
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.config.MaxConcurrency)
+
+	for idx := range series {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			if !acquire(ctx, sem) {
+				return
+			}
+			defer func() { <-sem }()
+
+			tv := &series[idx]
+
+			var newEpisodes, newFiles int
+			var err error
+			for retries := 0; retries < s.config.MaxRetries; retries++ {
+				if newEpisodes, newFiles, err = s.RefreshSeries(workCtx, tv); err != nil {
+					log.Printf("Error refreshing TV series %s: %v", tv.Title, err)
+					if isRateLimitError(err) && workCtx.Err() == nil {
+						continue
+					}
+				}
+				break
+			}
+
+			atomic.AddInt64(&summary.Refreshed, 1)
+			if err != nil {
+				atomic.AddInt64(&summary.Errored, 1)
+				return
+			}
+			if newFiles > 0 {
+				atomic.AddInt64(&summary.Updated, 1)
+				atomic.AddInt64(&summary.NewEpisodes, int64(newEpisodes))
+				atomic.AddInt64(&summary.NewFiles, int64(newFiles))
+			}
+		}(idx)
+	}
+	wg.Wait()
+	return summary
+}
+
+// fileIDs collects the FIDs of every file of the seasons
+func fileIDs(seasons []models.Season) map[int64]bool {
+	ids := make(map[int64]bool)
+	for _, season := range seasons {
+		for _, episode := range season.Episodes {
+			for _, source := range episode.Sources {
+				for _, file := range source.Files {
+					ids[file.FID] = true
+				}
+			}
+		}
+	}
+	return ids
+}
+
+func countEpisodes(seasons []models.Season) int {
+	count := 0
+	for _, season := range seasons {
+		count += len(season.Episodes)
+	}
+	return count
+}
@@ -0,0 +1,72 @@
+package febox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+)
+
+// special is a stored season 0 episode with a single file
+func special(number int, fid int64, name string) models.Episode {
+	return models.Episode{
+		EpisodeNo: number,
+		Sources: []models.Source{{
+			SourceName: "Unknown",
+			Files:      []models.File{{FID: fid, FileName: name}},
+		}},
+	}
+}
+
+func TestRefreshSeriesNumbersNewSpecialsAfterStoredOnes(t *testing.T) {
+	scraper, repo := newReplayScraper(t)
+	ctx := context.Background()
+
+	// The share holds "Behind the Scenes" (401), "Making Of" (402) and
+	// "Trailer" (403), none numbered. 402 is new and sorts between the
+	// stored ones, which must keep their numbers.
+	tv := &models.TV{
+		TVID:  "2003",
+		Title: "Sample Show",
+		Seasons: []models.Season{{
+			SeasonNumber: 0,
+			SeasonName:   "Specials",
+			Episodes: []models.Episode{
+				special(1, 401, "Sample Show - Behind the Scenes.mkv"),
+				special(2, 403, "Sample Show - Trailer.mkv"),
+			},
+		}},
+	}
+	if err := repo.CreateTV(ctx, tv); err != nil {
+		t.Fatalf("CreateTV: %v", err)
+	}
+
+	newEpisodes, newFiles, err := scraper.RefreshSeries(ctx, tv)
+	if err != nil {
+		t.Fatalf("RefreshSeries: %v", err)
+	}
+	if newEpisodes != 1 || newFiles != 1 {
+		t.Errorf("got %d new episodes and %d new files, want 1 and 1", newEpisodes, newFiles)
+	}
+
+	stored, err := repo.GetFullTVById(ctx, "2003")
+	if err != nil {
+		t.Fatalf("GetFullTVById: %v", err)
+	}
+	want := map[int][]int64{1: {401}, 2: {403}, 3: {402}}
+	episodes := stored.Seasons[0].Episodes
+	if len(stored.Seasons) != 1 || len(episodes) != len(want) {
+		t.Fatalf("got seasons %+v, want one season with %d episodes", stored.Seasons, len(want))
+	}
+	for _, episode := range episodes {
+		var fids []int64
+		for _, source := range episode.Sources {
+			for _, file := range source.Files {
+				fids = append(fids, file.FID)
+			}
+		}
+		if !sameFIDs(fids, want[episode.EpisodeNo]) {
+			t.Errorf("S00E%02d: got files %v, want %v", episode.EpisodeNo, fids, want[episode.EpisodeNo])
+		}
+	}
+}
@@ -1,63 +1,134 @@
 package febox
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/amankumarsingh77/go-showbox-api/db/models"
 	"github.com/amankumarsingh77/go-showbox-api/db/repository"
+	"github.com/amankumarsingh77/go-showbox-api/db/utils"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/cookiepool"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/hostlimit"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/httpclient"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/proxypool"
 )
 
 type Scraper struct {
 	client      *http.Client
-	dbRepo      *repository.MongoRepo
+	streamer    *utils.Streamer
+	dbRepo      repository.Repository
 	visitedURLs map[string]bool
 	config      *Config
+	jobs        *JobStore
 	mu          sync.Mutex
 }
 
-func NewScraper(dbRepo *repository.MongoRepo, cfg *Config) *Scraper {
+func NewScraper(dbRepo repository.Repository, cfg *Config) (*Scraper, error) {
+	if cfg.ShowboxBase == "" {
+		cfg.ShowboxBase = ShowboxBase
+	}
+	if cfg.FebboxBase == "" {
+		cfg.FebboxBase = FebboxBase
+	}
+	if cfg.Cookies == nil {
+		cfg.Cookies = cookiepool.Single(cfg.Cookie)
+	}
+	if cfg.Limiter == nil {
+		cfg.Limiter = hostlimit.New(hostlimit.Options{})
+	}
+	if cfg.Proxies == nil {
+		cfg.Proxies = proxypool.New(proxypool.FromURLs(cfg.ProxyURL, cfg.HTTPProxy), proxypool.Options{})
+	}
+	cfg.ShowboxBase = strings.TrimSuffix(cfg.ShowboxBase, "/")
+	cfg.FebboxBase = strings.TrimSuffix(cfg.FebboxBase, "/")
+
+	httpCfg := cfg.HTTP.WithProxies(cfg.Proxies).WithLimiter(cfg.Limiter)
+	httpCfg.Timeout = time.Duration(cfg.HTTPTimeout) * time.Second
+	client, err := httpclient.New(httpCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+
 	return &Scraper{
-		client: &http.Client{
-			Timeout: time.Duration(cfg.HTTPTimeout) * time.Second,
-		},
+		client: client,
+		streamer: utils.NewStreamer(client).
+			WithBaseURL(cfg.FebboxBase).
+			WithCookiePool(cfg.Cookies),
 		dbRepo:      dbRepo,
 		visitedURLs: make(map[string]bool),
 		config:      cfg,
+	}, nil
+}
+
+// Cookies returns the pool of febbox accounts the scraper uses
+func (s *Scraper) Cookies() *cookiepool.Pool {
+	return s.config.Cookies
+}
+
+// Limiter returns the per-host rate limiter of the scraper's requests
+func (s *Scraper) Limiter() *hostlimit.Limiter {
+	return s.config.Limiter
+}
+
+// Proxies returns the pool of proxies the scraper's requests go through
+func (s *Scraper) Proxies() *proxypool.Pool {
+	return s.config.Proxies
+}
+
+// SetJobStore makes the scraper checkpoint the outcome of every title
+func (s *Scraper) SetJobStore(jobs *JobStore) {
+	s.jobs = jobs
+}
+
+// recordJob stores the outcome of a title in the job store, if one is set
+func (s *Scraper) recordJob(contentType ContentType, id, title string, err error) {
+	if s.jobs == nil {
+		return
+	}
+	if saveErr := s.jobs.Record(contentType, id, title, err); saveErr != nil {
+		log.Printf("Error saving checkpoint for %s: %v", title, saveErr)
 	}
 }
 
 // ScrapeMoviesConcurrently scrapes multiple movies concurrently
 // Maintained for backward compatibility - consider using ScrapeContentConcurrently for new code
-func (s *Scraper) ScrapeMoviesConcurrently(movies []models.Movie) {
+func (s *Scraper) ScrapeMoviesConcurrently(ctx context.Context, movies []models.Movie) {
+	workCtx, cancel := s.drainContext(ctx)
+	defer cancel()
+
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, s.config.MaxConcurrency)
-	ticker := time.NewTicker(time.Duration(s.config.RequestInterval) * time.Second)
-	defer ticker.Stop()
 
 	for idx := range movies {
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
-			<-ticker.C
-			sem <- struct{}{}
+			if !acquire(ctx, sem) {
+				return
+			}
 			defer func() { <-sem }()
 
 			// Use a pointer to the movie in the original slice
 			movie := &movies[idx]
 
+			var err error
 			for retries := 0; retries < s.config.MaxRetries; retries++ {
-				if err := s.ScrapeContent(movie, idx); err != nil {
+				if err = s.ScrapeContent(workCtx, movie, idx); err != nil {
 					log.Printf("Error scraping movie %s: %v", movie.Title, err)
-					if isRateLimitError(err) {
-						time.Sleep(time.Duration(s.config.RetryDelay<<retries) * time.Second)
+					// The limiter has slowed down and holds the
+					// retry back for as long as febbox asked
+					if isRateLimitError(err) && workCtx.Err() == nil {
 						continue
 					}
 				}
 				break
 			}
+			s.recordJob(MovieType, movie.MovieID, movie.Title, err)
 		}(idx)
 	}
 	wg.Wait()
@@ -65,38 +136,85 @@ func (s *Scraper) ScrapeMoviesConcurrently(movies []models.Movie) {
 
 // ScrapeSeriesConcurrently scrapes multiple TV series concurrently
 // Maintained for backward compatibility - consider using ScrapeContentConcurrently for new code
-func (s *Scraper) ScrapeSeriesConcurrently(series []models.TV) {
+func (s *Scraper) ScrapeSeriesConcurrently(ctx context.Context, series []models.TV) {
+	workCtx, cancel := s.drainContext(ctx)
+	defer cancel()
+
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, s.config.MaxConcurrency)
-	ticker := time.NewTicker(time.Duration(s.config.RequestInterval) * time.Second)
-	defer ticker.Stop()
 
 	for idx := range series {
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
-			<-ticker.C
-			sem <- struct{}{}
+			if !acquire(ctx, sem) {
+				return
+			}
 			defer func() { <-sem }()
 
 			// Use a pointer to the TV series in the original slice
 			tv := &series[idx]
 
+			var err error
 			for retries := 0; retries < s.config.MaxRetries; retries++ {
-				if err := s.ScrapeContent(tv, idx); err != nil {
+				if err = s.ScrapeContent(workCtx, tv, idx); err != nil {
 					log.Printf("Error scraping TV series %s: %v", tv.Title, err)
-					if isRateLimitError(err) {
-						time.Sleep(time.Duration(s.config.RetryDelay<<retries) * time.Second)
+					// The limiter has slowed down and holds the
+					// retry back for as long as febbox asked
+					if isRateLimitError(err) && workCtx.Err() == nil {
 						continue
 					}
 				}
 				break
 			}
+			s.recordJob(TVType, tv.TVID, tv.Title, err)
 		}(idx)
 	}
 	wg.Wait()
 }
 
+// acquire waits for a free worker; the pace of requests is up to the host
+// limiter. It returns false without holding the semaphore when ctx is
+// cancelled first, which is how a shutdown stops new titles from being
+// started.
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case sem <- struct{}{}:
+		return true
+	}
+}
+
+// drainContext returns the context used for titles already in flight. It
+// outlives ctx by at most Config.DrainTimeout seconds, so a shutdown lets
+// started titles finish and be saved instead of killing them mid-write.
+// A DrainTimeout of 0 waits for in-flight titles indefinitely.
+func (s *Scraper) drainContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	workCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		select {
+		case <-workCtx.Done():
+			return
+		case <-ctx.Done():
+		}
+
+		if s.config.DrainTimeout <= 0 {
+			log.Println("Shutdown requested, waiting for in-flight titles to finish")
+			return
+		}
+		timeout := time.Duration(s.config.DrainTimeout) * time.Second
+		log.Printf("Shutdown requested, waiting up to %s for in-flight titles to finish", timeout)
+		select {
+		case <-workCtx.Done():
+		case <-time.After(timeout):
+			log.Println("Drain timeout reached, cancelling in-flight titles")
+			cancel()
+		}
+	}()
+	return workCtx, cancel
+}
+
 func (s *Scraper) isVisited(url string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -110,11 +228,12 @@ func (s *Scraper) markVisited(url string) {
 }
 
 // ScrapeContentConcurrently is a general function that can scrape both movies and TV series concurrently
-func (s *Scraper) ScrapeContentConcurrently(contents []interface{}) {
+func (s *Scraper) ScrapeContentConcurrently(ctx context.Context, contents []interface{}) {
+	workCtx, cancel := s.drainContext(ctx)
+	defer cancel()
+
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, s.config.MaxConcurrency)
-	ticker := time.NewTicker(time.Duration(s.config.RequestInterval) * time.Second)
-	defer ticker.Stop()
 
 	// For interface{} slice, we need to be careful about pointers
 	// Each element in contents should already be a pointer to the appropriate type
@@ -122,31 +241,36 @@ func (s *Scraper) ScrapeContentConcurrently(contents []interface{}) {
 		wg.Add(1)
 		go func(c interface{}, idx int) {
 			defer wg.Done()
-			<-ticker.C
-			sem <- struct{}{}
+			if !acquire(ctx, sem) {
+				return
+			}
 			defer func() { <-sem }()
 
-			var title string
+			var id, title string
+			var contentType ContentType
 			switch v := c.(type) {
 			case *models.Movie:
-				title = v.Title
+				id, title, contentType = v.MovieID, v.Title, MovieType
 			case *models.TV:
-				title = v.Title
+				id, title, contentType = v.TVID, v.Title, TVType
 			default:
 				log.Printf("Unsupported content type: %T", c)
 				return
 			}
 
+			var err error
 			for retries := 0; retries < s.config.MaxRetries; retries++ {
-				if err := s.ScrapeContent(c, idx); err != nil {
+				if err = s.ScrapeContent(workCtx, c, idx); err != nil {
 					log.Printf("Error scraping content %s: %v", title, err)
-					if isRateLimitError(err) {
-						time.Sleep(time.Duration(s.config.RetryDelay<<retries) * time.Second)
+					// The limiter has slowed down and holds the
+					// retry back for as long as febbox asked
+					if isRateLimitError(err) && workCtx.Err() == nil {
 						continue
 					}
 				}
 				break
 			}
+			s.recordJob(contentType, id, title, err)
 		}(content, idx)
 	}
 	wg.Wait()
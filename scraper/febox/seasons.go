@@ -0,0 +1,192 @@
+package febox
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/releaseparse"
+)
+
+// noSeason marks a share folder whose name doesn't tell which season it holds
+const noSeason = -1
+
+var (
+	seasonFolderPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(?:season|series|staffel|saison|temporada)[\s._-]*(\d{1,3})\b`), // Season 3, Season.03
+		regexp.MustCompile(`(?i)^\s*s(\d{1,3})\s*$`),                                              // S03
+	}
+	specialsFolderPattern = regexp.MustCompile(`(?i)\b(?:specials?|extras|ova)\b`)
+
+	// Episode-only names like "Episode 5" or "E05", used when the season
+	// comes from the folder instead of the file name
+	episodeOnlyPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bep(?:isode)?[\s._-]*(\d{1,4})\b`),
+		regexp.MustCompile(`(?i)(?:^|[\s._-])e(\d{1,4})(?:[\s._-]|$)`),
+	}
+)
+
+// parseSeasonFolder derives the season number from a share folder name like
+// "Season 3" or "Specials" (season 0). It returns noSeason when the name
+// doesn't say.
+func parseSeasonFolder(name string) int {
+	for _, re := range seasonFolderPatterns {
+		if matches := re.FindStringSubmatch(name); len(matches) == 2 {
+			season, _ := strconv.Atoi(matches[1])
+			return season
+		}
+	}
+	if specialsFolderPattern.MatchString(name) {
+		return 0
+	}
+	return noSeason
+}
+
+// extractEpisodeNumber finds a bare episode number in a file name that has
+// no season marker, including anime style absolute numbers
+func extractEpisodeNumber(filename string) (int, bool) {
+	for _, re := range episodeOnlyPatterns {
+		if matches := re.FindStringSubmatch(filename); len(matches) == 2 {
+			episode, err := strconv.Atoi(matches[1])
+			if err == nil {
+				return episode, true
+			}
+		}
+	}
+	if release := releaseparse.Parse(filename); release.Absolute > 0 {
+		return release.Absolute, true
+	}
+	return 0, false
+}
+
+// seasonName is the display name used when the folder name doesn't belong
+// to exactly one season
+func seasonName(number int) string {
+	if number == 0 {
+		return "Specials"
+	}
+	return fmt.Sprintf("Season %d", number)
+}
+
+// seasonBuilder regroups episodes coming from any number of share folders
+// into seasons keyed by their real season number
+type seasonBuilder struct {
+	seasons map[int]*models.Season
+	stored  map[int]bool // seasons seeded from the database, never renamed
+}
+
+func newSeasonBuilder() *seasonBuilder {
+	return &seasonBuilder{seasons: make(map[int]*models.Season)}
+}
+
+// newSeasonBuilderFrom starts from stored seasons, so new episodes are merged
+// into them and the episodes already stored keep their metadata. The seasons
+// are copied down to their files, so merging never changes the caller's.
+func newSeasonBuilderFrom(seasons []models.Season) *seasonBuilder {
+	b := &seasonBuilder{
+		seasons: make(map[int]*models.Season, len(seasons)),
+		stored:  make(map[int]bool, len(seasons)),
+	}
+	for _, season := range seasons {
+		season.Episodes = cloneEpisodes(season.Episodes)
+		b.seasons[season.SeasonNumber] = &season
+		b.stored[season.SeasonNumber] = true
+	}
+	return b
+}
+
+// cloneEpisodes copies episodes along with their source and file slices
+func cloneEpisodes(episodes []models.Episode) []models.Episode {
+	episodes = slices.Clone(episodes)
+	for i := range episodes {
+		sources := slices.Clone(episodes[i].Sources)
+		for j := range sources {
+			sources[j].Files = slices.Clone(sources[j].Files)
+		}
+		episodes[i].Sources = sources
+	}
+	return episodes
+}
+
+// add merges the episodes of one folder into the builder. folderName is used
+// as the season name when the folder is named after the season it holds.
+func (b *seasonBuilder) add(folderName string, folderSeason int, episodesBySeason map[int][]models.Episode) {
+	for number, episodes := range episodesBySeason {
+		season, exists := b.seasons[number]
+		if !exists {
+			season = &models.Season{
+				SeasonID:     fmt.Sprintf("season_%d", number),
+				SeasonName:   seasonName(number),
+				SeasonNumber: number,
+			}
+			b.seasons[number] = season
+		}
+		if folderSeason == number && folderName != "" && !b.stored[number] {
+			season.SeasonName = folderName
+		}
+
+		for _, episode := range episodes {
+			mergeEpisode(season, episode)
+		}
+	}
+}
+
+// lastEpisode returns the highest episode number of a season, or 0 when the
+// season has no episodes yet
+func (b *seasonBuilder) lastEpisode(number int) int {
+	last := 0
+	if season, ok := b.seasons[number]; ok {
+		for _, episode := range season.Episodes {
+			if episode.EpisodeNo > last {
+				last = episode.EpisodeNo
+			}
+		}
+	}
+	return last
+}
+
+// mergeEpisode adds an episode to a season, folding its sources into an
+// existing episode with the same number
+func mergeEpisode(season *models.Season, episode models.Episode) {
+	for i := range season.Episodes {
+		existing := &season.Episodes[i]
+		if existing.EpisodeNo != episode.EpisodeNo {
+			continue
+		}
+		for _, source := range episode.Sources {
+			merged := false
+			for j := range existing.Sources {
+				if existing.Sources[j].SourceName == source.SourceName {
+					existing.Sources[j].Files = append(existing.Sources[j].Files, source.Files...)
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				existing.Sources = append(existing.Sources, source)
+			}
+		}
+		existing.Size += episode.Size
+		return
+	}
+	season.Episodes = append(season.Episodes, episode)
+}
+
+// build returns the seasons ordered by number, with episodes ordered too
+func (b *seasonBuilder) build() []models.Season {
+	seasons := make([]models.Season, 0, len(b.seasons))
+	for _, season := range b.seasons {
+		sort.Slice(season.Episodes, func(i, j int) bool {
+			return season.Episodes[i].EpisodeNo < season.Episodes[j].EpisodeNo
+		})
+		season.Size = calculateTotalEpisodesSize(season.Episodes)
+		seasons = append(seasons, *season)
+	}
+	sort.Slice(seasons, func(i, j int) bool {
+		return seasons[i].SeasonNumber < seasons[j].SeasonNumber
+	})
+	return seasons
+}
@@ -0,0 +1,43 @@
+package febox
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amankumarsingh77/go-showbox-api/db/models"
+)
+
+func TestSeasonBuilderFromLeavesStoredSeasonsUntouched(t *testing.T) {
+	// Spare capacity lets append write into the backing arrays in place
+	files := make([]models.File, 1, 4)
+	files[0] = models.File{FID: 1}
+	sources := make([]models.Source, 1, 4)
+	sources[0] = models.Source{SourceName: "HEVC/x265", Files: files}
+	stored := []models.Season{{
+		SeasonNumber: 1,
+		Episodes:     []models.Episode{{EpisodeNo: 1, Sources: sources}},
+	}}
+	before := cloneEpisodes(stored[0].Episodes)
+
+	builder := newSeasonBuilderFrom(stored)
+	builder.add("Season 1", 1, map[int][]models.Episode{1: {{
+		EpisodeNo: 1,
+		Sources: []models.Source{
+			{SourceName: "HEVC/x265", Files: []models.File{{FID: 2}}},
+			{SourceName: "H.264/x264", Files: []models.File{{FID: 3}}},
+		},
+	}}})
+
+	if !reflect.DeepEqual(stored[0].Episodes, before) {
+		t.Errorf("stored episodes changed to %+v", stored[0].Episodes)
+	}
+	if got := files[:2][1].FID; got != 0 {
+		t.Errorf("file %d was appended into the stored files array", got)
+	}
+	if got := sources[:2][1].SourceName; got != "" {
+		t.Errorf("source %q was appended into the stored sources array", got)
+	}
+	if got := len(fileIDs(builder.build())); got != 3 {
+		t.Errorf("built seasons have %d files, want 3", got)
+	}
+}
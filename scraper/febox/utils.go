@@ -1,60 +1,61 @@
 package febox
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/amankumarsingh77/go-showbox-api/db/models"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/upstream"
 )
 
-func getMoviesList(start, end int) []models.Movie {
-	dir, err := os.Getwd()
+// LoadMovies reads the movie catalog written by `showbox crawl catalog`
+// (movies_final.json)
+func LoadMovies(path string) ([]models.Movie, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatal("Failed to get working directory:", err)
+		return nil, fmt.Errorf("failed to read movie catalog: %w", err)
 	}
 
-	filePath := filepath.Join(dir, "movies_final.json")
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		log.Fatal("Error reading file:", err)
-	}
-
-	var movies []models.Movie
-	if err := json.Unmarshal(data, &movies); err != nil {
-		log.Fatal("Error unmarshaling JSON:", err)
+	// Catalog entries carry the showbox ID under "id", which models.Movie
+	// uses for the database ID
+	var entries []Movie
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse movie catalog %s: %w", path, err)
 	}
 
-	if start < 0 || end >= len(movies) || start > end {
-		log.Fatal("Invalid range for start or end index")
+	movies := make([]models.Movie, 0, len(entries))
+	for _, entry := range entries {
+		movies = append(movies, models.Movie{
+			MovieID:     entry.ID,
+			Title:       entry.Title,
+			Description: entry.Description,
+			IMDbID:      entry.IMDbID,
+		})
 	}
-
-	return movies[start : end+1]
+	return movies, nil
 }
 
-func GetSeriesList(start, end int) []models.TV {
-	dir, err := os.Getwd()
+// LoadSeries reads the TV catalog written by `showbox crawl catalog --tv`
+// (tv_final.json)
+func LoadSeries(path string) ([]models.TV, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatal("Failed to get working directory:", err)
-	}
-
-	filePath := filepath.Join(dir, "tv_final.json")
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		log.Fatal("Error reading file:", err)
+		return nil, fmt.Errorf("failed to read TV catalog: %w", err)
 	}
 
 	var series []models.TV
 	if err := json.Unmarshal(data, &series); err != nil {
-		log.Fatal("Error unmarshaling JSON:", err)
+		return nil, fmt.Errorf("failed to parse TV catalog %s: %w", path, err)
 	}
-
-	return series[start : end+1]
+	return series, nil
 }
 
 func parseHTMLToJSON(html string) []VideoQuality {
@@ -76,30 +77,40 @@ func parseHTMLToJSON(html string) []VideoQuality {
 	return videos
 }
 
+// ErrShareNotFound is returned for titles showbox has no febbox share for
+var ErrShareNotFound = fmt.Errorf("febbox share %w", upstream.ErrNotFound)
+
 func isRateLimitError(err error) bool {
-	if err == nil {
-		return false
-	}
-	return strings.Contains(err.Error(), "429") ||
-		strings.Contains(err.Error(), "rate limit")
+	return errors.Is(err, upstream.ErrRateLimited)
 }
 
-func readBody(body io.ReadCloser) ([]byte, error) {
-	defer body.Close()
-	return io.ReadAll(body)
+// backoff is how long to wait before retry attempt (counted from 1) after
+// err. Rate limited requests go again right away, the host limiter already
+// slowed down and holds them back for as long as the site asked; other
+// failures back off exponentially from base. Rate limited titles are only
+// retried by the Scrape*Concurrently loops, so only file lookups, whose
+// errors never reach them, retry rate limits themselves.
+func backoff(err error, base time.Duration, attempt int) time.Duration {
+	if isRateLimitError(err) {
+		return 0
+	}
+	return base * time.Duration(1<<(attempt-1))
 }
 
-func checkResponseStatus(statusCode int) error {
-	if statusCode == 429 {
-		return ErrRateLimit
-	}
-	if statusCode != 200 {
-		return ErrUnexpectedStatus
+// sleepContext waits for d, returning early with the context's error if ctx
+// is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-	return nil
 }
 
-var (
-	ErrRateLimit        = errors.New("rate limit exceeded")
-	ErrUnexpectedStatus = errors.New("unexpected status code")
-)
+func readBody(body io.ReadCloser) ([]byte, error) {
+	defer body.Close()
+	return io.ReadAll(body)
+}
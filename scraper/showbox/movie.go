@@ -13,5 +13,6 @@ type Movie struct {
 	Country     string    `json:"country"`
 	Production  string    `json:"production"`
 	IMDBRating  string    `json:"imdb_rating"`
+	IMDbID      string    `json:"imdb_id,omitempty"`
 	ScrapedAt   time.Time `json:"scraped_at"`
 }
@@ -1,16 +1,18 @@
 package showbox
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math/rand"
-	"os"
-	"os/signal"
+	"net/url"
+	"regexp"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
+	"github.com/amankumarsingh77/go-showbox-api/pkg/httpclient"
+	"github.com/amankumarsingh77/go-showbox-api/pkg/proxypool"
 	"github.com/gocolly/colly"
 )
 
@@ -30,14 +32,23 @@ type Scraper struct {
 
 func NewScraper(config *Config, storage *Storage) (*Scraper, error) {
 	c := colly.NewCollector(
-		colly.AllowedDomains("www.showbox.media", "simple-proxy.xartpvt.workers.dev"),
+		colly.AllowedDomains(allowedDomains(config)...),
 		colly.UserAgent(config.UserAgent),
 		colly.Async(false),
 	)
 
+	if config.Proxies == nil {
+		config.Proxies = proxypool.New(proxypool.FromURLs(config.ProxyURL, ""), proxypool.Options{})
+	}
+	transport, err := httpclient.NewTransport(config.HTTP.WithProxies(config.Proxies))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP transport: %v", err)
+	}
+	c.WithTransport(transport)
+
 	c.SetRequestTimeout(time.Duration(config.Timeout) * time.Second)
 
-	err := c.Limit(&colly.LimitRule{
+	err = c.Limit(&colly.LimitRule{
 		DomainGlob:  "*",
 		Parallelism: config.Parallelism,
 		RandomDelay: time.Duration(config.RandomDelay) * time.Second,
@@ -52,11 +63,30 @@ func NewScraper(config *Config, storage *Storage) (*Scraper, error) {
 		storage:   storage,
 		shutdown:  make(chan struct{}),
 		done:      make(chan struct{}),
-		isMovie:   config.isMovie,
+		isMovie:   config.Movies,
 		visited:   sync.Map{},
 	}, nil
 }
 
+var imdbIDPattern = regexp.MustCompile(`\btt\d{7,10}\b`)
+
+// parseIMDbID extracts the title ID from an IMDb link like
+// https://www.imdb.com/title/tt0133093/
+func parseIMDbID(link string) string {
+	return imdbIDPattern.FindString(link)
+}
+
+// allowedDomains lets the collector follow the configured site on top of the
+// production host. Proxies don't need to be listed: the collector only ever
+// sees the site's URLs, the transport rewrites them.
+func allowedDomains(config *Config) []string {
+	domains := []string{"www.showbox.media"}
+	if u, err := url.Parse(config.BaseURL); err == nil && u.Hostname() != "" {
+		domains = append(domains, u.Hostname())
+	}
+	return domains
+}
+
 func (s *Scraper) setupCallbacks() {
 	s.collector.OnRequest(func(r *colly.Request) {
 		s.activeJobs.Add(1)
@@ -95,7 +125,7 @@ func (s *Scraper) setupCallbacks() {
 				return
 			}
 
-			fullLink := s.config.ProxyURL + s.config.BaseURL + link
+			fullLink := s.config.BaseURL + link
 
 			if _, exists := s.visited.LoadOrStore(fullLink, true); !exists {
 				s.activeJobs.Add(1)
@@ -133,6 +163,13 @@ func (s *Scraper) setupCallbacks() {
 				}
 			}
 
+			// The IMDb button links to the title when the page has one,
+			// which lets the TMDB sync match it exactly
+			imdbID := parseIMDbID(e.ChildAttr(".btn-imdb", "href"))
+			if imdbID == "" {
+				imdbID = parseIMDbID(e.ChildAttr(`a[href*="imdb.com/title/"]`, "href"))
+			}
+
 			var releaseDate, genre, casts, duration, country, production string
 			e.ForEach(".row-line", func(_ int, el *colly.HTMLElement) {
 				label := strings.ToLower(strings.TrimSpace(el.ChildText(".type")))
@@ -153,7 +190,7 @@ func (s *Scraper) setupCallbacks() {
 					production = value
 				}
 			})
-			if s.config.isMovie {
+			if s.config.Movies {
 				movie := Movie{
 					ID:          id,
 					Title:       cleanText(e.ChildText(".heading-name")),
@@ -165,6 +202,7 @@ func (s *Scraper) setupCallbacks() {
 					Country:     country,
 					Production:  production,
 					IMDBRating:  imdbRating,
+					IMDbID:      imdbID,
 					ScrapedAt:   time.Now(),
 				}
 
@@ -185,6 +223,7 @@ func (s *Scraper) setupCallbacks() {
 					Country:     country,
 					Production:  production,
 					IMDBRating:  imdbRating,
+					IMDbID:      imdbID,
 					ScrapedAt:   time.Now(),
 				}
 				log.Println(tv)
@@ -200,12 +239,11 @@ func (s *Scraper) setupCallbacks() {
 
 }
 
-func (s *Scraper) Run() error {
+// Run crawls the configured page range until it's done or ctx is cancelled,
+// saving what was collected either way
+func (s *Scraper) Run(ctx context.Context) error {
 	s.setupCallbacks()
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
 	// Start the sequential page processing
 	go func() {
 		defer close(s.done)
@@ -216,10 +254,10 @@ func (s *Scraper) Run() error {
 				return
 			default:
 				var url string
-				if s.config.isMovie {
-					url = fmt.Sprintf("%s%s/movie?page=%d", s.config.ProxyURL, s.config.BaseURL, page)
+				if s.config.Movies {
+					url = fmt.Sprintf("%s/movie?page=%d", s.config.BaseURL, page)
 				} else {
-					url = fmt.Sprintf("%s%s/tv?page=%d", s.config.ProxyURL, s.config.BaseURL, page)
+					url = fmt.Sprintf("%s/tv?page=%d", s.config.BaseURL, page)
 				}
 
 				if _, exists := s.visited.LoadOrStore(url, true); !exists {
@@ -235,7 +273,7 @@ func (s *Scraper) Run() error {
 					// Track progress after each page
 					if page%5 == 0 || page == s.config.EndPage {
 						log.Printf("Saving progress after page %d...\n", page)
-						if s.config.isMovie {
+						if s.config.Movies {
 							if err := s.storage.SaveProgress(s.movies); err != nil {
 								log.Printf("Error saving movie progress: %v\n", err)
 							}
@@ -261,7 +299,7 @@ func (s *Scraper) Run() error {
 
 	// Wait for either a signal or completion
 	select {
-	case <-sigChan:
+	case <-ctx.Done():
 		log.Println("Signal received. Shutting down immediately...")
 		close(s.shutdown)
 
@@ -280,7 +318,7 @@ func (s *Scraper) Run() error {
 		}
 
 		// Also save and merge TV progress if not in movie mode
-		if !s.config.isMovie {
+		if !s.config.Movies {
 			if err := s.storage.SaveTVProgress(s.tv); err != nil {
 				log.Printf("Error saving TV progress: %v\n", err)
 			}
@@ -312,7 +350,7 @@ func (s *Scraper) Run() error {
 	}
 
 	// Also save and merge TV progress if not in movie mode
-	if !s.config.isMovie {
+	if !s.config.Movies {
 		if err := s.storage.SaveTVProgress(s.tv); err != nil {
 			log.Printf("Error saving TV progress: %v\n", err)
 		}
@@ -0,0 +1,78 @@
+package showbox
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/amankumarsingh77/go-showbox-api/pkg/httpclient"
+)
+
+func TestCrawlMoviePageFromFixtures(t *testing.T) {
+	config := DefaultConfig()
+	config.BaseURL = "https://www.showbox.media"
+	config.Movies = true
+	config.RandomDelay = 0
+	config.HTTP = httpclient.Config{Mode: httpclient.ModeReplay, FixturesDir: "testdata/fixtures"}
+
+	scraper, err := NewScraper(config, nil)
+	if err != nil {
+		t.Fatalf("NewScraper: %v", err)
+	}
+	scraper.setupCallbacks()
+	if err := scraper.collector.Visit(config.BaseURL + "/movie?page=1"); err != nil {
+		t.Fatalf("Visit: %v", err)
+	}
+	scraper.activeJobs.Wait()
+
+	// Titles listed under related films aren't followed
+	movies := scraper.movies
+	if len(movies) != 2 {
+		t.Fatalf("got %d movies, want 2: %+v", len(movies), movies)
+	}
+	sort.Slice(movies, func(i, j int) bool { return movies[i].ID < movies[j].ID })
+
+	want := []Movie{
+		{
+			ID:          "1056",
+			Title:       "The Matrix",
+			Description: "A computer hacker learns about the true nature of reality.",
+			ReleaseDate: "1999-03-31",
+			Genre:       "Action, Science Fiction",
+			Casts:       "Keanu Reeves, Laurence Fishburne",
+			Duration:    "136 min",
+			Country:     "United States of America",
+			Production:  "Warner Bros. Pictures",
+			IMDBRating:  "8.7",
+			IMDbID:      "tt0133093",
+		},
+		{
+			ID:          "1057",
+			Title:       "Inception",
+			Description: "A thief who steals corporate secrets through dream-sharing technology.",
+			ReleaseDate: "2010-07-15",
+			Genre:       "Action, Thriller",
+			Duration:    "148 min",
+			IMDBRating:  "8.8",
+		},
+	}
+	for i, got := range movies {
+		got.ScrapedAt = want[i].ScrapedAt
+		if got != want[i] {
+			t.Errorf("movie %d:\n got %+v\nwant %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestParseIMDbID(t *testing.T) {
+	tests := map[string]string{
+		"https://www.imdb.com/title/tt0133093/":        "tt0133093",
+		"https://m.imdb.com/title/tt10872600/?ref_=nv": "tt10872600",
+		"https://www.imdb.com/find?q=matrix":           "",
+		"":                                             "",
+	}
+	for link, want := range tests {
+		if got := parseIMDbID(link); got != want {
+			t.Errorf("parseIMDbID(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
@@ -13,6 +13,7 @@ type Storage struct {
 	TempDir     string
 	FinalFile   string
 	TVFinalFile string
+	DryRun      bool // log what would be saved without writing any file
 }
 
 func NewStorage() *Storage {
@@ -24,6 +25,10 @@ func NewStorage() *Storage {
 }
 
 func (s *Storage) SaveTVProgress(tvShows []Tv) error {
+	if s.DryRun {
+		log.Printf("Dry run: not saving progress of %d TV shows\n", len(tvShows))
+		return nil
+	}
 	if err := os.MkdirAll(s.TempDir, 0755); err != nil {
 		return fmt.Errorf("failed to create temp directory: %v", err)
 	}
@@ -48,6 +53,10 @@ func (s *Storage) SaveTVProgress(tvShows []Tv) error {
 }
 
 func (s *Storage) SaveProgress(movies []Movie) error {
+	if s.DryRun {
+		log.Printf("Dry run: not saving progress of %d movies\n", len(movies))
+		return nil
+	}
 	if err := os.MkdirAll(s.TempDir, 0755); err != nil {
 		return fmt.Errorf("failed to create temp directory: %v", err)
 	}
@@ -72,6 +81,9 @@ func (s *Storage) SaveProgress(movies []Movie) error {
 }
 
 func (s *Storage) MergeMovieFiles() error {
+	if s.DryRun {
+		return nil
+	}
 	allMovies := make(map[string]Movie)
 
 	if _, err := os.Stat(s.FinalFile); err == nil {
@@ -135,6 +147,9 @@ func (s *Storage) MergeMovieFiles() error {
 }
 
 func (s *Storage) MergeTVFiles() error {
+	if s.DryRun {
+		return nil
+	}
 	allTVShows := make(map[string]Tv)
 
 	if _, err := os.Stat(s.TVFinalFile); err == nil {